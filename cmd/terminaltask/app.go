@@ -3,16 +3,31 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/term"
 	"github.com/jacobdanielrose/terminaltask/internal/app"
 	"github.com/jacobdanielrose/terminaltask/internal/config"
 	taskservice "github.com/jacobdanielrose/terminaltask/internal/service"
 	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/watch"
 )
 
 type CLIOptions struct {
 	ShowVersion bool
+
+	// Store is the URI or name of the store to use instead of the
+	// configured one.
+	Store string
+
+	// Command holds the subcommand and its arguments, such as
+	// ["backup", "list"]; it is empty when starting the TUI.
+	Command []string
 }
 
 func parseArgs(args []string) (CLIOptions, error) {
@@ -21,10 +36,12 @@ func parseArgs(args []string) (CLIOptions, error) {
 
 	var opts CLIOptions
 	fs.BoolVar(&opts.ShowVersion, "version", false, "print version and exit")
+	fs.StringVar(&opts.Store, "store", "", "store `uri` to keep tasks in, such as sqlite:///path/to/tasks.db")
 
 	if err := fs.Parse(args); err != nil {
 		return CLIOptions{}, err
 	}
+	opts.Command = fs.Args()
 
 	return opts, nil
 }
@@ -60,10 +77,27 @@ func (TeaProgramRunner) Run(model tea.Model) error {
 	return nil
 }
 
+// PassphraseReader asks the user for a passphrase with the given
+// prompt.
+type PassphraseReader func(prompt string) (string, error)
+
+// TerminalPassphrase reads a passphrase from the terminal without
+// echoing it.
+func TerminalPassphrase(prompt string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", errNoPassphrase
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return string(b), err
+}
+
 type AppEnv struct {
-	Printer       Printer
-	LoadConfig    ConfigLoader
-	ProgramRunner ProgramRunner
+	Printer        Printer
+	LoadConfig     ConfigLoader
+	ProgramRunner  ProgramRunner
+	ReadPassphrase PassphraseReader
 }
 
 type App struct {
@@ -81,6 +115,9 @@ func NewApp(env AppEnv) *App {
 	if env.ProgramRunner == nil {
 		env.ProgramRunner = TeaProgramRunner{}
 	}
+	if env.ReadPassphrase == nil {
+		env.ReadPassphrase = TerminalPassphrase
+	}
 	return &App{env: env}
 }
 
@@ -102,14 +139,86 @@ func (a *App) Run(args []string) error {
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
+	if opts.Store != "" {
+		cfg.Store = opts.Store
+	}
+
+	if len(opts.Command) > 0 {
+		return a.runCommand(cfg, opts.Command)
+	}
+
+	taskStore, closeStore, err := openStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer closeStore()
+
+	if cfg.Encrypt {
+		passphrase, err := a.passphrase(cfg, false)
+		if err != nil {
+			return err
+		}
+		taskStore = store.NewEncryptedTaskStore(taskStore, passphrase)
+	}
+	// Watch before loading, so no change made meanwhile goes unnoticed.
+	var changes <-chan struct{}
+	if fb, ok := taskStore.(store.FileBacked); ok && len(fb.Paths()) > 0 {
+		watcher := watch.New(fb.Paths()...)
+		defer watcher.Close()
+		changes = watcher.Changes()
+	}
 
-	taskStore := store.NewFileTaskStore(cfg.TasksFile)
-	taskService := taskservice.NewFileTaskService(taskStore)
-	model := app.NewModel(cfg, taskService)
+	tasks, err := checkEncryption(cfg, taskStore)
+	if err != nil {
+		return err
+	}
+
+	// Changes are written in the background, so the TUI never waits for
+	// the disk; what is still pending is written once it exits.
+	taskService := taskservice.NewCachedTaskService(taskStore, taskservice.DefaultWriteDelay)
+	if tasks != nil {
+		taskService.Prime(tasks)
+	}
+	model := app.NewModel(cfg, taskService, changes)
 
-	if err := a.env.ProgramRunner.Run(model); err != nil {
-		return fmt.Errorf("run program: %w", err)
+	runErr := a.env.ProgramRunner.Run(model)
+	if err := taskService.Close(); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("run program: %w", runErr)
 	}
 
 	return nil
 }
+
+// openStore opens the task store selected in cfg. The returned function
+// releases it once the program exits, which for the journal store folds
+// its journal back into the tasks file. Options left out of the store
+// URI are taken from cfg; the first time the SQLite store is used, it
+// imports the tasks in the JSON tasks file.
+func openStore(cfg config.Config) (store.TaskStore, func(), error) {
+	taskStore, err := store.Open(cfg.StoreURI(), storeDefaults(cfg))
+	if err != nil {
+		return nil, nil, err
+	}
+	closer, ok := taskStore.(io.Closer)
+	if !ok {
+		return taskStore, func() {}, nil
+	}
+	return taskStore, func() {
+		if err := closer.Close(); err != nil {
+			log.Error("closing store", "store", taskStore.Name(), "err", err)
+		}
+	}, nil
+}
+
+// storeDefaults returns the store options configured in cfg.
+func storeDefaults(cfg config.Config) url.Values {
+	return url.Values{
+		store.OptionBackups:        {strconv.Itoa(cfg.BackupKeep)},
+		store.OptionBackupInterval: {cfg.BackupInterval.String()},
+		store.OptionBackupDir:      {cfg.BackupDir},
+		store.OptionImport:         {cfg.TasksFile},
+	}
+}
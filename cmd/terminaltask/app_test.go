@@ -4,11 +4,18 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/app"
 	"github.com/jacobdanielrose/terminaltask/internal/config"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
 )
 
 // --- Test helpers ---
@@ -94,3 +101,414 @@ func TestProgramRunnerIsInvoked(t *testing.T) {
 		t.Fatalf("expected program runner to run once, ran %d times", fakeRunner.runs)
 	}
 }
+
+func TestSQLiteStoreImportsTasksFile(t *testing.T) {
+	dir := t.TempDir()
+	tasksFile := filepath.Join(dir, "tasks.json")
+	if err := os.WriteFile(tasksFile, []byte(`[{"TitleStr": "imported"}]`), 0o644); err != nil {
+		t.Fatalf("WriteFile error = %v", err)
+	}
+	cfg := config.Config{
+		TasksFile:    tasksFile,
+		DatabaseFile: filepath.Join(dir, "tasks.db"),
+		Store:        config.StoreSQLite,
+	}
+
+	taskStore, closeStore, err := openStore(cfg)
+	if err != nil {
+		t.Fatalf("openStore() error = %v", err)
+	}
+	defer closeStore()
+
+	tasks, err := taskStore.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title() != "imported" {
+		t.Fatalf("Load() = %+v, want the imported task", tasks)
+	}
+}
+
+func TestJournalStoreCompactsOnClose(t *testing.T) {
+	tasksFile := filepath.Join(t.TempDir(), "tasks.json")
+	cfg := config.Config{TasksFile: tasksFile, Store: config.StoreJournal}
+
+	taskStore, closeStore, err := openStore(cfg)
+	if err != nil {
+		t.Fatalf("openStore() error = %v", err)
+	}
+	if err := taskStore.Save([]task.Task{{ID: uuid.New(), TitleStr: "journaled"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	closeStore()
+
+	tasks, err := store.NewFileTaskStore(tasksFile).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title() != "journaled" {
+		t.Fatalf("Load() = %+v, want the journaled task in the tasks file", tasks)
+	}
+}
+
+func TestUnknownStoreIsRejected(t *testing.T) {
+	a := NewApp(AppEnv{
+		LoadConfig: func() (config.Config, error) {
+			return config.Config{TasksFile: "/tmp/tasks.json", Store: "floppy"}, nil
+		},
+		ProgramRunner: &fakeProgramRunner{},
+	})
+
+	err := a.Run([]string{})
+	if err == nil || !strings.Contains(err.Error(), `unknown store "floppy"`) {
+		t.Fatalf("expected unknown store error, got %v", err)
+	}
+}
+
+func TestStoreFlagOverridesConfig(t *testing.T) {
+	tasksFile := filepath.Join(t.TempDir(), "tasks.json")
+	a := NewApp(AppEnv{
+		LoadConfig: func() (config.Config, error) {
+			return config.Config{TasksFile: tasksFile, Store: config.StoreFile}, nil
+		},
+		ProgramRunner: &fakeProgramRunner{},
+	})
+
+	if err := a.Run([]string{"--store", "mem://"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(tasksFile); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("tasks file was touched with --store mem://: stat error = %v", err)
+	}
+
+	err := a.Run([]string{"--store", "mem://", "backup", "list"})
+	if err == nil || !strings.Contains(err.Error(), "file store only") {
+		t.Fatalf("backup list on the memory store: error = %v, want it refused", err)
+	}
+}
+
+func TestBackupListAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{
+		TasksFile:  filepath.Join(dir, "tasks.json"),
+		BackupDir:  filepath.Join(dir, "backups"),
+		BackupKeep: 5,
+		Store:      config.StoreFile,
+	}
+	fileStore := store.NewFileTaskStoreWithBackups(cfg.TasksFile, store.BackupPolicy{Dir: cfg.BackupDir, Keep: cfg.BackupKeep})
+	if err := fileStore.Save([]task.Task{{TitleStr: "a"}, {TitleStr: "b"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := fileStore.Save(nil); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	backups, err := fileStore.Backups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Backups() = %v, %v; want one backup", backups, err)
+	}
+
+	var out bytes.Buffer
+	a := NewApp(AppEnv{
+		Printer:       bufferPrinter{buf: &out},
+		LoadConfig:    func() (config.Config, error) { return cfg, nil },
+		ProgramRunner: &fakeProgramRunner{},
+	})
+
+	if err := a.Run([]string{"backup", "list"}); err != nil {
+		t.Fatalf("backup list error = %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, backups[0].ID) || !strings.Contains(got, "  2\n") {
+		t.Fatalf("backup list output = %q, want the backup with its 2 tasks", got)
+	}
+
+	out.Reset()
+	if err := a.Run([]string{"backup", "restore", backups[0].ID}); err != nil {
+		t.Fatalf("backup restore error = %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "Restored 2 tasks") {
+		t.Fatalf("backup restore output = %q", got)
+	}
+	tasks, err := store.NewFileTaskStore(cfg.TasksFile).Load()
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("Load() after restore = %v, %v; want 2 tasks", tasks, err)
+	}
+}
+
+func TestBackupUsageErrors(t *testing.T) {
+	cfg := config.Config{TasksFile: filepath.Join(t.TempDir(), "tasks.json"), Store: config.StoreFile}
+	a := NewApp(AppEnv{
+		LoadConfig:    func() (config.Config, error) { return cfg, nil },
+		ProgramRunner: &fakeProgramRunner{},
+	})
+
+	for _, args := range [][]string{{"backup"}, {"backup", "restore"}, {"frobnicate"}} {
+		if err := a.Run(args); !errors.Is(err, ErrUsage) {
+			t.Errorf("Run(%q) error = %v, want ErrUsage", args, err)
+		}
+	}
+}
+
+func TestHistoryCommands(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	cfg := config.Config{Store: "git://" + filepath.ToSlash(filepath.Join(t.TempDir(), "repo"))}
+	taskStore, closeStore, err := openStore(cfg)
+	if err != nil {
+		t.Fatalf("openStore() error = %v", err)
+	}
+	defer closeStore()
+
+	deploy := task.Task{ID: uuid.New(), TitleStr: "Deploy v2"}
+	if err := taskStore.Save([]task.Task{deploy}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := taskStore.Save(nil); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	a := NewApp(AppEnv{
+		Printer:       bufferPrinter{buf: &out},
+		LoadConfig:    func() (config.Config, error) { return cfg, nil },
+		ProgramRunner: &fakeProgramRunner{},
+	})
+
+	if err := a.Run([]string{"history"}); err != nil {
+		t.Fatalf("history error = %v", err)
+	}
+	for _, want := range []string{"Deleted: Deploy v2", "Added: Deploy v2"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("history output = %q, want it to mention %q", out.String(), want)
+		}
+	}
+
+	out.Reset()
+	if err := a.Run([]string{"history", "show", "HEAD~1"}); err != nil {
+		t.Fatalf("history show error = %v", err)
+	}
+	if !strings.Contains(out.String(), deploy.ID.String()) {
+		t.Fatalf("history show output = %q, want the deleted task", out.String())
+	}
+
+	out.Reset()
+	if err := a.Run([]string{"history", "restore", "HEAD~1", deploy.ID.String()[:8]}); err != nil {
+		t.Fatalf("history restore error = %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, `Restored "Deploy v2" from HEAD~1`) {
+		t.Fatalf("history restore output = %q", got)
+	}
+	tasks, err := taskStore.Load()
+	if err != nil || len(tasks) != 1 || tasks[0].ID != deploy.ID {
+		t.Fatalf("Load() after restore = %v, %v; want the restored task", tasks, err)
+	}
+
+	if err := a.Run([]string{"history", "restore"}); !errors.Is(err, ErrUsage) {
+		t.Fatalf("history restore without a commit: error = %v, want ErrUsage", err)
+	}
+}
+
+func TestEncryptAndDecryptCommands(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{
+		TasksFile: filepath.Join(dir, "tasks.json"),
+		BackupDir: filepath.Join(dir, "backups"),
+		Store:     config.StoreFile,
+	}
+	// Saving twice leaves a backup with the task in plain text.
+	plain := store.NewFileTaskStoreWithBackups(cfg.TasksFile, store.BackupPolicy{Dir: cfg.BackupDir, Keep: 5})
+	for range 2 {
+		if err := plain.Save([]task.Task{{ID: uuid.New(), TitleStr: "incident 42"}}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	var (
+		out     bytes.Buffer
+		prompts []string
+	)
+	a := NewApp(AppEnv{
+		Printer:       bufferPrinter{buf: &out},
+		LoadConfig:    func() (config.Config, error) { return cfg, nil },
+		ProgramRunner: &fakeProgramRunner{},
+		ReadPassphrase: func(prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			return "hunter2", nil
+		},
+	})
+
+	if err := a.Run([]string{"encrypt"}); err != nil {
+		t.Fatalf("encrypt error = %v", err)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("encrypt asked %d times, want the passphrase and its confirmation", len(prompts))
+	}
+	b, _ := os.ReadFile(cfg.TasksFile)
+	if strings.Contains(string(b), "incident 42") {
+		t.Fatalf("tasks file still holds plain text after encrypt")
+	}
+	backups, err := plain.Backups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Backups() = %v, %v; want the backup taken before encrypting", backups, err)
+	}
+	b, _ = os.ReadFile(backups[0].Path)
+	if strings.Contains(string(b), "incident 42") {
+		t.Fatalf("backup still holds plain text after encrypt")
+	}
+	if !strings.Contains(out.String(), "Encrypted 1 backups") {
+		t.Fatalf("encrypt output = %q, want the backups it encrypted", out.String())
+	}
+	if err := a.Run([]string{"encrypt"}); !errors.Is(err, errAlreadyEncrypted) {
+		t.Fatalf("second encrypt error = %v, want errAlreadyEncrypted", err)
+	}
+
+	// Starting without encryption refuses to show the sealed tasks.
+	if err := a.Run(nil); !errors.Is(err, errEncryptionTurnedOff) {
+		t.Fatalf("Run() without encryption error = %v, want errEncryptionTurnedOff", err)
+	}
+
+	// A passphrase from the environment is used without asking.
+	cfg.Encrypt, cfg.Passphrase = true, "wrong"
+	if err := a.Run(nil); !errors.Is(err, store.ErrWrongPassphrase) {
+		t.Fatalf("Run() with wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+	cfg.Passphrase = "hunter2"
+	if err := a.Run(nil); err != nil {
+		t.Fatalf("Run() with encryption error = %v", err)
+	}
+
+	if err := a.Run([]string{"decrypt"}); err != nil {
+		t.Fatalf("decrypt error = %v", err)
+	}
+	tasks, err := store.NewFileTaskStore(cfg.TasksFile).Load()
+	if err != nil || len(tasks) != 1 || tasks[0].Title() != "incident 42" {
+		t.Fatalf("Load() after decrypt = %+v, %v; want the plain task back", tasks, err)
+	}
+}
+
+func TestPassphraseFromKeyFile(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "tasks.key")
+	if err := os.WriteFile(keyFile, []byte("from file\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	a := NewApp(AppEnv{
+		ReadPassphrase: func(string) (string, error) {
+			t.Fatal("asked for a passphrase despite the key file")
+			return "", nil
+		},
+	})
+
+	got, err := a.passphrase(config.Config{KeyFile: keyFile}, true)
+	if err != nil || string(got) != "from file" {
+		t.Fatalf("passphrase() = %q, %v; want %q", got, err, "from file")
+	}
+}
+
+// keysRunner runs a program by pressing keys, running the commands they
+// return until the program quits.
+type keysRunner struct{ keys []string }
+
+func (r keysRunner) Run(m tea.Model) error {
+	for _, k := range r.keys {
+		var cmd tea.Cmd
+		m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		for cmd != nil {
+			msg := cmd()
+			if _, ok := msg.(tea.QuitMsg); ok {
+				return nil
+			}
+			m, cmd = m.Update(msg)
+		}
+	}
+	return nil
+}
+
+func TestMergeCommand(t *testing.T) {
+	dir := t.TempDir()
+	report := task.Task{ID: uuid.New(), TitleStr: "report"}
+	gym := task.Task{ID: uuid.New(), TitleStr: "gym"}
+	write := func(name string, tasks ...task.Task) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := store.WriteTasksFile(path, tasks); err != nil {
+			t.Fatalf("WriteTasksFile() error = %v", err)
+		}
+		return path
+	}
+
+	ourReport, theirReport, theirGym := report, report, gym
+	ourReport.TitleStr, theirReport.TitleStr = "Q1 report", "annual report"
+	theirGym.Done = true
+	base := write("base.json", report, gym)
+	ours := write("ours.json", ourReport, gym)
+	theirs := write("theirs.json", theirReport, theirGym)
+
+	run := func(runner ProgramRunner) error {
+		var out bytes.Buffer
+		a := NewApp(AppEnv{
+			Printer:       bufferPrinter{buf: &out},
+			LoadConfig:    func() (config.Config, error) { return config.Config{}, nil },
+			ProgramRunner: runner,
+		})
+		return a.Run([]string{"merge", base, ours, theirs})
+	}
+
+	// Quitting the resolver leaves ours alone.
+	if err := run(keysRunner{keys: []string{"q"}}); !errors.Is(err, app.ErrMergeAborted) {
+		t.Fatalf("aborted merge error = %v, want ErrMergeAborted", err)
+	}
+	if tasks, _ := store.ReadTasksFile(ours); tasks[0].TitleStr != "Q1 report" || tasks[1].Done {
+		t.Fatalf("aborted merge changed ours: %+v", tasks)
+	}
+
+	if err := run(keysRunner{keys: []string{"t"}}); err != nil {
+		t.Fatalf("merge error = %v", err)
+	}
+	tasks, err := store.ReadTasksFile(ours)
+	if err != nil {
+		t.Fatalf("ReadTasksFile() error = %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].TitleStr != "annual report" || !tasks[1].Done {
+		t.Fatalf("merged tasks = %+v, want their title and the gym done", tasks)
+	}
+
+	// Merging again changes nothing and asks nothing.
+	runner := &fakeProgramRunner{}
+	if err := run(runner); err != nil || runner.runs != 0 {
+		t.Fatalf("clean merge: error = %v, resolver runs = %d", err, runner.runs)
+	}
+
+	a := NewApp(AppEnv{LoadConfig: func() (config.Config, error) { return config.Config{}, nil }})
+	if err := a.Run([]string{"merge", base, ours}); !errors.Is(err, ErrUsage) {
+		t.Fatalf("merge with two files: error = %v, want ErrUsage", err)
+	}
+}
+
+func TestSyncNeedsCalendar(t *testing.T) {
+	cfg := config.Config{TasksFile: filepath.Join(t.TempDir(), "tasks.json"), Store: config.StoreFile}
+	a := NewApp(AppEnv{LoadConfig: func() (config.Config, error) { return cfg, nil }})
+
+	if err := a.Run([]string{"sync"}); !errors.Is(err, errNoCalendar) {
+		t.Fatalf("sync error = %v, want errNoCalendar", err)
+	}
+	if err := a.Run([]string{"sync", "now"}); !errors.Is(err, ErrUsage) {
+		t.Fatalf("sync now error = %v, want ErrUsage", err)
+	}
+}
+
+func TestSyncRefusesEncryptedTasks(t *testing.T) {
+	cfg := config.Config{
+		TasksFile: filepath.Join(t.TempDir(), "tasks.json"),
+		Store:     config.StoreFile,
+		CalDAVURL: "https://dav.example.com/calendars/me/tasks/",
+		Encrypt:   true,
+	}
+	a := NewApp(AppEnv{LoadConfig: func() (config.Config, error) { return cfg, nil }})
+
+	if err := a.Run([]string{"sync"}); !errors.Is(err, errSyncEncrypted) {
+		t.Fatalf("sync error = %v, want errSyncEncrypted", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/jacobdanielrose/terminaltask/internal/config"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+)
+
+const (
+	backupUsage     = "usage: terminaltask backup list | backup restore <id>"
+	backupTimestamp = "2006-01-02 15:04:05"
+)
+
+// ErrUsage is returned when a subcommand is called with the wrong
+// arguments.
+var ErrUsage = errors.New("invalid arguments")
+
+// runCommand runs the subcommand named by args[0] instead of the TUI.
+func (a *App) runCommand(cfg config.Config, args []string) error {
+	switch args[0] {
+	case "backup":
+		return a.runBackup(cfg, args[1:])
+	case "encrypt":
+		return a.runEncrypt(cfg, args[1:])
+	case "decrypt":
+		return a.runDecrypt(cfg, args[1:])
+	case "history":
+		return a.runHistory(cfg, args[1:])
+	case "merge":
+		return a.runMerge(args[1:])
+	case "sync":
+		return a.runSync(cfg, args[1:])
+	default:
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, args[0])
+	}
+}
+
+// runBackup lists the backups of the tasks file or restores one of
+// them.
+func (a *App) runBackup(cfg config.Config, args []string) error {
+	taskStore, closeStore, err := openStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer closeStore()
+	fileStore, ok := taskStore.(*store.FileTaskStore)
+	if !ok {
+		return fmt.Errorf("backups are kept by the file store only, not the %s", taskStore.Name())
+	}
+
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		backups, err := fileStore.Backups()
+		if err != nil {
+			return fmt.Errorf("list backups: %w", err)
+		}
+		if len(backups) == 0 {
+			a.env.Printer.Printf("No backups in %s\n", fileStore.BackupDir())
+			return nil
+		}
+
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0) //nolint:mnd
+		fmt.Fprintln(w, "ID\tTAKEN\tTASKS")
+		for _, b := range backups {
+			count := "unreadable"
+			if b.Tasks >= 0 {
+				count = fmt.Sprint(b.Tasks)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", b.ID, b.Created.Format(backupTimestamp), count)
+		}
+		_ = w.Flush()
+		a.env.Printer.Printf("%s", buf.String())
+		return nil
+
+	case len(args) == 2 && args[0] == "restore":
+		restored, err := fileStore.Restore(args[1])
+		if err != nil {
+			return fmt.Errorf("restore backup: %w", err)
+		}
+		a.env.Printer.Printf("Restored %d tasks from backup %s into %s\n",
+			restored.Tasks, restored.ID, fileStore.Paths()[0])
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %s", ErrUsage, backupUsage)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jacobdanielrose/terminaltask/internal/config"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	promptPassphrase        = "Passphrase: "
+	promptConfirmPassphrase = "Repeat passphrase: "
+)
+
+var (
+	errNoPassphrase = errors.New(
+		"no passphrase: set TERMINALTASK_PASSPHRASE or TERMINALTASK_KEY_FILE, or run in a terminal")
+	errEmptyPassphrase     = errors.New("passphrase is empty")
+	errPassphraseMismatch  = errors.New("passphrases do not match")
+	errAlreadyEncrypted    = errors.New("tasks are already encrypted")
+	errEncryptionTurnedOff = errors.New("tasks are encrypted; set TERMINALTASK_ENCRYPT=1 to open them")
+)
+
+// passphrase returns the passphrase for encrypted tasks: the one in
+// cfg, the contents of its key file, or else one the user types. With
+// confirm set, a typed passphrase has to be entered twice.
+func (a *App) passphrase(cfg config.Config, confirm bool) ([]byte, error) {
+	switch {
+	case cfg.Passphrase != "":
+		return []byte(cfg.Passphrase), nil
+
+	case cfg.KeyFile != "":
+		b, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read key file: %w", err)
+		}
+		b = bytes.TrimRight(b, "\r\n")
+		if len(b) == 0 {
+			return nil, fmt.Errorf("key file %s: %w", cfg.KeyFile, errEmptyPassphrase)
+		}
+		return b, nil
+	}
+
+	typed, err := a.env.ReadPassphrase(promptPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	if typed == "" {
+		return nil, errEmptyPassphrase
+	}
+	if confirm {
+		again, err := a.env.ReadPassphrase(promptConfirmPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		if again != typed {
+			return nil, errPassphraseMismatch
+		}
+	}
+	return []byte(typed), nil
+}
+
+// checkEncryption loads the tasks, failing early, before the TUI starts,
+// when they cannot be read because of how encryption is configured: a
+// wrong passphrase, plain-text tasks with encryption on, or encrypted
+// tasks with it off. It returns the tasks, so they need not be loaded
+// again, or nil after other load errors, which are left for the TUI to
+// report.
+func checkEncryption(cfg config.Config, taskStore store.TaskStore) ([]task.Task, error) {
+	tasks, err := taskStore.Load()
+	switch {
+	case errors.Is(err, store.ErrNotEncrypted):
+		return nil, fmt.Errorf("%w; run terminaltask encrypt first", err)
+	case errors.Is(err, store.ErrWrongPassphrase):
+		return nil, err
+	case err != nil:
+		return nil, nil
+	case !cfg.Encrypt && store.IsEncrypted(tasks):
+		return nil, errEncryptionTurnedOff
+	}
+	return tasks, nil
+}
+
+// runEncrypt encrypts the tasks of the configured store in place.
+func (a *App) runEncrypt(cfg config.Config, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: usage: terminaltask encrypt", ErrUsage)
+	}
+
+	inner, closeStore, err := openStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer closeStore()
+
+	tasks, err := inner.Load()
+	if err != nil {
+		return fmt.Errorf("load tasks: %w", err)
+	}
+	if store.IsEncrypted(tasks) {
+		return errAlreadyEncrypted
+	}
+
+	passphrase, err := a.passphrase(cfg, true)
+	if err != nil {
+		return err
+	}
+	// A backup taken now would keep the tasks in plain text.
+	target := inner
+	fileStore, isFile := inner.(*store.FileTaskStore)
+	if isFile {
+		target = fileStore.WithoutBackups()
+	}
+	encrypted := store.NewEncryptedTaskStore(target, passphrase)
+	if err := encrypted.Save(tasks); err != nil {
+		return fmt.Errorf("save tasks: %w", err)
+	}
+	a.env.Printer.Printf("Encrypted %d tasks. Set TERMINALTASK_ENCRYPT=1 to keep using them.\n", len(tasks))
+	if !isFile {
+		return nil
+	}
+
+	// Backups taken before would still hold the tasks in plain text.
+	n, unreadable, err := encrypted.SealBackups(fileStore)
+	if err != nil {
+		return fmt.Errorf("encrypt backups: %w", err)
+	}
+	if n > 0 {
+		a.env.Printer.Printf("Encrypted %d backups in %s.\n", n, fileStore.BackupDir())
+	}
+	for _, b := range unreadable {
+		a.env.Printer.Printf("Warning: could not read backup %s to encrypt it; remove it if it holds tasks in plain text.\n",
+			b.Path)
+	}
+	return nil
+}
+
+// runDecrypt turns encrypted tasks of the configured store back into
+// plain text.
+func (a *App) runDecrypt(cfg config.Config, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: usage: terminaltask decrypt", ErrUsage)
+	}
+
+	inner, closeStore, err := openStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer closeStore()
+
+	passphrase, err := a.passphrase(cfg, false)
+	if err != nil {
+		return err
+	}
+	tasks, err := store.NewEncryptedTaskStore(inner, passphrase).Load()
+	if err != nil {
+		return fmt.Errorf("load tasks: %w", err)
+	}
+	if err := inner.Save(tasks); err != nil {
+		return fmt.Errorf("save tasks: %w", err)
+	}
+
+	a.env.Printer.Printf("Decrypted %d tasks. Unset TERMINALTASK_ENCRYPT to keep using them.\n", len(tasks))
+	return nil
+}
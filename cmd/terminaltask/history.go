@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/config"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const historyUsage = "usage: terminaltask history [list] | history show <commit> | history restore <commit> [<task-id>]"
+
+var errAmbiguousTask = errors.New("task ID matches more than one task")
+
+// runHistory browses the commits of the git store and restores tasks
+// from them.
+func (a *App) runHistory(cfg config.Config, args []string) error {
+	taskStore, closeStore, err := openStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer closeStore()
+	repo, ok := taskStore.(*store.GitTaskStore)
+	if !ok {
+		return fmt.Errorf("history is kept by the git store only, not the %s", taskStore.Name())
+	}
+
+	switch {
+	case len(args) == 0 || len(args) == 1 && args[0] == "list":
+		revs, err := repo.History()
+		if err != nil {
+			return fmt.Errorf("list history: %w", err)
+		}
+		if len(revs) == 0 {
+			a.env.Printer.Printf("No history in %s yet\n", repo.Dir())
+			return nil
+		}
+
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0) //nolint:mnd
+		fmt.Fprintln(w, "COMMIT\tDATE\tAUTHOR\tCHANGE")
+		for _, r := range revs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Short(), r.Time.Local().Format(backupTimestamp), r.Author, r.Message)
+		}
+		_ = w.Flush()
+		a.env.Printer.Printf("%s", buf.String())
+		return nil
+
+	case len(args) == 2 && args[0] == "show":
+		tasks, err := repo.TasksAt(args[1])
+		if err != nil {
+			return fmt.Errorf("show %s: %w", args[1], err)
+		}
+
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0) //nolint:mnd
+		fmt.Fprintln(w, "ID\tDONE\tTITLE")
+		for _, t := range tasks {
+			done := ""
+			if t.Done {
+				done = "x"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.ID, done, t.Title())
+		}
+		_ = w.Flush()
+		a.env.Printer.Printf("%s", buf.String())
+		return nil
+
+	case len(args) == 2 && args[0] == "restore":
+		n, err := repo.Restore(args[1])
+		if err != nil {
+			return fmt.Errorf("restore %s: %w", args[1], err)
+		}
+		a.env.Printer.Printf("Restored %d tasks from %s\n", n, args[1])
+		return nil
+
+	case len(args) == 3 && args[0] == "restore":
+		tasks, err := repo.TasksAt(args[1])
+		if err != nil {
+			return fmt.Errorf("restore %s: %w", args[1], err)
+		}
+		id, err := matchTaskID(tasks, args[2])
+		if err != nil {
+			return fmt.Errorf("restore %s: %w", args[1], err)
+		}
+		restored, err := repo.RestoreTask(args[1], id)
+		if err != nil {
+			return fmt.Errorf("restore %s: %w", args[1], err)
+		}
+		a.env.Printer.Printf("Restored %q from %s\n", restored.Title(), args[1])
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %s", ErrUsage, historyUsage)
+	}
+}
+
+// matchTaskID returns the ID of the one task whose ID starts with
+// prefix, so IDs can be shortened as long as they stay unique.
+func matchTaskID(tasks []task.Task, prefix string) (uuid.UUID, error) {
+	var match uuid.UUID
+	for _, t := range tasks {
+		if !strings.HasPrefix(t.ID.String(), strings.ToLower(prefix)) {
+			continue
+		}
+		if match != uuid.Nil {
+			return uuid.Nil, fmt.Errorf("%w: %s", errAmbiguousTask, prefix)
+		}
+		match = t.ID
+	}
+	if match == uuid.Nil {
+		return uuid.Nil, fmt.Errorf("%w: %s", store.ErrTaskNotInRevision, prefix)
+	}
+	return match, nil
+}
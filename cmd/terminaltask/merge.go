@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/jacobdanielrose/terminaltask/internal/app"
+	"github.com/jacobdanielrose/terminaltask/internal/merge"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+)
+
+const mergeUsage = "usage: terminaltask merge <base> <ours> <theirs>"
+
+// runMerge merges two tasks files that diverged from a common base,
+// asking how to resolve any conflicts, and writes the result to ours.
+// The arguments are those git passes to a merge driver (%O %A %B).
+func (a *App) runMerge(args []string) error {
+	if len(args) != 3 { //nolint:mnd
+		return fmt.Errorf("%w: %s", ErrUsage, mergeUsage)
+	}
+	basePath, oursPath, theirsPath := args[0], args[1], args[2]
+
+	base, err := store.ReadTasksFile(basePath)
+	if err != nil {
+		return fmt.Errorf("read base: %w", err)
+	}
+	ours, err := store.ReadTasksFile(oursPath)
+	if err != nil {
+		return fmt.Errorf("read ours: %w", err)
+	}
+	theirs, err := store.ReadTasksFile(theirsPath)
+	if err != nil {
+		return fmt.Errorf("read theirs: %w", err)
+	}
+
+	result := merge.Merge(base, ours, theirs)
+	if len(result.Conflicts) > 0 {
+		resolver := app.NewResolverModel(&result, filepath.Base(oursPath), filepath.Base(theirsPath))
+		if err := a.env.ProgramRunner.Run(resolver); err != nil {
+			return fmt.Errorf("resolve conflicts: %w", err)
+		}
+		if err := resolver.Err(); err != nil {
+			return err
+		}
+	}
+
+	tasks, err := result.Resolved()
+	if err != nil {
+		return err
+	}
+	if err := store.WriteTasksFile(oursPath, tasks); err != nil {
+		return fmt.Errorf("write %s: %w", oursPath, err)
+	}
+	a.env.Printer.Printf("Merged %s into %s: %d changes, %d conflicts resolved\n",
+		theirsPath, oursPath, result.Changes, len(result.Conflicts))
+	return nil
+}
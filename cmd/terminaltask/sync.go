@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jacobdanielrose/terminaltask/internal/caldav"
+	"github.com/jacobdanielrose/terminaltask/internal/config"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+)
+
+const (
+	syncUsage = "usage: terminaltask sync [--plaintext]"
+
+	// syncPlaintextFlag lets sync send encrypted tasks to the server.
+	syncPlaintextFlag = "--plaintext"
+
+	// syncRequestTimeout bounds each request to the CalDAV server.
+	syncRequestTimeout = 30 * time.Second
+)
+
+var (
+	errNoCalendar = errors.New("no calendar to sync with: set TERMINALTASK_CALDAV_URL")
+
+	errSyncEncrypted = errors.New("the tasks are encrypted, but the calendar would receive them in plain text; " +
+		"run terminaltask sync " + syncPlaintextFlag + " to sync anyway")
+)
+
+// runSync syncs the tasks with the CalDAV calendar configured in cfg.
+// The server gets the tasks in plain text, so encrypted tasks are only
+// synced when args ask for it.
+func (a *App) runSync(cfg config.Config, args []string) error {
+	plaintext := len(args) == 1 && args[0] == syncPlaintextFlag
+	if len(args) != 0 && !plaintext {
+		return fmt.Errorf("%w: %s", ErrUsage, syncUsage)
+	}
+	if cfg.CalDAVURL == "" {
+		return errNoCalendar
+	}
+	if cfg.Encrypt && !plaintext {
+		return errSyncEncrypted
+	}
+	client, err := caldav.NewClient(cfg.CalDAVURL, cfg.CalDAVUser, cfg.CalDAVPassword)
+	if err != nil {
+		return err
+	}
+	client.HTTP = &http.Client{Timeout: syncRequestTimeout}
+
+	taskStore, closeStore, err := openStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer closeStore()
+	if cfg.Encrypt {
+		passphrase, err := a.passphrase(cfg, false)
+		if err != nil {
+			return err
+		}
+		taskStore = store.NewEncryptedTaskStore(taskStore, passphrase)
+	}
+
+	syncer := &caldav.Syncer{Client: client, Store: taskStore, StatePath: cfg.SyncStateFile}
+	r, err := syncer.Sync(context.Background())
+	if err != nil {
+		return fmt.Errorf("sync with %s: %w", cfg.CalDAVURL, err)
+	}
+
+	a.env.Printer.Printf("Synced with %s: %d sent, %d received, %d deleted there, %d deleted here, %d merged\n",
+		client.Collection(), r.Sent, r.Received, r.DeletedRemote, r.DeletedLocal, r.Merged)
+	if r.Deferred > 0 {
+		a.env.Printer.Printf("%d tasks changed on the server meanwhile; sync again to merge them\n", r.Deferred)
+	}
+	return nil
+}
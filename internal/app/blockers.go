@@ -0,0 +1,97 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/google/uuid"
+	taskservice "github.com/jacobdanielrose/terminaltask/internal/service"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	statusMsgBlockedTask    = "\"%s\" is blocked by \"%s\""
+	statusMsgClearedBlocked = "Cleared blockers of \"%s\""
+	statusMsgBlockCycle     = "Error: %s"
+)
+
+// pickBlocker starts choosing a blocker for the selected task. The
+// user moves the cursor to the blocking task and confirms with enter.
+func (m Model) pickBlocker() (tea.Model, tea.Cmd) {
+	t, ok := m.list.SelectedItem().(task.Task)
+	if !ok {
+		return m, nil
+	}
+	m.blockerFor = t.GetID()
+	m.list.Title = fmt.Sprintf(listTitlePickBlocker, t.Title())
+	m.list.Styles.Title = m.styles.List.Title
+	return m, nil
+}
+
+// stopPickingBlocker leaves blocker picking mode.
+func (m Model) stopPickingBlocker() Model {
+	m.blockerFor = uuid.Nil
+	return m.refreshTitle()
+}
+
+// addBlocker links the task a blocker is being picked for to the
+// selected task.
+func (m Model) addBlocker() (Model, tea.Cmd) {
+	dependentID := m.blockerFor
+	m = m.stopPickingBlocker()
+
+	blocker, ok := m.list.SelectedItem().(task.Task)
+	if !ok {
+		return m, nil
+	}
+	dependent, ok := m.findTask(dependentID)
+	if !ok {
+		return m, nil
+	}
+	if !dependent.AddBlocker(blocker.GetID()) {
+		return m, nil
+	}
+
+	return m.saveDependencies(dependent, fmt.Sprintf(statusMsgBlockedTask, dependent.Title(), blocker.Title()))
+}
+
+// clearBlockers removes every blocked-by link from the selected task.
+func (m Model) clearBlockers() (tea.Model, tea.Cmd) {
+	t, ok := m.list.SelectedItem().(task.Task)
+	if !ok || len(t.BlockedBy) == 0 {
+		return m, nil
+	}
+	t.BlockedBy = nil
+	return m.saveDependencies(t, fmt.Sprintf(statusMsgClearedBlocked, t.Title()))
+}
+
+// saveDependencies persists a task whose blocked-by links changed and
+// updates it in the list. Dependency cycles rejected by the service are
+// reported in the status bar.
+func (m Model) saveDependencies(t task.Task, statusText string) (Model, tea.Cmd) {
+	saved, err := m.service.UpsertTask(t)
+	if err != nil {
+		log.Error("Error saving task dependencies", "err", err, "store", m.service.Name())
+		if errors.Is(err, taskservice.ErrDependencyCycle) {
+			status := fmt.Sprintf(statusMsgBlockCycle, err)
+			return m, m.list.NewStatusMessage(m.renderErrorStatus(status))
+		}
+		return m, m.saveErrorStatus(err, statusMsgSaveError)
+	}
+
+	m = m.putTask(saved)
+	return m, m.list.NewStatusMessage(m.renderSuccessStatus(statusText))
+}
+
+// removeBlockerFromTasks drops links to a deleted task from the tasks
+// that remain, mirroring what the service did in the store.
+func (m Model) removeBlockerFromTasks(id uuid.UUID) Model {
+	for _, t := range m.tasks {
+		if t.RemoveBlocker(id) {
+			m = m.putTask(t)
+		}
+	}
+	return m
+}
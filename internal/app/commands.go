@@ -2,6 +2,7 @@ package app
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+	taskservice "github.com/jacobdanielrose/terminaltask/internal/service"
 	task "github.com/jacobdanielrose/terminaltask/internal/task"
 )
 
@@ -26,3 +27,51 @@ func (m Model) loadTasksCmd() tea.Cmd {
 		return TasksLoadedMsg{Tasks: tasks}
 	}
 }
+
+// waitForChangesCmd returns a command that waits for the next change
+// made to the tasks by another program. It returns nil when changes are
+// not watched.
+func (m Model) waitForChangesCmd() tea.Cmd {
+	if m.changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-m.changes; !ok {
+			return nil
+		}
+		return TasksChangedMsg{}
+	}
+}
+
+// waitForWriteErrorCmd returns a command that waits for the next
+// change the service failed to write in the background. It returns nil
+// when the service writes changes before returning.
+func (m Model) waitForWriteErrorCmd() tea.Cmd {
+	bw, ok := m.service.(taskservice.BackgroundWriter)
+	if !ok {
+		return nil
+	}
+	errs := bw.WriteErrors()
+	return func() tea.Msg {
+		err, ok := <-errs
+		if !ok {
+			return nil
+		}
+		return TasksWriteErrorMsg{Err: err}
+	}
+}
+
+// toggleCompletedCmd returns a command that toggles the completion
+// state of the given task through the service.
+func (m Model) toggleCompletedCmd(t task.Task) tea.Cmd {
+	return func() tea.Msg {
+		updated, err := m.service.ToggleCompleted(t)
+		if err != nil {
+			return TasksSaveErrorMsg{Err: err}
+		}
+		return TaskToggledMsg{
+			Task:     updated,
+			Recurred: t.IsRecurring() && updated.Done,
+		}
+	}
+}
@@ -17,7 +17,8 @@ type commandsFakeService struct {
 	loadTasksFn  func() ([]task.Task, error)
 	toggleFn     func(t task.Task) (task.Task, error)
 	deleteByIDFn func(id uuid.UUID) error
-	upsertFn     func(t task.Task) error
+	upsertFn     func(t task.Task) (task.Task, error)
+	moveFn       func(id uuid.UUID, list string) (task.Task, error)
 	nameFn       func() string
 }
 
@@ -52,11 +53,18 @@ func (f *commandsFakeService) DeleteByID(id uuid.UUID) error {
 	return nil
 }
 
-func (f *commandsFakeService) UpsertTask(t task.Task) error {
+func (f *commandsFakeService) UpsertTask(t task.Task) (task.Task, error) {
 	if f.upsertFn != nil {
 		return f.upsertFn(t)
 	}
-	return nil
+	return t, nil
+}
+
+func (f *commandsFakeService) MoveToList(id uuid.UUID, list string) (task.Task, error) {
+	if f.moveFn != nil {
+		return f.moveFn(id, list)
+	}
+	return task.Task{ID: id, List: list}, nil
 }
 
 func (f *commandsFakeService) Name() string {
@@ -196,3 +204,37 @@ func TestLoadTasksCmd_Error(t *testing.T) {
 		t.Errorf("TasksLoadErrorMsg.Err = %v, want %v", errMsg.Err, loadErr)
 	}
 }
+
+// writingFakeService is a commandsFakeService that writes in the
+// background and reports failures on errs.
+type writingFakeService struct {
+	commandsFakeService
+	errs chan error
+}
+
+func (f *writingFakeService) WriteErrors() <-chan error {
+	return f.errs
+}
+
+func TestWaitForWriteErrorCmd(t *testing.T) {
+	m := Model{service: &commandsFakeService{}}
+	if cmd := m.waitForWriteErrorCmd(); cmd != nil {
+		t.Fatalf("waitForWriteErrorCmd() = non-nil for a service that writes right away")
+	}
+
+	writeErr := errors.New("disk full")
+	svc := &writingFakeService{errs: make(chan error, 1)}
+	m = newRepairTestModel(&svc.commandsFakeService)
+	m.service = svc
+	svc.errs <- writeErr
+
+	out := m.waitForWriteErrorCmd()()
+	msg, ok := out.(TasksWriteErrorMsg)
+	if !ok || msg.Err != writeErr {
+		t.Fatalf("waitForWriteErrorCmd() message = %#v, want TasksWriteErrorMsg{%v}", out, writeErr)
+	}
+
+	if _, cmd := m.Update(msg); cmd == nil {
+		t.Fatalf("Update(TasksWriteErrorMsg) returned nil cmd, want one that keeps listening")
+	}
+}
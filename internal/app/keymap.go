@@ -7,7 +7,26 @@ import (
 // listKeyMap defines key bindings for interacting with the task list.
 type listKeyMap struct {
 	NewItem key.Binding
+	Sort    key.Binding
 	Quit    key.Binding
+
+	// NextList, PrevList, and JumpList switch between task lists, and
+	// MoveNext and MovePrev move the selected task to a neighbouring
+	// list.
+	NextList key.Binding
+	PrevList key.Binding
+	JumpList key.Binding
+	MoveNext key.Binding
+	MovePrev key.Binding
+
+	// ConfirmBlocker and CancelBlocker finish picking a blocker.
+	ConfirmBlocker key.Binding
+	CancelBlocker  key.Binding
+
+	// OpenRepaired and KeepDamaged answer whether to open the tasks
+	// recovered from a damaged tasks file.
+	OpenRepaired key.Binding
+	KeepDamaged  key.Binding
 }
 
 // NewListKeyMap constructs the default key bindings for the list view.
@@ -17,9 +36,80 @@ func NewListKeyMap() *listKeyMap {
 			key.WithKeys("n"),
 			key.WithHelp("n", "new item"),
 		),
+		Sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "cycle sort"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("ctrl+c"),
 			key.WithHelp("ctrl+c", "quit"),
 		),
+		NextList: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "next list"),
+		),
+		PrevList: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "previous list"),
+		),
+		JumpList: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1-9", "go to list"),
+		),
+		MoveNext: key.NewBinding(
+			key.WithKeys(">"),
+			key.WithHelp(">", "move to next list"),
+		),
+		MovePrev: key.NewBinding(
+			key.WithKeys("<"),
+			key.WithHelp("<", "move to previous list"),
+		),
+		ConfirmBlocker: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "choose blocker"),
+		),
+		CancelBlocker: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		OpenRepaired: key.NewBinding(
+			key.WithKeys("y", "enter"),
+			key.WithHelp("y", "open recovered tasks"),
+		),
+		KeepDamaged: key.NewBinding(
+			key.WithKeys("n", "esc", "q"),
+			key.WithHelp("n", "quit and fix the file by hand"),
+		),
+	}
+}
+
+// resolverKeyMap defines key bindings for resolving merge conflicts.
+type resolverKeyMap struct {
+	KeepOurs   key.Binding
+	TakeTheirs key.Binding
+	Back       key.Binding
+	Abort      key.Binding
+}
+
+// newResolverKeyMap constructs the default key bindings for resolving
+// merge conflicts.
+func newResolverKeyMap() *resolverKeyMap {
+	return &resolverKeyMap{
+		KeepOurs: key.NewBinding(
+			key.WithKeys("o", "left", "1"),
+			key.WithHelp("o", "keep ours"),
+		),
+		TakeTheirs: key.NewBinding(
+			key.WithKeys("t", "right", "2"),
+			key.WithHelp("t", "take theirs"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("backspace", "up"),
+			key.WithHelp("backspace", "previous conflict"),
+		),
+		Abort: key.NewBinding(
+			key.WithKeys("esc", "q"),
+			key.WithHelp("q", "quit without merging"),
+		),
 	}
 }
@@ -0,0 +1,211 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	statusMsgSwitchedList = "List: %s"
+	statusMsgMovedTask    = "Moved \"%s\" to %s"
+	statusMsgOnlyOneList  = "Set a task's list in the edit menu to create another list"
+	statusMsgMoveError    = "Error moving task!"
+
+	// maxListShortcuts is the number of lists reachable with the 1-9
+	// number keys.
+	maxListShortcuts = 9
+)
+
+// lists returns the names of every task list, including the current
+// one even when it has no tasks left.
+func (m Model) lists() []string {
+	lists := task.CollectLists(m.tasks)
+	if current := m.listTitle(); !slices.Contains(lists, current) {
+		lists = append(lists, current)
+	}
+	return lists
+}
+
+// listTitle returns the display name of the list being shown.
+func (m Model) listTitle() string {
+	return task.Task{List: m.listName}.ListName()
+}
+
+// findTask looks a task up by ID across every list.
+func (m Model) findTask(id uuid.UUID) (task.Task, bool) {
+	if i := slices.IndexFunc(m.tasks, func(t task.Task) bool { return t.GetID() == id }); i >= 0 {
+		return m.tasks[i], true
+	}
+	if i := m.indexOfTask(id); i >= 0 {
+		return itemToTask(m.list.Items()[i]), true
+	}
+	return task.Task{}, false
+}
+
+// setTasks replaces every task the model knows about and shows the ones
+// in the current list, keeping the cursor on the task that was
+// selected before.
+func (m Model) setTasks(tasks []task.Task) (Model, tea.Cmd) {
+	var selected uuid.UUID
+	if t, ok := m.list.SelectedItem().(task.Task); ok {
+		selected = t.GetID()
+	}
+
+	m.tasks = tasks
+	visible := sortTasks(task.TasksInList(tasks, m.listName), m.sort)
+	cmd := m.setItems(visible)
+	m = m.selectTask(selected).refreshDelegate().refreshTitle()
+	return m, cmd
+}
+
+// putTask records a new or changed task. The list view is updated to
+// match: the task is replaced or appended when it belongs to the
+// current list, and removed when it was moved to another one.
+func (m Model) putTask(t task.Task) Model {
+	m.tasks = slices.Clone(m.tasks)
+	if i := slices.IndexFunc(m.tasks, func(other task.Task) bool { return other.GetID() == t.GetID() }); i >= 0 {
+		m.tasks[i] = t
+	} else {
+		m.tasks = append(m.tasks, t)
+	}
+
+	index := m.indexOfTask(t.GetID())
+	switch {
+	case !t.InList(m.listName):
+		if index >= 0 {
+			m.list.RemoveItem(index)
+		}
+	case index >= 0:
+		m.list.SetItem(index, t)
+	default:
+		m.list.InsertItem(len(m.list.Items()), t)
+	}
+	return m.refreshFilter().refreshDelegate().refreshTitle()
+}
+
+// dropTask forgets a deleted task.
+func (m Model) dropTask(id uuid.UUID) Model {
+	m.tasks = slices.DeleteFunc(slices.Clone(m.tasks), func(t task.Task) bool {
+		return t.GetID() == id
+	})
+	if index := m.indexOfTask(id); index >= 0 {
+		m.list.RemoveItem(index)
+	}
+	return m.refreshFilter().refreshDelegate().refreshTitle()
+}
+
+// setItems makes tasks, in order, the list items.
+func (m *Model) setItems(tasks []task.Task) tea.Cmd {
+	// The filter is set first: changing the items refilters them.
+	m.list.Filter = task.FilterTasks(tasks)
+	return m.list.SetItems(tasksToItems(tasks))
+}
+
+// refreshDelegate gives the list a delegate that knows every task, so
+// tasks blocked by one in another list are shown as blocked.
+func (m Model) refreshDelegate() Model {
+	m.list.SetDelegate(task.NewTaskDelegate().SetShowSubtasks(m.showSubtasks).SetTasks(m.tasks))
+	return m
+}
+
+// refreshFilter lets the list filter match the current list items by
+// their fields, such as when they were completed.
+func (m Model) refreshFilter() Model {
+	m.list.Filter = task.FilterTasks(itemsToTasks(m.list.Items()))
+	return m
+}
+
+// switchList shows the list at the given index among m.lists.
+func (m Model) switchList(index int) (Model, tea.Cmd) {
+	lists := m.lists()
+	if index < 0 || index >= len(lists) {
+		return m, nil
+	}
+
+	m.listName = task.NormalizeList(lists[index])
+	m.list.ResetFilter()
+	m, cmd := m.setTasks(m.tasks)
+	m.list.Select(0)
+	return m, tea.Batch(cmd, m.list.NewStatusMessage(
+		m.renderSuccessStatus(fmt.Sprintf(statusMsgSwitchedList, m.listTitle())),
+	))
+}
+
+// cycleList moves delta lists to the right (or left, when negative) of
+// the current one, wrapping around.
+func (m Model) cycleList(delta int) (Model, tea.Cmd) {
+	lists := m.lists()
+	current := slices.Index(lists, m.listTitle())
+	return m.switchList((current + delta + len(lists)) % len(lists))
+}
+
+// moveTask moves the selected task delta lists to the right (or left,
+// when negative) of the current one.
+func (m Model) moveTask(delta int) (Model, tea.Cmd) {
+	t, ok := m.list.SelectedItem().(task.Task)
+	if !ok {
+		return m, nil
+	}
+
+	lists := m.lists()
+	if len(lists) < 2 { //nolint:mnd
+		return m, m.list.NewStatusMessage(m.renderErrorStatus(statusMsgOnlyOneList))
+	}
+	current := slices.Index(lists, t.ListName())
+	target := lists[(current+delta+len(lists))%len(lists)]
+
+	moved, err := m.service.MoveToList(t.GetID(), target)
+	if err != nil {
+		log.Error("Error moving task", "err", err, "store", m.service.Name())
+		return m, m.saveErrorStatus(err, statusMsgMoveError)
+	}
+
+	m = m.putTask(moved)
+	return m, m.list.NewStatusMessage(
+		m.renderSuccessStatus(fmt.Sprintf(statusMsgMovedTask, moved.Title(), moved.ListName())),
+	)
+}
+
+// refreshTitle shows tabs for every list in the list title once there
+// is more than one list, and the plain application title otherwise.
+func (m Model) refreshTitle() Model {
+	if m.blockerFor != uuid.Nil {
+		return m
+	}
+
+	lists := m.lists()
+	if len(lists) < 2 { //nolint:mnd
+		m.list.Title = listModelTitle
+		m.list.Styles.Title = m.styles.List.Title
+		return m
+	}
+
+	current := m.listTitle()
+	tabs := make([]string, len(lists))
+	for i, name := range lists {
+		label := name
+		if i < maxListShortcuts {
+			label = fmt.Sprintf("%d %s", i+1, name)
+		}
+		if name == current {
+			tabs[i] = m.styles.List.Title.Render(label)
+		} else {
+			tabs[i] = m.styles.List.Tab.Render(label)
+		}
+	}
+	m.list.Title = strings.Join(tabs, " ")
+	m.list.Styles.Title = m.styles.List.Tabs
+	return m
+}
+
+// listShortcut returns the index of the list selected by one of the
+// JumpList number keys.
+func listShortcut(msg tea.KeyMsg) int {
+	return int(msg.String()[0] - '1')
+}
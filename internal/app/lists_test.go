@@ -0,0 +1,132 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+	"github.com/jacobdanielrose/terminaltask/internal/task/editmenu"
+)
+
+func newListsTestModel(svc *commandsFakeService, tasks []task.Task) Model {
+	l := list.New(nil, task.NewTaskDelegate(), 80, 40)
+	m := Model{list: l, service: svc, styles: newAppStyles(), keymap: NewListKeyMap()}
+	m, _ = m.setTasks(tasks)
+	return m
+}
+
+func visibleTitles(m Model) []string {
+	var titles []string
+	for _, t := range itemsToTasks(m.list.Items()) {
+		titles = append(titles, t.Title())
+	}
+	return titles
+}
+
+func TestSetTasks_ShowsOnlyCurrentList(t *testing.T) {
+	m := newListsTestModel(&commandsFakeService{}, []task.Task{
+		{ID: uuid.New(), TitleStr: "inbox"},
+		{ID: uuid.New(), TitleStr: "standup", List: "Work"},
+	})
+
+	if got := visibleTitles(m); len(got) != 1 || got[0] != "inbox" {
+		t.Fatalf("visible tasks = %v, want [inbox]", got)
+	}
+	if !contains(m.list.Title, "2 Work") {
+		t.Fatalf("list title = %q, want tabs including %q", m.list.Title, "2 Work")
+	}
+
+	m, _ = m.stateListUpdate(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	if got := visibleTitles(m); len(got) != 1 || got[0] != "standup" {
+		t.Fatalf("visible tasks after pressing 2 = %v, want [standup]", got)
+	}
+
+	m, _ = m.stateListUpdate(tea.KeyMsg{Type: tea.KeyTab})
+	if m.listName != "" {
+		t.Fatalf("listName after tab = %q, want the default list", m.listName)
+	}
+}
+
+func TestMoveTask_MovesToNextList(t *testing.T) {
+	inbox := task.Task{ID: uuid.New(), TitleStr: "inbox"}
+	work := task.Task{ID: uuid.New(), TitleStr: "standup", List: "Work"}
+
+	var movedTo string
+	svc := &commandsFakeService{
+		moveFn: func(id uuid.UUID, list string) (task.Task, error) {
+			movedTo = list
+			moved := inbox
+			moved.List = task.NormalizeList(list)
+			return moved, nil
+		},
+	}
+	m := newListsTestModel(svc, []task.Task{inbox, work})
+
+	m, _ = m.stateListUpdate(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(">")})
+	if movedTo != "Work" {
+		t.Fatalf("moved to %q, want %q", movedTo, "Work")
+	}
+	if got := visibleTitles(m); len(got) != 0 {
+		t.Fatalf("visible tasks = %v, want the moved task gone from the default list", got)
+	}
+
+	m, _ = m.cycleList(1)
+	if got := visibleTitles(m); len(got) != 2 {
+		t.Fatalf("Work list shows %v, want both tasks", got)
+	}
+}
+
+func TestSaveTask_NewTaskInOtherListLeavesView(t *testing.T) {
+	m := newListsTestModel(&commandsFakeService{}, nil)
+
+	m, _ = m.saveTask(editmenu.SaveTaskMsg{Title: "errand", List: "Personal", IsNew: true})
+	if got := visibleTitles(m); len(got) != 0 {
+		t.Fatalf("visible tasks = %v, want none in the default list", got)
+	}
+	if lists := m.lists(); len(lists) != 2 || lists[1] != "Personal" {
+		t.Fatalf("lists() = %v, want the new Personal list", lists)
+	}
+}
+
+func TestFilter_MatchesListItemsByTimestamps(t *testing.T) {
+	now := time.Now()
+	done := task.Task{ID: uuid.New(), TitleStr: "shipped", Done: true, CompletedAt: now}
+	m := newListsTestModel(&commandsFakeService{}, []task.Task{
+		{ID: uuid.New(), TitleStr: "open"},
+		done,
+	})
+	m = m.dropTask(m.list.Items()[0].(task.Task).GetID()).putTask(task.Task{ID: uuid.New(), TitleStr: "later"})
+
+	items := itemsToTasks(m.list.Items())
+	targets := make([]string, len(items))
+	for i, tk := range items {
+		targets[i] = tk.FilterValue()
+	}
+	ranks := m.list.Filter("done:today", targets)
+	if len(ranks) != 1 || items[ranks[0].Index].GetID() != done.GetID() {
+		t.Fatalf("filter done:today over %v = %+v, want only %q", visibleTitles(m), ranks, done.Title())
+	}
+}
+
+func TestSetTasks_ShowsBlockersFromOtherLists(t *testing.T) {
+	spec := task.Task{ID: uuid.New(), TitleStr: "spec", List: "Work"}
+	build := task.Task{ID: uuid.New(), TitleStr: "build", BlockedBy: []uuid.UUID{spec.ID}}
+	m := newListsTestModel(&commandsFakeService{}, []task.Task{spec, build})
+
+	if view := m.list.View(); !contains(view, "blocked by spec") {
+		t.Fatalf("list view = %q, want build shown as blocked by spec", view)
+	}
+
+	// Expanding subtasks keeps the delegate knowing every task.
+	updated, _ := m.Update(task.ToggleSubtasksMsg{})
+	m = updated.(Model)
+	if !m.showSubtasks {
+		t.Fatalf("showSubtasks = false after toggling, want true")
+	}
+	if view := m.list.View(); !contains(view, "blocked by spec") {
+		t.Fatalf("list view = %q, want build still shown as blocked", view)
+	}
+}
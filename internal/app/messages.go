@@ -8,8 +8,31 @@ type TasksSavedMsg struct{ msg string }
 // TasksSaveErrorMsg indicates an error occurred while saving tasks.
 type TasksSaveErrorMsg struct{ Err error }
 
+// TasksWriteErrorMsg indicates the service failed to write changes in
+// the background, after reporting them done.
+type TasksWriteErrorMsg struct{ Err error }
+
 // TasksLoadedMsg carries tasks loaded from the service.
 type TasksLoadedMsg struct{ Tasks []task.Task }
 
+// TasksChangedMsg indicates the tasks were changed by another program
+// and need to be reloaded.
+type TasksChangedMsg struct{}
+
 // TasksLoadErrorMsg indicates an error occurred while loading tasks.
 type TasksLoadErrorMsg struct{ Err error }
+
+// TaskToggledMsg carries a task whose completion state was toggled by
+// the service. Recurred is set when completing the task scheduled its
+// next occurrence, which means the list needs to be reloaded.
+type TaskToggledMsg struct {
+	Task     task.Task
+	Recurred bool
+}
+
+// MergeResolvedMsg indicates every conflict of a merge was resolved.
+type MergeResolvedMsg struct{}
+
+// MergeAbortedMsg indicates the user gave up resolving the conflicts of
+// a merge.
+type MergeAbortedMsg struct{}
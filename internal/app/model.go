@@ -9,8 +9,10 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
 	"github.com/jacobdanielrose/terminaltask/internal/config"
 	taskservice "github.com/jacobdanielrose/terminaltask/internal/service"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
 	task "github.com/jacobdanielrose/terminaltask/internal/task"
 	"github.com/jacobdanielrose/terminaltask/internal/task/editmenu"
 )
@@ -20,10 +22,22 @@ type state int
 const (
 	stateList state = iota
 	stateEdit
+
+	// stateRepair asks whether to open the tasks recovered from a
+	// damaged tasks file.
+	stateRepair
+
+	// stateResolve walks through the conflicts between the tasks file
+	// and a conflict copy of it.
+	stateResolve
 )
 
 const (
 	listModelTitle = "Terminal Task"
+
+	// listTitlePickBlocker replaces the list title while picking a
+	// blocker for the named task.
+	listTitlePickBlocker = "What blocks \"%s\"? (enter to choose, esc to cancel)"
 )
 
 // statusMessageStyles groups the global styles used for rendering
@@ -37,6 +51,12 @@ type statusMessageStyles struct {
 // list title styling applied at the top of the list component.
 type ListStyles struct {
 	Title lipgloss.Style
+
+	// Tab styles the names of the lists that are not being shown, and
+	// Tabs the row of list names that replaces the title once there is
+	// more than one list. The current list uses Title.
+	Tab  lipgloss.Style
+	Tabs lipgloss.Style
 }
 
 // AppStyles is the top-level style graph for the application. It
@@ -86,6 +106,10 @@ func newAppStyles() AppStyles {
 		Status: status,
 		List: ListStyles{
 			Title: listTitle,
+			Tab: lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"}).
+				Padding(0, 1),
+			Tabs: lipgloss.NewStyle(),
 		},
 		EditMenu: editMenuStyles,
 		Form:     formStyles,
@@ -109,18 +133,50 @@ type Model struct {
 	// keymap holds global key bindings for list-level interactions.
 	keymap *listKeyMap
 
+	// sort is the ordering currently applied to the task list.
+	sort sortMode
+
+	// tasks holds every task across all lists; the list view only
+	// shows those in the current list.
+	tasks []task.Task
+
+	// listName is the normalized name of the list being shown, empty
+	// for the default list.
+	listName string
+
+	// showSubtasks expands the subtasks beneath each task in the list.
+	showSubtasks bool
+
+	// blockerFor is the ID of the task a blocker is being picked for,
+	// or uuid.Nil when not picking one.
+	blockerFor uuid.UUID
+
+	// repair holds what was recovered from a damaged tasks file while
+	// the user decides whether to open it.
+	repair *store.CorruptError
+
+	// merging holds the conflicts between the tasks file and a conflict
+	// copy of it while resolver walks the user through them.
+	merging  *store.MergeConflictError
+	resolver resolver
+
 	// styles contains all top-level styling information for the app.
 	styles AppStyles
 
 	// service abstracts persistence and higher-level task operations.
 	service taskservice.Service
+
+	// changes reports changes made to the tasks by other programs, or
+	// is nil when they are not watched.
+	changes <-chan struct{}
 }
 
 // NewModel constructs a new application model wired with the provided
 // configuration and task service. It initializes the list and edit
 // menu sub-models and returns a Bubble Tea model ready for use in a
-// tea.Program.
-func NewModel(cfg config.Config, service taskservice.Service) tea.Model {
+// tea.Program. Whenever a value arrives on changes, the tasks are
+// reloaded; changes may be nil.
+func NewModel(cfg config.Config, service taskservice.Service, changes <-chan struct{}) tea.Model {
 	appStyles := newAppStyles()
 
 	delegate := task.NewTaskDelegate()
@@ -136,6 +192,7 @@ func NewModel(cfg config.Config, service taskservice.Service) tea.Model {
 		keymap:   NewListKeyMap(),
 		styles:   appStyles,
 		service:  service,
+		changes:  changes,
 	}
 }
 
@@ -147,9 +204,27 @@ func configureListModel(listModel list.Model, styles ListStyles) list.Model {
 	listModel.SetShowStatusBar(true)
 	listModel.SetStatusBarItemName("task", "tasks")
 	listModel.StatusMessageLifetime = 1 * time.Second
+	listModel.Filter = task.Filter
 	return listModel
 }
 
+// knownTags returns every tag used by tasks in the list, offered as
+// completions in the edit menu.
+func (m Model) knownTags() []string {
+	return task.CollectTags(itemsToTasks(m.list.Items()))
+}
+
+// indexOfTask returns the index of the task with the given ID among
+// all list items, or -1 if it is not in the list.
+func (m Model) indexOfTask(id uuid.UUID) int {
+	for i, item := range m.list.Items() {
+		if itemToTask(item).GetID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
 // taskToItem converts a Task into a list.Item.
 func taskToItem(t task.Task) list.Item {
 	return t
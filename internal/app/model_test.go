@@ -16,12 +16,13 @@ type fakeService struct {
 	name string
 }
 
-func (f *fakeService) LoadTasks() ([]task.Task, error)                { return nil, nil }
-func (f *fakeService) SaveTasks([]task.Task) error                    { return nil }
-func (f *fakeService) ToggleCompleted(t task.Task) (task.Task, error) { return t, nil }
-func (f *fakeService) DeleteByID(uuid.UUID) error                     { return nil }
-func (f *fakeService) UpsertTask(task.Task) error                     { return nil }
-func (f *fakeService) Name() string                                   { return f.name }
+func (f *fakeService) LoadTasks() ([]task.Task, error)                 { return nil, nil }
+func (f *fakeService) SaveTasks([]task.Task) error                     { return nil }
+func (f *fakeService) ToggleCompleted(t task.Task) (task.Task, error)  { return t, nil }
+func (f *fakeService) DeleteByID(uuid.UUID) error                      { return nil }
+func (f *fakeService) MoveToList(uuid.UUID, string) (task.Task, error) { return task.Task{}, nil }
+func (f *fakeService) UpsertTask(t task.Task) (task.Task, error)       { return t, nil }
+func (f *fakeService) Name() string                                    { return f.name }
 
 func TestTasksToItemsAndBack(t *testing.T) {
 	t1 := task.Task{TitleStr: "one", DescStr: "first"}
@@ -91,7 +92,7 @@ func TestConfigureListModel(t *testing.T) {
 func TestNewModelInitialState(t *testing.T) {
 	cfg := config.Config{}
 	svc := &fakeService{name: "fake"}
-	mAny := NewModel(cfg, svc)
+	mAny := NewModel(cfg, svc, nil)
 
 	// NewModel returns tea.Model; assert and inspect concrete model.
 	m, ok := mAny.(Model)
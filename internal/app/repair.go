@@ -0,0 +1,102 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+)
+
+const (
+	statusMsgLoadError     = "Error loading tasks!"
+	statusMsgOpenedRepair  = "Opened %d recovered tasks"
+	repairPromptTitle      = "Could not read all of %s"
+	repairPromptRecovered  = "Recovered %d tasks; these records were damaged and left out:"
+	repairPromptMoreDamage = "  ...and %d more"
+	repairPromptFiles      = "The damaged file was copied to\n  %s\nand the recovered tasks to\n  %s"
+	repairPromptQuestion   = "Open the recovered tasks? They will replace the damaged file."
+
+	// repairShownDamage is how many damaged records the prompt lists;
+	// the log has them all.
+	repairShownDamage = 5
+)
+
+// tasksLoadError reports tasks that failed to load. When the tasks file
+// is damaged, it asks whether to open the tasks recovered from it
+// rather than start with an empty list. When a sync tool left a
+// conflict copy that changes the same fields, it asks how to merge them.
+func (m Model) tasksLoadError(msg TasksLoadErrorMsg) (tea.Model, tea.Cmd) {
+	var conflict *store.MergeConflictError
+	if errors.As(msg.Err, &conflict) {
+		return m.mergeConflict(conflict)
+	}
+	log.Error("Error loading tasks", "err", msg.Err, "store", m.service.Name())
+
+	var corrupt *store.CorruptError
+	if !errors.As(msg.Err, &corrupt) {
+		return m, m.list.NewStatusMessage(m.renderErrorStatus(statusMsgLoadError))
+	}
+	for _, d := range corrupt.Damage {
+		log.Warn("Damaged task record", "file", corrupt.Path, "offset", d.Offset, "reason", d.Reason)
+	}
+	m.repair = corrupt
+	m.state = stateRepair
+	return m, nil
+}
+
+// stateRepairUpdate handles the answer to the repair prompt: open the
+// recovered tasks, or quit and leave the damaged file to be fixed by
+// hand.
+func (m Model) stateRepairUpdate(msg tea.Msg) (Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keymap.OpenRepaired):
+		return m.openRepaired()
+	case key.Matches(keyMsg, m.keymap.KeepDamaged):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// openRepaired shows the recovered tasks and saves them in place of
+// the damaged file.
+func (m Model) openRepaired() (Model, tea.Cmd) {
+	tasks := m.repair.Tasks
+	m.repair = nil
+	m.state = stateList
+
+	m, cmd := m.setTasks(tasks)
+	return m, tea.Batch(cmd, m.saveTasksCmd(tasks, fmt.Sprintf(statusMsgOpenedRepair, len(tasks))))
+}
+
+// repairView renders the repair prompt.
+func (m Model) repairView() string {
+	e := m.repair
+
+	var b strings.Builder
+	b.WriteString(m.styles.Status.ErrorStyle.Render(fmt.Sprintf(repairPromptTitle, e.Path)))
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, repairPromptRecovered, len(e.Tasks))
+	b.WriteString("\n")
+	for i, d := range e.Damage {
+		if i == repairShownDamage {
+			fmt.Fprintf(&b, repairPromptMoreDamage+"\n", len(e.Damage)-i)
+			break
+		}
+		b.WriteString("  " + d.String() + "\n")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, repairPromptFiles, e.Quarantined, e.Repaired)
+	b.WriteString("\n\n")
+	b.WriteString(repairPromptQuestion)
+	b.WriteString("\n\n")
+	b.WriteString(m.list.Help.ShortHelpView([]key.Binding{m.keymap.OpenRepaired, m.keymap.KeepDamaged}))
+	return b.String()
+}
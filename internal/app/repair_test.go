@@ -0,0 +1,84 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+func newRepairTestModel(svc *commandsFakeService) Model {
+	l := list.New(nil, task.NewTaskDelegate(), 80, 40)
+	return Model{list: l, service: svc, styles: newAppStyles(), keymap: NewListKeyMap()}
+}
+
+func TestTasksLoadError_DamagedFileOffersRepair(t *testing.T) {
+	recovered := []task.Task{task.NewWithOptions("kept", "", task.Task{}.DueDate, false)}
+	var saved []task.Task
+	svc := &commandsFakeService{
+		saveTasksFn: func(tasks []task.Task) error {
+			saved = tasks
+			return nil
+		},
+	}
+	corrupt := &store.CorruptError{
+		Path:        "/tmp/tasks.json",
+		Tasks:       recovered,
+		Damage:      []store.Damage{{Offset: 120, Reason: "invalid character 'x'"}},
+		Quarantined: "/tmp/tasks.json.abcd.damaged",
+		Repaired:    "/tmp/tasks.json.abcd.repaired",
+	}
+
+	updated, _ := newRepairTestModel(svc).Update(TasksLoadErrorMsg{Err: corrupt})
+	m := updated.(Model)
+	if m.state != stateRepair {
+		t.Fatalf("state = %v, want the repair prompt", m.state)
+	}
+	view := m.View()
+	for _, want := range []string{"byte 120: invalid character", corrupt.Quarantined, corrupt.Repaired} {
+		if !contains(view, want) {
+			t.Fatalf("repair prompt %q does not mention %q", view, want)
+		}
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = updated.(Model)
+	if m.state != stateList || len(m.list.Items()) != 1 {
+		t.Fatalf("after accepting: state = %v with %d tasks, want the recovered task listed", m.state, len(m.list.Items()))
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		for _, c := range batch {
+			if c != nil {
+				c()
+			}
+		}
+	}
+	if len(saved) != 1 || saved[0].GetID() != recovered[0].GetID() {
+		t.Fatalf("saved %v, want the recovered tasks saved", saved)
+	}
+}
+
+func TestTasksLoadError_DeclinedRepairQuits(t *testing.T) {
+	m := newRepairTestModel(&commandsFakeService{})
+	m.state, m.repair = stateRepair, &store.CorruptError{Path: "/tmp/tasks.json"}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if cmd == nil {
+		t.Fatal("declining the repair returned no command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("declining the repair did not quit")
+	}
+}
+
+func TestTasksLoadError_OtherErrorsShowStatus(t *testing.T) {
+	m := newRepairTestModel(&commandsFakeService{})
+
+	updated, cmd := m.Update(TasksLoadErrorMsg{Err: errors.New("disk on fire")})
+	if updated.(Model).state != stateList || cmd == nil {
+		t.Fatalf("state = %v, cmd = %v; want the list with a status message", updated.(Model).state, cmd)
+	}
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/jacobdanielrose/terminaltask/internal/merge"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+)
+
+const (
+	statusMsgMerged = "Merged %s"
+
+	resolverTitle    = "Conflict %d of %d in \"%s\": %s"
+	resolverUnchosen = "  "
+	resolverChosen   = "> "
+	resolverBase     = "Before both changes: %s"
+)
+
+// resolver walks the user through the conflicts of a merge one at a
+// time, recording the side they keep in each. Once the last one is
+// settled it sends MergeResolvedMsg; giving up sends MergeAbortedMsg.
+type resolver struct {
+	result  *merge.Result
+	current int
+
+	// ours and theirs label the two sides, such as the names of the
+	// files they come from.
+	ours, theirs string
+
+	keymap *resolverKeyMap
+	help   help.Model
+	styles AppStyles
+}
+
+func newResolver(result *merge.Result, ours, theirs string, styles AppStyles) resolver {
+	return resolver{
+		result: result,
+		ours:   ours,
+		theirs: theirs,
+		keymap: newResolverKeyMap(),
+		help:   help.New(),
+		styles: styles,
+	}
+}
+
+func (r resolver) Update(msg tea.Msg) (resolver, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || r.current >= len(r.result.Conflicts) {
+		return r, nil
+	}
+	switch {
+	case key.Matches(keyMsg, r.keymap.KeepOurs):
+		return r.choose(merge.Ours)
+	case key.Matches(keyMsg, r.keymap.TakeTheirs):
+		return r.choose(merge.Theirs)
+	case key.Matches(keyMsg, r.keymap.Back):
+		if r.current > 0 {
+			r.current--
+		}
+	case key.Matches(keyMsg, r.keymap.Abort):
+		return r, func() tea.Msg { return MergeAbortedMsg{} }
+	}
+	return r, nil
+}
+
+// choose settles the current conflict and moves on to the next one.
+func (r resolver) choose(side merge.Side) (resolver, tea.Cmd) {
+	r.result.Conflicts[r.current].Choice = side
+	r.current++
+	if r.current < len(r.result.Conflicts) {
+		return r, nil
+	}
+	return r, func() tea.Msg { return MergeResolvedMsg{} }
+}
+
+func (r resolver) View() string {
+	if r.current >= len(r.result.Conflicts) {
+		return ""
+	}
+	c := r.result.Conflicts[r.current]
+
+	var b strings.Builder
+	title := fmt.Sprintf(resolverTitle, r.current+1, len(r.result.Conflicts), c.Title(), c.Field)
+	b.WriteString(r.styles.List.Title.Render(title))
+	b.WriteString("\n\n")
+
+	label := lipgloss.NewStyle().Bold(true)
+	for _, side := range []merge.Side{merge.Ours, merge.Theirs} {
+		name := r.ours
+		if side == merge.Theirs {
+			name = r.theirs
+		}
+		marker := resolverUnchosen
+		if c.Choice == side {
+			marker = resolverChosen
+		}
+		b.WriteString(marker + label.Render(name) + "\n")
+		b.WriteString("    " + c.Show(side) + "\n\n")
+	}
+	if c.Base != nil && c.Field != merge.FieldTask {
+		b.WriteString(fmt.Sprintf(resolverBase, c.ShowBase()))
+		b.WriteString("\n\n")
+	}
+	b.WriteString(r.help.ShortHelpView([]key.Binding{
+		r.keymap.KeepOurs, r.keymap.TakeTheirs, r.keymap.Back, r.keymap.Abort,
+	}))
+	return b.String()
+}
+
+// mergeConflict starts resolving the conflicts between the tasks file
+// and a conflict copy of it that the store could not merge by itself.
+func (m Model) mergeConflict(conflict *store.MergeConflictError) (tea.Model, tea.Cmd) {
+	log.Warn("Conflict copy needs merging", "file", conflict.Path, "copy", conflict.Copy,
+		"conflicts", len(conflict.Result.Conflicts))
+
+	m.merging = conflict
+	m.resolver = newResolver(&conflict.Result, filepath.Base(conflict.Path), filepath.Base(conflict.Copy), m.styles)
+	m.state = stateResolve
+	return m, nil
+}
+
+// mergeResolved shows the merged tasks and saves them, which puts them
+// in place of both the tasks file and the conflict copy.
+func (m Model) mergeResolved() (tea.Model, tea.Cmd) {
+	conflict := m.merging
+	m.merging = nil
+	m.state = stateList
+
+	tasks, err := conflict.Result.Resolved()
+	if err != nil {
+		return m, m.list.NewStatusMessage(m.renderErrorStatus(statusMsgLoadError))
+	}
+	m, cmd := m.setTasks(tasks)
+	return m, tea.Batch(cmd, m.saveTasksCmd(tasks, fmt.Sprintf(statusMsgMerged, filepath.Base(conflict.Copy))))
+}
+
+// ResolverModel is a standalone program that resolves the conflicts of
+// a merge, for merging task files from the command line. It records
+// the choices in the result it was given and quits once they are all
+// made, or when the user gives up.
+type ResolverModel struct {
+	resolver resolver
+	styles   AppStyles
+	aborted  bool
+}
+
+// ErrMergeAborted is returned by ResolverModel.Err when the user quit
+// without resolving every conflict.
+var ErrMergeAborted = errors.New("merge aborted")
+
+// NewResolverModel returns a program resolving the conflicts of result,
+// labelling the two sides ours and theirs.
+func NewResolverModel(result *merge.Result, ours, theirs string) *ResolverModel {
+	styles := newAppStyles()
+	return &ResolverModel{resolver: newResolver(result, ours, theirs, styles), styles: styles}
+}
+
+// Err reports whether the user quit before resolving every conflict.
+func (m *ResolverModel) Err() error {
+	if m.aborted || m.resolver.result.Unresolved() > 0 {
+		return ErrMergeAborted
+	}
+	return nil
+}
+
+func (m *ResolverModel) Init() tea.Cmd { return nil }
+
+func (m *ResolverModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			m.aborted = true
+			return m, tea.Quit
+		}
+	case MergeResolvedMsg:
+		return m, tea.Quit
+	case MergeAbortedMsg:
+		m.aborted = true
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.resolver, cmd = m.resolver.Update(msg)
+	return m, cmd
+}
+
+func (m *ResolverModel) View() string {
+	return m.styles.Frame.Render(m.resolver.View())
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/merge"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+func keyPress(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// conflictingEdits returns a merge of two versions of a task with
+// different titles and different descriptions.
+func conflictingEdits() (merge.Result, task.Task) {
+	base := task.Task{ID: uuid.New(), TitleStr: "report"}
+	ours, theirs := base, base
+	ours.TitleStr, theirs.TitleStr = "Q1 report", "annual report"
+	ours.DescStr, theirs.DescStr = "slides", "spreadsheet"
+	return merge.Merge([]task.Task{base}, []task.Task{ours}, []task.Task{theirs}), theirs
+}
+
+func TestTasksLoadError_ConflictCopyIsResolved(t *testing.T) {
+	result, theirs := conflictingEdits()
+	var saved []task.Task
+	svc := &commandsFakeService{
+		saveTasksFn: func(tasks []task.Task) error {
+			saved = tasks
+			return nil
+		},
+	}
+	conflict := &store.MergeConflictError{
+		Path:   "/tmp/tasks.json",
+		Copy:   "/tmp/tasks.sync-conflict-20261017-093000-ABCDEFG.json",
+		Result: result,
+	}
+
+	updated, _ := newRepairTestModel(svc).Update(TasksLoadErrorMsg{Err: conflict})
+	m := updated.(Model)
+	if m.state != stateResolve {
+		t.Fatalf("state = %v, want the conflict resolver", m.state)
+	}
+	view := m.View()
+	for _, want := range []string{"Conflict 1 of 2", "title", "tasks.json", "Q1 report", "annual report", "Before both changes: report"} {
+		if !contains(view, want) {
+			t.Fatalf("resolver %q does not mention %q", view, want)
+		}
+	}
+
+	// Take their title, go back, then keep ours after all.
+	updated, _ = m.Update(keyPress("t"))
+	m = updated.(Model)
+	if !contains(m.View(), "Conflict 2 of 2") {
+		t.Fatalf("after choosing: %q, want the second conflict", m.View())
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	updated, _ = updated.(Model).Update(keyPress("o"))
+	updated, cmd := updated.(Model).Update(keyPress("t"))
+	if cmd == nil {
+		t.Fatal("resolving the last conflict returned no command")
+	}
+	msg := cmd()
+	if _, ok := msg.(MergeResolvedMsg); !ok {
+		t.Fatalf("resolving the last conflict sent %T, want MergeResolvedMsg", msg)
+	}
+
+	updated, cmd = updated.(Model).Update(msg)
+	m = updated.(Model)
+	if m.state != stateList || len(m.list.Items()) != 1 {
+		t.Fatalf("after resolving: state = %v with %d tasks, want the merged task listed", m.state, len(m.list.Items()))
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		for _, c := range batch {
+			if c != nil {
+				c()
+			}
+		}
+	}
+	if len(saved) != 1 || saved[0].TitleStr != "Q1 report" || saved[0].DescStr != theirs.DescStr {
+		t.Fatalf("saved %+v, want our title with their description", saved)
+	}
+}
+
+func TestResolverModel_Abort(t *testing.T) {
+	result, _ := conflictingEdits()
+	m := NewResolverModel(&result, "ours", "theirs")
+
+	_, cmd := m.Update(keyPress("o"))
+	if cmd != nil {
+		t.Fatal("resolving the first conflict quit the resolver")
+	}
+	_, cmd = m.Update(keyPress("q"))
+	_, cmd = m.Update(cmd())
+	if cmd == nil {
+		t.Fatal("aborting returned no command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("aborting did not quit")
+	}
+	if err := m.Err(); !errors.Is(err, ErrMergeAborted) {
+		t.Fatalf("Err() = %v, want ErrMergeAborted", err)
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"github.com/google/uuid"
+	task "github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// sortMode selects how tasks are ordered in the list view.
+type sortMode int
+
+const (
+	// sortManual keeps tasks in the order the store returned them.
+	sortManual sortMode = iota
+	sortPriority
+	// sortDue puts the earliest deadlines first and undated tasks last.
+	sortDue
+	// sortCreated, sortUpdated, and sortCompleted put the most recent
+	// tasks first.
+	sortCreated
+	sortUpdated
+	sortCompleted
+	sortModeMax
+)
+
+// String returns the human-readable name of the sort mode.
+func (s sortMode) String() string {
+	switch s {
+	case sortManual:
+		return "manual"
+	case sortPriority:
+		return "priority"
+	case sortDue:
+		return "due date"
+	case sortCreated:
+		return "created"
+	case sortUpdated:
+		return "updated"
+	case sortCompleted:
+		return "completed"
+	default:
+		return "unknown"
+	}
+}
+
+// next returns the sort mode that follows s, wrapping around.
+func (s sortMode) next() sortMode {
+	return (s + 1) % sortModeMax
+}
+
+// sortTasks orders tasks in place according to mode and returns them.
+func sortTasks(tasks []task.Task, mode sortMode) []task.Task {
+	switch mode {
+	case sortPriority:
+		task.SortByPriority(tasks)
+	case sortDue:
+		task.SortBy(tasks, task.CompareDue)
+	case sortCreated:
+		task.SortBy(tasks, task.CompareCreated)
+	case sortUpdated:
+		task.SortBy(tasks, task.CompareUpdated)
+	case sortCompleted:
+		task.SortBy(tasks, task.CompareCompleted)
+	}
+	return tasks
+}
+
+// applySort reorders the list items according to the current sort
+// mode, keeping the cursor on the task that was selected before.
+func (m Model) applySort() Model {
+	if m.sort == sortManual {
+		return m
+	}
+
+	var selected uuid.UUID
+	if t, ok := m.list.SelectedItem().(task.Task); ok {
+		selected = t.GetID()
+	}
+
+	tasks := sortTasks(itemsToTasks(m.list.Items()), m.sort)
+	m.setItems(tasks)
+	return m.selectTask(selected)
+}
+
+// selectTask moves the list cursor to the task with the given ID, if
+// it is currently visible in the list.
+func (m Model) selectTask(id uuid.UUID) Model {
+	if id == uuid.Nil {
+		return m
+	}
+	for i, item := range m.list.VisibleItems() {
+		if itemToTask(item).GetID() == id {
+			m.list.Select(i)
+			break
+		}
+	}
+	return m
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+func TestSortModeNextWraps(t *testing.T) {
+	if got := sortManual.next(); got != sortPriority {
+		t.Fatalf("sortManual.next() = %v, want %v", got, sortPriority)
+	}
+	if got := sortCompleted.next(); got != sortManual {
+		t.Fatalf("sortCompleted.next() = %v, want %v", got, sortManual)
+	}
+}
+
+func TestSortTasks_CompletedMostRecentFirst(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tasks := []task.Task{
+		{TitleStr: "open"},
+		{TitleStr: "older", Done: true, CompletedAt: now.Add(-48 * time.Hour)},
+		{TitleStr: "newer", Done: true, CompletedAt: now},
+	}
+
+	got := sortTasks(tasks, sortCompleted)
+	for i, want := range []string{"newer", "older", "open"} {
+		if got[i].TitleStr != want {
+			t.Fatalf("sortTasks(completed)[%d] = %q, want %q", i, got[i].TitleStr, want)
+		}
+	}
+}
+
+func TestSortTasks_ManualKeepsOrder(t *testing.T) {
+	tasks := []task.Task{
+		{TitleStr: "low", Priority: task.PriorityLow},
+		{TitleStr: "high", Priority: task.PriorityHigh},
+	}
+
+	got := sortTasks(tasks, sortManual)
+	if got[0].TitleStr != "low" || got[1].TitleStr != "high" {
+		t.Fatalf("sortTasks(manual) reordered tasks: %q, %q", got[0].TitleStr, got[1].TitleStr)
+	}
+}
+
+func TestApplySort_KeepsSelection(t *testing.T) {
+	low := task.NewWithOptions("low", "", task.Task{}.DueDate, false)
+	low.Priority = task.PriorityLow
+	high := task.NewWithOptions("high", "", task.Task{}.DueDate, false)
+	high.Priority = task.PriorityHigh
+
+	l := list.New(tasksToItems([]task.Task{low, high}), task.NewTaskDelegate(), 40, 40)
+	l.Select(0)
+
+	m := Model{list: l, sort: sortPriority}
+	m = m.applySort()
+
+	items := itemsToTasks(m.list.Items())
+	if items[0].Title() != "high" {
+		t.Fatalf("items[0] = %q, want %q", items[0].Title(), "high")
+	}
+
+	selected, ok := m.list.SelectedItem().(task.Task)
+	if !ok {
+		t.Fatalf("SelectedItem() is not a task")
+	}
+	if selected.GetID() != low.GetID() {
+		t.Fatalf("selected task = %q, want %q", selected.Title(), low.Title())
+	}
+}
@@ -5,12 +5,15 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
 	"github.com/jacobdanielrose/terminaltask/internal/task"
 	"github.com/jacobdanielrose/terminaltask/internal/task/editmenu"
 )
@@ -20,18 +23,21 @@ const (
 	// Generic error text for failed saves.
 	statusMsgSaveError   = "Error saving!"
 	statusMsgDeleteError = "Error deleting task!"
+	statusMsgStaleSave   = "Tasks were changed in another window; reloaded them, please try again"
+	statusMsgStaleWrite  = "Tasks were changed in another window; reloaded them and kept your changes"
 
 	// Success status templates.
 	statusMsgEditedTask    = "Edited: \"%s\""
 	statusMsgDeletedTask   = "Deleted: \"%s\""
 	statusMsgCompletedTask = "Completed: \"%s\""
 	statusMsgCreatedTask   = "Created new task: \"%s\""
+	statusMsgSortedBy      = "Sorted by %s"
 )
 
 // Init implements tea.Model and, in this application, triggers loading
 // tasks from the backing service.
 func (m Model) Init() tea.Cmd {
-	return m.loadTasksCmd()
+	return tea.Batch(m.loadTasksCmd(), m.waitForChangesCmd(), m.waitForWriteErrorCmd())
 }
 
 // renderSuccessStatus formats a success status message using the
@@ -48,6 +54,19 @@ func (m Model) renderErrorStatus(msg string) string {
 	return m.styles.Status.ErrorStyle.Render(msg)
 }
 
+// saveErrorStatus reports a failed save with the given message. When
+// the save was refused because another session changed the tasks in
+// the meantime, it says so instead and reloads them.
+func (m Model) saveErrorStatus(err error, msg string) tea.Cmd {
+	if errors.Is(err, store.ErrStaleSave) {
+		return tea.Batch(
+			m.list.NewStatusMessage(m.renderErrorStatus(statusMsgStaleSave)),
+			m.loadTasksCmd(),
+		)
+	}
+	return m.list.NewStatusMessage(m.renderErrorStatus(msg))
+}
+
 // Update implements the Bubble Tea Update method for the root
 // application model. It delegates high-level messages first, then
 // routes to the appropriate state-specific update function.
@@ -78,18 +97,46 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case task.ToggleDoneMsg:
 		return m.toggleDone()
 
+	case TaskToggledMsg:
+		return m.taskToggled(msg)
+
 	case TasksSavedMsg:
 		return m.taskSaved(msg)
 
 	case TasksSaveErrorMsg:
 		return m.taskSaveError(msg)
 
+	case TasksWriteErrorMsg:
+		return m.taskWriteError(msg)
+
 	case TasksLoadedMsg:
-		m.list.SetItems(tasksToItems(msg.Tasks))
-		return m, nil
+		return m.tasksLoaded(msg)
+
+	case TasksLoadErrorMsg:
+		return m.tasksLoadError(msg)
+
+	case MergeResolvedMsg:
+		return m.mergeResolved()
+
+	case MergeAbortedMsg:
+		return m, tea.Quit
+
+	case TasksChangedMsg:
+		// Reload, then keep listening. An open edit is left alone.
+		return m, tea.Batch(m.loadTasksCmd(), m.waitForChangesCmd())
 
 	case task.DeleteMsg:
 		return m.deleteTask()
+
+	case task.PickBlockerMsg:
+		return m.pickBlocker()
+
+	case task.ClearBlockersMsg:
+		return m.clearBlockers()
+
+	case task.ToggleSubtasksMsg:
+		m.showSubtasks = !m.showSubtasks
+		return m.refreshDelegate(), nil
 	}
 
 	// Fallback to state-specific handling.
@@ -98,11 +145,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.stateListUpdate(msg)
 	case stateEdit:
 		return m.stateEditUpdate(msg)
+	case stateRepair:
+		return m.stateRepairUpdate(msg)
+	case stateResolve:
+		var cmd tea.Cmd
+		m.resolver, cmd = m.resolver.Update(msg)
+		return m, cmd
 	default:
 		return m, nil
 	}
 }
 
+// tasksLoaded replaces the list contents with freshly loaded tasks,
+// keeping the cursor on the task that was selected before. A damaged
+// tasks file that was fixed by hand meanwhile ends the repair prompt,
+// and a conflict copy merged meanwhile ends resolving its conflicts.
+func (m Model) tasksLoaded(msg TasksLoadedMsg) (tea.Model, tea.Cmd) {
+	switch m.state {
+	case stateRepair:
+		m.state, m.repair = stateList, nil
+	case stateResolve:
+		m.state, m.merging = stateList, nil
+	}
+	return m.setTasks(msg.Tasks)
+}
+
 func (m Model) resizeWindow(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 	h, v := m.styles.Frame.GetFrameSize()
 	contentW, contentH := msg.Width-h, msg.Height-v
@@ -118,7 +185,7 @@ func (m Model) enterEditMenu() (tea.Model, tea.Cmd) {
 	}
 	t := item.(task.Task)
 	w, h := m.editmenu.Width(), m.editmenu.Height()
-	m.editmenu = editmenu.NewWithSize(w, h, t)
+	m.editmenu = editmenu.NewWithSize(w, h, t).SetKnownTags(m.knownTags())
 	m.state = stateEdit
 	return m, nil
 }
@@ -129,12 +196,14 @@ func (m Model) toggleDone() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	taskItem := item.(task.Task)
-	index := m.list.Index()
-	taskItem.Done = !taskItem.Done
-	m.list.SetItem(index, taskItem)
+	return m, m.toggleCompletedCmd(item.(task.Task))
+}
 
-	tasks := itemsToTasks(m.list.Items())
+// taskToggled replaces the toggled task in the list with the version
+// returned by the service and reports the new completion state.
+func (m Model) taskToggled(msg TaskToggledMsg) (tea.Model, tea.Cmd) {
+	taskItem := msg.Task
+	m = m.putTask(taskItem).applySort()
 
 	var statusText string
 	if taskItem.Done {
@@ -143,17 +212,40 @@ func (m Model) toggleDone() (tea.Model, tea.Cmd) {
 		statusText = fmt.Sprintf(statusMsgEditedTask, taskItem.Title())
 	}
 
-	return m, m.saveTasksCmd(tasks, statusText)
+	cmd := m.list.NewStatusMessage(
+		m.renderSuccessStatus(statusText),
+	)
+	if msg.Recurred {
+		// The service added the next occurrence; pick it up.
+		cmd = tea.Batch(cmd, m.loadTasksCmd())
+	}
+	return m, cmd
 }
 
 func (m Model) taskSaveError(msg TasksSaveErrorMsg) (tea.Model, tea.Cmd) {
-	cmd := m.list.NewStatusMessage(
-		m.renderErrorStatus(statusMsgSaveError),
-	)
+	cmd := m.saveErrorStatus(msg.Err, statusMsgSaveError)
 	log.Error("Error saving tasks", "err", msg.Err, "store", m.service.Name())
 	return m, cmd
 }
 
+// taskWriteError reports changes the service failed to write in the
+// background, then keeps listening for more. Changes refused because
+// another session changed the tasks meanwhile are kept by the service
+// and written again once the tasks are reloaded.
+func (m Model) taskWriteError(msg TasksWriteErrorMsg) (tea.Model, tea.Cmd) {
+	log.Error("Error writing tasks", "err", msg.Err, "store", m.service.Name())
+	var cmd tea.Cmd
+	if errors.Is(msg.Err, store.ErrStaleSave) {
+		cmd = tea.Batch(
+			m.list.NewStatusMessage(m.renderErrorStatus(statusMsgStaleWrite)),
+			m.loadTasksCmd(),
+		)
+	} else {
+		cmd = m.list.NewStatusMessage(m.renderErrorStatus(statusMsgSaveError))
+	}
+	return m, tea.Batch(cmd, m.waitForWriteErrorCmd())
+}
+
 func (m Model) taskSaved(msg TasksSavedMsg) (tea.Model, tea.Cmd) {
 	cmd := m.list.NewStatusMessage(
 		m.renderSuccessStatus(msg.msg),
@@ -162,31 +254,28 @@ func (m Model) taskSaved(msg TasksSavedMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) deleteTask() (tea.Model, tea.Cmd) {
-	index := m.list.Index()
 	taskItem, ok := m.list.SelectedItem().(task.Task)
 	if !ok {
 		return m, nil
 	}
 
 	if err := m.service.DeleteByID(taskItem.GetID()); err != nil {
-		cmd := m.list.NewStatusMessage(
-			m.renderErrorStatus(statusMsgDeleteError),
-		)
+		cmd := m.saveErrorStatus(err, statusMsgDeleteError)
 		log.Error("Error deleting task", "err", err, "store", m.service.Name())
 		return m, cmd
 	}
 
-	m.list.RemoveItem(index)
+	m = m.dropTask(taskItem.GetID()).removeBlockerFromTasks(taskItem.GetID())
 	cmd := m.list.NewStatusMessage(
 		m.renderSuccessStatus(fmt.Sprintf(statusMsgDeletedTask, taskItem.Title())),
 	)
 	return m, cmd
 }
 
-// saveTask handles an editmenu.SaveTaskMsg by either updating an
-// existing task in the list or inserting a new one. It then switches
-// back to the list state and returns a command that persists the
-// updated tasks and shows an appropriate status message.
+// saveTask handles an editmenu.SaveTaskMsg by persisting the task
+// through the service and then either updating the existing task in
+// the list or appending the new one. It switches back to the list
+// state and shows an appropriate status message.
 func (m Model) saveTask(msg editmenu.SaveTaskMsg) (Model, tea.Cmd) {
 	t := task.NewWithOptions(
 		msg.Title,
@@ -194,37 +283,46 @@ func (m Model) saveTask(msg editmenu.SaveTaskMsg) (Model, tea.Cmd) {
 		msg.Date,
 		msg.Done,
 	)
+	t.DueHasTime = msg.DueHasTime
+	t.DueZone = msg.DueZone
+	t.StartDate = msg.Start
+	t.Priority = msg.Priority
+	t.Tags = msg.Tags
+	t.Subtasks = msg.Subtasks
+	t.Recurrence = msg.Recurrence
+	t.List = msg.List
+	t.BlockedBy = msg.BlockedBy
+	t.RollUpSubtasks()
 
-	index := -1
 	var statusText string
-
 	if len(m.list.Items()) != 0 && !msg.IsNew {
 		// Existing task, preserve ID.
 		t.SetID(msg.TaskID)
-
-		index = m.list.Index()
-		m.list.SetItem(index, t)
 		statusText = fmt.Sprintf(statusMsgEditedTask, t.Title())
 	} else {
 		// New task: use the ID generated by NewWithOptions.
-		m.list.InsertItem(index, t)
 		statusText = fmt.Sprintf(statusMsgCreatedTask, t.Title())
 	}
 
 	m.state = stateList
-	tasks := itemsToTasks(m.list.Items())
 
-	if err := m.service.SaveTasks(tasks); err != nil {
-		cmd := m.list.NewStatusMessage(
-			m.renderErrorStatus(statusMsgSaveError),
-		)
-		log.Error("Error saving tasks", "err", err, "store", m.service.Name())
+	saved, err := m.service.UpsertTask(t)
+	if err != nil {
+		cmd := m.saveErrorStatus(err, statusMsgSaveError)
+		log.Error("Error saving task", "err", err, "store", m.service.Name())
 		return m, cmd
 	}
 
+	// The service appends new tasks, and putTask mirrors that.
+	m = m.putTask(saved).applySort().selectTask(saved.GetID())
+
 	cmd := m.list.NewStatusMessage(
 		m.renderSuccessStatus(statusText),
 	)
+	if t.IsRecurring() && !saved.IsRecurring() {
+		// Completing it made the service add the next occurrence.
+		cmd = tea.Batch(cmd, m.loadTasksCmd())
+	}
 	return m, cmd
 }
 
@@ -239,13 +337,36 @@ func (m Model) stateListUpdate(msg tea.Msg) (Model, tea.Cmd) {
 		if m.list.FilterState() == list.Filtering {
 			break
 		}
+		if m.blockerFor != uuid.Nil {
+			switch {
+			case key.Matches(msg, m.keymap.ConfirmBlocker):
+				return m.addBlocker()
+			case key.Matches(msg, m.keymap.CancelBlocker):
+				return m.stopPickingBlocker(), nil
+			}
+		}
 		// New item: open the edit menu with an empty task.
 		if key.Matches(msg, m.keymap.NewItem) {
 			newTask := task.New()
+			newTask.List = m.listName
 			w, h := m.editmenu.Width(), m.editmenu.Height()
-			m.editmenu = editmenu.NewWithSize(w, h, newTask)
+			m.editmenu = editmenu.NewWithSize(w, h, newTask).SetKnownTags(m.knownTags())
 			m.state = stateEdit
 		}
+		switch {
+		case key.Matches(msg, m.keymap.Sort):
+			return m.cycleSort()
+		case key.Matches(msg, m.keymap.NextList):
+			return m.cycleList(1)
+		case key.Matches(msg, m.keymap.PrevList):
+			return m.cycleList(-1)
+		case key.Matches(msg, m.keymap.JumpList):
+			return m.switchList(listShortcut(msg))
+		case key.Matches(msg, m.keymap.MoveNext):
+			return m.moveTask(1)
+		case key.Matches(msg, m.keymap.MovePrev):
+			return m.moveTask(-1)
+		}
 	}
 
 	var cmd tea.Cmd
@@ -253,6 +374,25 @@ func (m Model) stateListUpdate(msg tea.Msg) (Model, tea.Cmd) {
 	return m, cmd
 }
 
+// cycleSort advances to the next sort mode and reorders the list. When
+// returning to manual order the tasks are reloaded so they appear in
+// the order the store keeps them.
+func (m Model) cycleSort() (Model, tea.Cmd) {
+	m.sort = m.sort.next()
+
+	cmds := []tea.Cmd{
+		m.list.NewStatusMessage(
+			m.renderSuccessStatus(fmt.Sprintf(statusMsgSortedBy, m.sort)),
+		),
+	}
+	if m.sort == sortManual {
+		cmds = append(cmds, m.loadTasksCmd())
+	} else {
+		m = m.applySort()
+	}
+	return m, tea.Batch(cmds...)
+}
+
 // stateEditUpdate handles messages that should be processed while the
 // application is in the edit state. It delegates the message to the
 // edit menu sub-model and returns the updated root model and command.
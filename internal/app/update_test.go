@@ -1 +1,276 @@
 package app
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+	"github.com/jacobdanielrose/terminaltask/internal/task/editmenu"
+)
+
+func TestToggleDone_UsesServiceAndUpdatesList(t *testing.T) {
+	tk := task.NewWithOptions("release", "", task.Task{}.DueDate, false)
+	tk.Subtasks = []task.Subtask{task.NewSubtask("tag")}
+
+	var toggled task.Task
+	svc := &commandsFakeService{
+		toggleFn: func(t task.Task) (task.Task, error) {
+			toggled = t
+			t.SetDone(!t.Done)
+			return t, nil
+		},
+	}
+
+	l := list.New(tasksToItems([]task.Task{tk}), task.NewTaskDelegate(), 40, 40)
+	m := Model{list: l, service: svc, styles: newAppStyles()}
+
+	_, cmd := m.toggleDone()
+	if cmd == nil {
+		t.Fatalf("toggleDone() returned nil cmd")
+	}
+
+	msg, ok := cmd().(TaskToggledMsg)
+	if !ok {
+		t.Fatalf("expected TaskToggledMsg from toggle command")
+	}
+	if toggled.GetID() != tk.GetID() {
+		t.Fatalf("service toggled %v, want %v", toggled.GetID(), tk.GetID())
+	}
+
+	updated, _ := m.taskToggled(msg)
+	got := itemToTask(updated.(Model).list.Items()[0])
+	if !got.Done || !got.Subtasks[0].Done {
+		t.Fatalf("list task Done = %v, subtask Done = %v, want both true", got.Done, got.Subtasks[0].Done)
+	}
+}
+
+func TestTaskToggled_RecurredReloadsTasks(t *testing.T) {
+	tk := task.NewWithOptions("chores", "", task.Task{}.DueDate, true)
+	loaded := false
+	svc := &commandsFakeService{
+		loadTasksFn: func() ([]task.Task, error) {
+			loaded = true
+			return []task.Task{tk}, nil
+		},
+	}
+
+	l := list.New(tasksToItems([]task.Task{tk}), task.NewTaskDelegate(), 40, 40)
+	m := Model{list: l, service: svc, styles: newAppStyles()}
+
+	_, cmd := m.taskToggled(TaskToggledMsg{Task: tk, Recurred: true})
+	if cmd == nil {
+		t.Fatalf("taskToggled() returned nil cmd")
+	}
+
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		t.Fatalf("expected a batch of status and reload commands")
+	}
+	// The reload is batched after the status message.
+	if _, ok := batch[len(batch)-1]().(TasksLoadedMsg); !ok || !loaded {
+		t.Fatalf("expected tasks to be reloaded after a recurring task completed")
+	}
+}
+
+func TestSaveTask_UpsertsThroughServiceKeepingTimestamps(t *testing.T) {
+	created := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	existing := task.NewWithOptions("draft", "", task.Task{}.DueDate, false)
+	existing.CreatedAt = created
+
+	svc := &commandsFakeService{
+		upsertFn: func(t task.Task) (task.Task, error) {
+			t.CreatedAt = created
+			t.UpdatedAt = created.Add(time.Hour)
+			return t, nil
+		},
+	}
+
+	l := list.New(tasksToItems([]task.Task{existing}), task.NewTaskDelegate(), 40, 40)
+	m := Model{list: l, service: svc, styles: newAppStyles()}
+
+	m, _ = m.saveTask(editmenu.SaveTaskMsg{TaskID: existing.GetID(), Title: "final"})
+	m, _ = m.saveTask(editmenu.SaveTaskMsg{Title: "another", IsNew: true})
+
+	items := itemsToTasks(m.list.Items())
+	if len(items) != 2 {
+		t.Fatalf("list has %d items, want 2", len(items))
+	}
+	if items[0].Title() != "final" || !items[0].CreatedAt.Equal(created) {
+		t.Fatalf("items[0] = %q created %v, want %q created %v", items[0].Title(), items[0].CreatedAt, "final", created)
+	}
+	if items[1].Title() != "another" {
+		t.Fatalf("items[1] = %q, want the new task appended", items[1].Title())
+	}
+}
+
+func TestSaveTask_CompletingRecurringReloadsTasks(t *testing.T) {
+	rule, err := task.ParseRecurrence("every week")
+	if err != nil {
+		t.Fatalf("ParseRecurrence error = %v", err)
+	}
+	tk := task.NewWithOptions("chores", "", task.Task{}.DueDate, false)
+	tk.Recurrence = rule
+	loaded := false
+	svc := &commandsFakeService{
+		upsertFn: func(t task.Task) (task.Task, error) {
+			t.Recurrence = nil
+			return t, nil
+		},
+		loadTasksFn: func() ([]task.Task, error) {
+			loaded = true
+			return []task.Task{tk}, nil
+		},
+	}
+
+	l := list.New(tasksToItems([]task.Task{tk}), task.NewTaskDelegate(), 40, 40)
+	m := Model{list: l, service: svc, styles: newAppStyles()}
+
+	_, cmd := m.saveTask(editmenu.SaveTaskMsg{TaskID: tk.GetID(), Title: "chores", Done: true, Recurrence: rule})
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		t.Fatalf("expected a batch of status and reload commands")
+	}
+	if _, ok := batch[len(batch)-1]().(TasksLoadedMsg); !ok || !loaded {
+		t.Fatalf("expected tasks to be reloaded after a recurring task was completed")
+	}
+}
+
+func TestPickBlocker_LinksSelectedTask(t *testing.T) {
+	spec := task.NewWithOptions("spec", "", task.Task{}.DueDate, false)
+	build := task.NewWithOptions("build", "", task.Task{}.DueDate, false)
+
+	var saved task.Task
+	svc := &commandsFakeService{
+		upsertFn: func(t task.Task) (task.Task, error) {
+			saved = t
+			return t, nil
+		},
+	}
+
+	l := list.New(tasksToItems([]task.Task{spec, build}), task.NewTaskDelegate(), 40, 40)
+	m := Model{list: l, service: svc, styles: newAppStyles(), keymap: NewListKeyMap()}
+	m.list.Select(1)
+
+	updated, _ := m.pickBlocker()
+	m = updated.(Model)
+	if m.blockerFor != build.GetID() {
+		t.Fatalf("blockerFor = %v, want %v", m.blockerFor, build.GetID())
+	}
+
+	m.list.Select(0)
+	m, _ = m.stateListUpdate(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.blockerFor != uuid.Nil {
+		t.Fatalf("still picking a blocker after confirming")
+	}
+	if !saved.IsBlockedBy(spec.GetID()) {
+		t.Fatalf("saved BlockedBy = %v, want %v", saved.BlockedBy, spec.GetID())
+	}
+	if got := itemToTask(m.list.Items()[1]); !got.IsBlockedBy(spec.GetID()) {
+		t.Fatalf("list task BlockedBy = %v, want %v", got.BlockedBy, spec.GetID())
+	}
+	if m.list.Title != listModelTitle {
+		t.Fatalf("list title = %q, want it restored", m.list.Title)
+	}
+}
+
+func TestSaveTask_StaleSaveReloadsTasks(t *testing.T) {
+	tk := task.NewWithOptions("draft", "", task.Task{}.DueDate, false)
+	loaded := false
+	svc := &commandsFakeService{
+		upsertFn: func(t task.Task) (task.Task, error) {
+			return t, fmt.Errorf("save tasks: %w", store.ErrStaleSave)
+		},
+		loadTasksFn: func() ([]task.Task, error) {
+			loaded = true
+			return []task.Task{tk}, nil
+		},
+	}
+
+	l := list.New(tasksToItems([]task.Task{tk}), task.NewTaskDelegate(), 40, 40)
+	m := Model{list: l, service: svc, styles: newAppStyles()}
+
+	_, cmd := m.saveTask(editmenu.SaveTaskMsg{TaskID: tk.GetID(), Title: "final"})
+	if cmd == nil {
+		t.Fatalf("saveTask() returned nil cmd")
+	}
+
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		t.Fatalf("expected a batch of status and reload commands")
+	}
+	if _, ok := batch[len(batch)-1]().(TasksLoadedMsg); !ok || !loaded {
+		t.Fatalf("expected tasks to be reloaded after a stale save")
+	}
+}
+
+func TestTasksChanged_ReloadsKeepingSelectionAndEdit(t *testing.T) {
+	first := task.NewWithOptions("first", "", task.Task{}.DueDate, false)
+	second := task.NewWithOptions("second", "", task.Task{}.DueDate, false)
+	external := task.NewWithOptions("from elsewhere", "", task.Task{}.DueDate, false)
+
+	changes := make(chan struct{}, 1)
+	svc := &commandsFakeService{
+		loadTasksFn: func() ([]task.Task, error) {
+			return []task.Task{external, first, second}, nil
+		},
+	}
+
+	l := list.New(tasksToItems([]task.Task{first, second}), task.NewTaskDelegate(), 40, 40)
+	l.Select(1)
+	m := Model{
+		list:     l,
+		service:  svc,
+		styles:   newAppStyles(),
+		editmenu: editmenu.New(second),
+		state:    stateEdit,
+		changes:  changes,
+	}
+
+	updated, cmd := m.Update(TasksChangedMsg{})
+	if cmd == nil {
+		t.Fatalf("Update(TasksChangedMsg) returned nil cmd")
+	}
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("expected a batch of reload and wait commands")
+	}
+	loaded, ok := batch[0]().(TasksLoadedMsg)
+	if !ok {
+		t.Fatalf("expected the first command to reload tasks")
+	}
+
+	// The next change is waited for.
+	changes <- struct{}{}
+	if _, ok := batch[1]().(TasksChangedMsg); !ok {
+		t.Fatalf("expected the second command to wait for the next change")
+	}
+
+	updated, _ = updated.(Model).Update(loaded)
+	got := updated.(Model)
+	if len(got.list.Items()) != 3 {
+		t.Fatalf("list has %d items after reload, want 3", len(got.list.Items()))
+	}
+	if sel := itemToTask(got.list.SelectedItem()); sel.GetID() != second.GetID() {
+		t.Fatalf("selected %q after reload, want %q", sel.Title(), second.Title())
+	}
+	if got.state != stateEdit || !contains(got.View(), "second") {
+		t.Fatalf("open edit lost after reload")
+	}
+}
+
+func TestWaitForChanges_NotWatched(t *testing.T) {
+	if cmd := (Model{}).waitForChangesCmd(); cmd != nil {
+		t.Fatalf("waitForChangesCmd() = non-nil without a changes channel")
+	}
+
+	changes := make(chan struct{})
+	close(changes)
+	if msg := (Model{changes: changes}).waitForChangesCmd()(); msg != nil {
+		t.Fatalf("waitForChangesCmd() after close = %T, want nil", msg)
+	}
+}
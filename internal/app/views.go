@@ -7,6 +7,10 @@ func (m Model) View() string {
 		return m.styles.Frame.Render(m.list.View())
 	case stateEdit:
 		return m.styles.Frame.Render(m.editmenu.View())
+	case stateRepair:
+		return m.styles.Frame.Render(m.repairView())
+	case stateResolve:
+		return m.styles.Frame.Render(m.resolver.View())
 	default:
 		return "Unknown State"
 	}
@@ -0,0 +1,312 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// calendarContentType is the media type of calendar objects.
+const calendarContentType = "text/calendar; charset=utf-8"
+
+var (
+	// ErrPreconditionFailed is returned when a resource was changed on
+	// the server since its ETag was read, or was created meanwhile.
+	ErrPreconditionFailed = errors.New("resource changed on the server")
+
+	// ErrInvalidSyncToken is returned when the server no longer accepts
+	// a sync token, so the whole collection has to be listed again.
+	ErrInvalidSyncToken = errors.New("sync token is no longer valid")
+)
+
+// StatusError is returned for a request the server answered with an
+// unexpected status.
+type StatusError struct {
+	Method string
+	URL    string
+	Code   int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.Code, http.StatusText(e.Code))
+}
+
+// Resource is a calendar object in a collection, identified by its href,
+// the path of its URL.
+type Resource struct {
+	Href string
+	ETag string
+}
+
+// Client talks to a CalDAV calendar collection.
+type Client struct {
+	// HTTP sends the requests; nil means http.DefaultClient.
+	HTTP *http.Client
+
+	collection         *url.URL
+	username, password string
+}
+
+// NewClient returns a client for the calendar collection at rawURL,
+// which authenticates with username and password when username is set.
+func NewClient(rawURL, username, password string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("calendar URL %q: want an http or https URL", rawURL)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	if u.User != nil && username == "" {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+	}
+	u.User = nil
+	return &Client{collection: u, username: username, password: password}, nil
+}
+
+// Collection returns the URL of the calendar collection.
+func (c *Client) Collection() string {
+	return c.collection.String()
+}
+
+// Href returns the href a new calendar object with the given name gets
+// in the collection.
+func (c *Client) Href(name string) string {
+	return c.collection.Path + url.PathEscape(name)
+}
+
+// SyncCollection asks for the calendar objects changed and removed
+// since the sync token was handed out (RFC 6578), or for all of them
+// when token is empty. It returns them with the token to ask with next
+// time.
+func (c *Client) SyncCollection(ctx context.Context, token string) (changed []Resource, removed []string, next string, err error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>` +
+		`<d:sync-collection xmlns:d="DAV:"><d:sync-token>`)
+	if err := xml.EscapeText(&body, []byte(token)); err != nil {
+		return nil, nil, "", err
+	}
+	body.WriteString(`</d:sync-token><d:sync-level>1</d:sync-level>` +
+		`<d:prop><d:getetag/></d:prop></d:sync-collection>`)
+
+	ms, err := c.multistatus(ctx, "REPORT", c.collection.String(), "", body.Bytes())
+	var status *StatusError
+	if errors.As(err, &status) && token != "" &&
+		(status.Code == http.StatusForbidden || status.Code == http.StatusConflict) {
+		return nil, nil, "", ErrInvalidSyncToken
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	for _, r := range ms.Responses {
+		if r.isCollection() {
+			continue
+		}
+		if statusCode(r.Status) == http.StatusNotFound {
+			removed = append(removed, r.Href)
+			continue
+		}
+		if etag, ok := r.etag(); ok {
+			changed = append(changed, Resource{Href: r.Href, ETag: etag})
+		}
+	}
+	return changed, removed, ms.SyncToken, nil
+}
+
+// List returns every calendar object in the collection, for servers
+// that do not support SyncCollection.
+func (c *Client) List(ctx context.Context) ([]Resource, error) {
+	body := []byte(`<?xml version="1.0" encoding="utf-8"?>` +
+		`<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getetag/></d:prop></d:propfind>`)
+	ms, err := c.multistatus(ctx, "PROPFIND", c.collection.String(), "1", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []Resource
+	for _, r := range ms.Responses {
+		if r.isCollection() {
+			continue
+		}
+		if etag, ok := r.etag(); ok {
+			resources = append(resources, Resource{Href: r.Href, ETag: etag})
+		}
+	}
+	return resources, nil
+}
+
+// Get returns the calendar object at href with its ETag.
+func (c *Client) Get(ctx context.Context, href string) (string, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, href, nil, nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+	return string(b), resp.Header.Get("ETag"), nil
+}
+
+// Put stores a calendar object at href and returns its new ETag, which
+// is empty when the server does not tell. With an etag, the object is
+// only replaced if it was not changed since; without one, it is only
+// created if there is none yet. Otherwise ErrPreconditionFailed is
+// returned.
+func (c *Client) Put(ctx context.Context, href, data, etag string) (string, error) {
+	header := http.Header{"Content-Type": {calendarContentType}}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	} else {
+		header.Set("If-None-Match", "*")
+	}
+	resp, err := c.do(ctx, http.MethodPut, href, header, strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+// Delete removes the calendar object at href, unless it was changed
+// since etag, if given, which yields ErrPreconditionFailed. An object
+// that is gone already is no error.
+func (c *Client) Delete(ctx context.Context, href, etag string) error {
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	}
+	resp, err := c.do(ctx, http.MethodDelete, href, header, nil)
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a request for href, resolved against the collection, and
+// fails unless the server answers with a success status.
+func (c *Client) do(ctx context.Context, method, href string, header http.Header, body io.Reader) (*http.Response, error) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	u := c.collection.ResolveReference(ref)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil, fmt.Errorf("%s %s: %w", method, u, ErrPreconditionFailed)
+	}
+	return nil, &StatusError{Method: method, URL: u.String(), Code: resp.StatusCode}
+}
+
+func (c *Client) multistatus(ctx context.Context, method, href, depth string, body []byte) (*multistatus, error) {
+	header := http.Header{"Content-Type": {"application/xml; charset=utf-8"}}
+	if depth != "" {
+		header.Set("Depth", depth)
+	}
+	resp, err := c.do(ctx, method, href, header, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, &StatusError{Method: method, URL: href, Code: resp.StatusCode}
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, href, err)
+	}
+	return &ms, nil
+}
+
+// multistatus is a WebDAV multi-status response (RFC 4918).
+type multistatus struct {
+	Responses []response `xml:"DAV: response"`
+	SyncToken string     `xml:"DAV: sync-token"`
+}
+
+type response struct {
+	Href     string     `xml:"DAV: href"`
+	Status   string     `xml:"DAV: status"`
+	Propstat []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   struct {
+		ETag         string `xml:"DAV: getetag"`
+		ResourceType struct {
+			Collection *struct{} `xml:"DAV: collection"`
+		} `xml:"DAV: resourcetype"`
+	} `xml:"DAV: prop"`
+}
+
+func (r response) isCollection() bool {
+	for _, ps := range r.Propstat {
+		if ps.Prop.ResourceType.Collection != nil {
+			return true
+		}
+	}
+	return strings.HasSuffix(r.Href, "/")
+}
+
+func (r response) etag() (string, bool) {
+	for _, ps := range r.Propstat {
+		if statusCode(ps.Status) == http.StatusOK && ps.Prop.ETag != "" {
+			return ps.Prop.ETag, true
+		}
+	}
+	return "", false
+}
+
+// statusCode returns the code in a status line such as
+// "HTTP/1.1 404 Not Found", or 0.
+func statusCode(line string) int {
+	fields := strings.Fields(line)
+	if len(fields) < 2 { //nolint:mnd
+		return 0
+	}
+	var code int
+	_, _ = fmt.Sscanf(fields[1], "%d", &code)
+	return code
+}
@@ -0,0 +1,259 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	fakeCollection = "/calendars/me/tasks/"
+	fakeTokenBase  = "http://fake.example/sync/"
+)
+
+// fakeServer is a CalDAV server holding one calendar collection in
+// memory. It supports sync-collection reports, unless noSync is set,
+// and conditional writes, and counts the requests made to it.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	objects  map[string]*fakeObject
+	removed  map[string]int
+	version  int
+	noSync   bool
+	requests map[string]int
+
+	// beforeWrite, when set, is called before a PUT or DELETE is
+	// handled, with the lock held, to change the collection in between.
+	beforeWrite func(href string)
+}
+
+type fakeObject struct {
+	data    string
+	etag    string
+	version int
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	s := &fakeServer{objects: map[string]*fakeObject{}, removed: map[string]int{}, requests: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeServer) client(t *testing.T) *Client {
+	t.Helper()
+	c, err := NewClient(s.URL+fakeCollection, "me", "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return c
+}
+
+// put stores an object as another client would.
+func (s *fakeServer) put(href, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(href, data)
+}
+
+// edit changes an object as another client would.
+func (s *fakeServer) edit(href string, change func(string) string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(href, change(s.objects[href].data))
+}
+
+// remove deletes an object as another client would.
+func (s *fakeServer) remove(href string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delete(href)
+}
+
+func (s *fakeServer) data(href string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.objects[href]
+	if !ok {
+		return "", false
+	}
+	return o.data, true
+}
+
+func (s *fakeServer) hrefs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var hrefs []string
+	for href := range s.objects {
+		hrefs = append(hrefs, href)
+	}
+	slices.Sort(hrefs)
+	return hrefs
+}
+
+func (s *fakeServer) resetRequests() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.requests
+	s.requests = map[string]int{}
+	return r
+}
+
+func (s *fakeServer) store(href, data string) string {
+	s.version++
+	o := &fakeObject{data: data, etag: fmt.Sprintf(`"v%d"`, s.version), version: s.version}
+	s.objects[href] = o
+	delete(s.removed, href)
+	return o.etag
+}
+
+func (s *fakeServer) delete(href string) {
+	s.version++
+	delete(s.objects, href)
+	s.removed[href] = s.version
+}
+
+func (s *fakeServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.Method]++
+
+	if user, pass, ok := r.BasicAuth(); !ok || user != "me" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	href := r.URL.Path
+	if !strings.HasPrefix(href, fakeCollection) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if (r.Method == http.MethodPut || r.Method == http.MethodDelete) && s.beforeWrite != nil {
+		s.beforeWrite(href)
+	}
+
+	o := s.objects[href]
+	switch r.Method {
+	case "PROPFIND":
+		s.propfind(w)
+
+	case "REPORT":
+		if s.noSync {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		s.report(w, r)
+
+	case http.MethodGet:
+		if o == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", o.etag)
+		_, _ = io.WriteString(w, o.data)
+
+	case http.MethodPut:
+		if !s.preconditions(r, o) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("ETag", s.store(href, string(body)))
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodDelete:
+		if o == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !s.preconditions(r, o) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		s.delete(href)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *fakeServer) preconditions(r *http.Request, o *fakeObject) bool {
+	if match := r.Header.Get("If-Match"); match != "" && (o == nil || o.etag != match) {
+		return false
+	}
+	return r.Header.Get("If-None-Match") != "*" || o == nil
+}
+
+func (s *fakeServer) propfind(w http.ResponseWriter) {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<d:response><d:href>%s</d:href><d:propstat><d:prop>`+
+		`<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>`+
+		`</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, fakeCollection)
+	for href, o := range s.objects {
+		writeObjectResponse(&b, href, o.etag)
+	}
+	writeMultistatus(w, b.String(), "")
+}
+
+func (s *fakeServer) report(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `xml:"DAV: sync-token"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	since := 0
+	if req.Token != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(req.Token, fakeTokenBase))
+		if err != nil || !strings.HasPrefix(req.Token, fakeTokenBase) || n > s.version {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `<d:error xmlns:d="DAV:"><d:valid-sync-token/></d:error>`)
+			return
+		}
+		since = n
+	}
+
+	var b strings.Builder
+	for href, o := range s.objects {
+		if o.version > since {
+			writeObjectResponse(&b, href, o.etag)
+		}
+	}
+	if req.Token != "" {
+		for href, v := range s.removed {
+			if v > since {
+				fmt.Fprintf(&b, `<d:response><d:href>%s</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>`, href)
+			}
+		}
+	}
+	writeMultistatus(w, b.String(), fakeTokenBase+strconv.Itoa(s.version))
+}
+
+func writeObjectResponse(b *strings.Builder, href, etag string) {
+	fmt.Fprintf(b, `<d:response><d:href>%s</d:href><d:propstat><d:prop>`+
+		`<d:getetag>%s</d:getetag><d:resourcetype/>`+
+		`</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, href, html.EscapeString(etag))
+}
+
+func writeMultistatus(w http.ResponseWriter, responses, token string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>`+
+		`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">%s`, responses)
+	if token != "" {
+		fmt.Fprintf(w, `<d:sync-token>%s</d:sync-token>`, token)
+	}
+	_, _ = io.WriteString(w, `</d:multistatus>`)
+}
@@ -0,0 +1,294 @@
+package caldav
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest content line iCalendar allows before it
+// has to be folded, not counting the line break.
+const maxLineOctets = 75
+
+// ErrInvalidCalendar is returned for data that is not an iCalendar
+// object.
+var ErrInvalidCalendar = errors.New("invalid iCalendar data")
+
+// Component is an iCalendar component, such as a VCALENDAR or the VTODO
+// inside it, with its properties and the components nested in it, in
+// the order they were read.
+type Component struct {
+	Name       string
+	Props      []Prop
+	Components []*Component
+}
+
+// Prop is a property of a component. Value is kept as it is written, so
+// text values are still escaped; see Text and SetText.
+type Prop struct {
+	Name   string
+	Params []Param
+	Value  string
+}
+
+// Param is a property parameter, such as VALUE=DATE.
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Param returns the value of the named parameter, or "" when the
+// property does not have it.
+func (p Prop) Param(name string) string {
+	for _, param := range p.Params {
+		if strings.EqualFold(param.Name, name) {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// Text returns the value of a text property with its escapes undone.
+func (p Prop) Text() string {
+	return unescapeText(p.Value)
+}
+
+// Get returns the first property with the given name, or nil.
+func (c *Component) Get(name string) *Prop {
+	for i := range c.Props {
+		if strings.EqualFold(c.Props[i].Name, name) {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// All returns the properties with the given name.
+func (c *Component) All(name string) []Prop {
+	var props []Prop
+	for _, p := range c.Props {
+		if strings.EqualFold(p.Name, name) {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// Set replaces the properties with the given name by p, which takes the
+// place of the first of them, or is added at the end.
+func (c *Component) Set(p Prop) {
+	for i := range c.Props {
+		if strings.EqualFold(c.Props[i].Name, p.Name) {
+			c.Props[i] = p
+			c.Props = append(c.Props[:i+1], removeProps(c.Props[i+1:], p.Name)...)
+			return
+		}
+	}
+	c.Props = append(c.Props, p)
+}
+
+// SetText sets the text property name to the escaped value, or removes
+// it when value is empty.
+func (c *Component) SetText(name, value string) {
+	if value == "" {
+		c.Del(name)
+		return
+	}
+	c.Set(Prop{Name: name, Value: escapeText(value)})
+}
+
+// Del removes the properties with the given name.
+func (c *Component) Del(name string) {
+	c.Props = removeProps(c.Props, name)
+}
+
+// Child returns the first component nested in c with the given name, or
+// nil.
+func (c *Component) Child(name string) *Component {
+	for _, child := range c.Components {
+		if strings.EqualFold(child.Name, name) {
+			return child
+		}
+	}
+	return nil
+}
+
+func removeProps(props []Prop, name string) []Prop {
+	kept := props[:0]
+	for _, p := range props {
+		if !strings.EqualFold(p.Name, name) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// ParseCalendar parses an iCalendar object, such as a CalDAV resource,
+// and returns its VCALENDAR component.
+func ParseCalendar(data string) (*Component, error) {
+	var (
+		root  *Component
+		stack []*Component
+	)
+	for _, line := range unfold(data) {
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.EqualFold(p.Name, "BEGIN"):
+			c := &Component{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else if root == nil {
+				root = c
+			} else {
+				return nil, fmt.Errorf("%w: more than one top-level component", ErrInvalidCalendar)
+			}
+			stack = append(stack, c)
+
+		case strings.EqualFold(p.Name, "END"):
+			if len(stack) == 0 || !strings.EqualFold(stack[len(stack)-1].Name, p.Value) {
+				return nil, fmt.Errorf("%w: unexpected END:%s", ErrInvalidCalendar, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+
+		case len(stack) == 0:
+			return nil, fmt.Errorf("%w: property %s outside a component", ErrInvalidCalendar, p.Name)
+
+		default:
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, p)
+		}
+	}
+	if root == nil || len(stack) > 0 || root.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("%w: no complete VCALENDAR", ErrInvalidCalendar)
+	}
+	return root, nil
+}
+
+// unfold splits data into content lines, joining the lines that
+// continue the one before them.
+func unfold(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseLine parses a content line: a name, its parameters, and a value
+// after the first colon that is not inside a quoted parameter value.
+func parseLine(line string) (Prop, error) {
+	var (
+		p      Prop
+		quoted bool
+		named  bool
+		start  int
+	)
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == ';' || r == ':':
+			part := line[start:i]
+			if !named {
+				p.Name = strings.ToUpper(part)
+			} else {
+				name, value, _ := strings.Cut(part, "=")
+				p.Params = append(p.Params, Param{Name: strings.ToUpper(name), Value: strings.Trim(value, `"`)})
+			}
+			start, named = i+1, true
+			if r == ':' {
+				p.Value = line[i+1:]
+				if p.Name == "" {
+					return Prop{}, fmt.Errorf("%w: line %q has no name", ErrInvalidCalendar, line)
+				}
+				return p, nil
+			}
+		}
+	}
+	return Prop{}, fmt.Errorf("%w: line %q has no value", ErrInvalidCalendar, line)
+}
+
+// String writes c as iCalendar data, with CRLF line breaks and long
+// lines folded.
+func (c *Component) String() string {
+	var b strings.Builder
+	c.write(&b)
+	return b.String()
+}
+
+func (c *Component) write(b *strings.Builder) {
+	writeLine(b, "BEGIN:"+c.Name)
+	for _, p := range c.Props {
+		var line strings.Builder
+		line.WriteString(p.Name)
+		for _, param := range p.Params {
+			value := param.Value
+			if strings.ContainsAny(value, ":;,") {
+				value = `"` + value + `"`
+			}
+			line.WriteString(";" + param.Name + "=" + value)
+		}
+		line.WriteString(":" + p.Value)
+		writeLine(b, line.String())
+	}
+	for _, child := range c.Components {
+		child.write(b)
+	}
+	writeLine(b, "END:"+c.Name)
+}
+
+// writeLine writes a content line, folding it so no line is longer than
+// maxLineOctets, without splitting a character.
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// The space starting a continuation line counts too.
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line + "\r\n")
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeText(s string) string   { return textEscaper.Replace(s) }
+func unescapeText(s string) string { return textUnescaper.Replace(s) }
+
+// splitList splits a property value holding a list of escaped text
+// values, such as CATEGORIES, at the commas that are not escaped.
+func splitList(value string) []string {
+	var (
+		items []string
+		start int
+	)
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, unescapeText(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, unescapeText(value[start:]))
+}
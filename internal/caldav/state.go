@@ -0,0 +1,126 @@
+package caldav
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// stateVersion is the version of the sync state file format.
+const stateVersion = 1
+
+// State is what the last sync left: the sync token to ask for changes
+// with, and each task as it was on both sides, from which the next sync
+// tells which side changed what. It is kept in a JSON file.
+type State struct {
+	Version int `json:"version"`
+
+	// Collection is the URL of the calendar the state belongs to;
+	// syncing with another one starts over.
+	Collection string `json:"collection"`
+
+	SyncToken string `json:"syncToken,omitempty"`
+
+	Items map[uuid.UUID]*Item `json:"items"`
+
+	// Others are the calendar objects in the collection that are not
+	// tasks, such as events, by href, with their ETags, so they are not
+	// fetched again until they change.
+	Others map[string]string `json:"others,omitempty"`
+}
+
+// Item is a task as of the last sync.
+type Item struct {
+	Href string `json:"href"`
+	ETag string `json:"etag"`
+
+	// Data is the calendar object as it was on the server, the base new
+	// versions of it are written into.
+	Data string `json:"data"`
+
+	// Task is the task as it was in the store.
+	Task task.Task `json:"task"`
+}
+
+// newState returns the state of a collection never synced with.
+func newState(collection string) *State {
+	return &State{
+		Version:    stateVersion,
+		Collection: collection,
+		Items:      map[uuid.UUID]*Item{},
+		Others:     map[string]string{},
+	}
+}
+
+// LoadState reads the sync state file at path for the collection. A
+// missing file, or one kept for another collection, yields the state of
+// a collection never synced with.
+func LoadState(path, collection string) (*State, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newState(collection), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s State
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("read sync state %s: %w", path, err)
+	}
+	if s.Version != stateVersion {
+		return nil, fmt.Errorf("sync state %s: unsupported version %d", path, s.Version)
+	}
+	if s.Collection != collection {
+		return newState(collection), nil
+	}
+	if s.Items == nil {
+		s.Items = map[uuid.UUID]*Item{}
+	}
+	if s.Others == nil {
+		s.Others = map[string]string{}
+	}
+	return &s, nil
+}
+
+// Save writes the state to the file at path, readable by the user only
+// since it holds the tasks.
+func (s *State) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// byHref returns the ID of the task synced as the object at href.
+func (s *State) byHref(href string) (uuid.UUID, bool) {
+	for id, item := range s.Items {
+		if item.Href == href {
+			return id, true
+		}
+	}
+	return uuid.Nil, false
+}
@@ -0,0 +1,410 @@
+// Package caldav syncs tasks with a calendar on a CalDAV server, such
+// as Nextcloud, Radicale, or Fastmail, where calendar clients show them
+// as to-dos. Each task is a VTODO calendar object named after its ID.
+//
+// A sync asks the server for what changed since the last one, by sync
+// token (RFC 6578) where the server supports it or else by comparing
+// the ETags of all objects, and compares the store's tasks with those
+// in the sync state file, which records each task as it was on both
+// sides. Changes made on one side are copied to the other; a task
+// changed on both sides is merged field by field, and a field changed
+// on both takes the change made last. Writes carry the ETag they were
+// based on, so a change made on the server meanwhile is never
+// overwritten, only merged by the next sync.
+package caldav
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/charmbracelet/log"
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/merge"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// objectSuffix ends the name of each calendar object terminaltask
+// creates.
+const objectSuffix = ".ics"
+
+// Report counts what a sync changed.
+type Report struct {
+	// Sent and Received count the tasks created or changed on the
+	// server and in the store.
+	Sent     int
+	Received int
+
+	// DeletedRemote and DeletedLocal count the tasks deleted on the
+	// server and in the store.
+	DeletedRemote int
+	DeletedLocal  int
+
+	// Merged counts the tasks changed on both sides.
+	Merged int
+
+	// Deferred counts the changes not sent because the task was changed
+	// on the server meanwhile; the next sync merges them.
+	Deferred int
+}
+
+// Syncer syncs the tasks in a store with a calendar collection.
+type Syncer struct {
+	Client *Client
+	Store  store.TaskStore
+
+	// StatePath is the sync state file.
+	StatePath string
+}
+
+// remoteChange is a calendar object the server changed or removed.
+type remoteChange struct {
+	href, etag, data string
+	task             task.Task
+	removed          bool
+}
+
+// Sync copies the changes made on each side since the last sync to the
+// other. It stops at the first request that fails, keeping what was
+// synced until then.
+func (s *Syncer) Sync(ctx context.Context) (Report, error) {
+	state, err := LoadState(s.StatePath, s.Client.Collection())
+	if err != nil {
+		return Report{}, err
+	}
+	local, err := s.Store.Load()
+	if err != nil {
+		return Report{}, err
+	}
+	changes, next, err := s.remoteChanges(ctx, state)
+	if err != nil {
+		return Report{}, err
+	}
+
+	p := &syncPass{ctx: ctx, client: s.Client, state: state, tasks: slices.Clone(local)}
+	for _, id := range syncOrder(local, state, changes) {
+		if err = p.sync(id, changes); err != nil {
+			break
+		}
+	}
+
+	if p.changed {
+		if saveErr := s.Store.Save(p.tasks); saveErr != nil {
+			return p.report, saveErr
+		}
+	}
+	if err == nil {
+		state.SyncToken = next
+	}
+	if saveErr := state.Save(s.StatePath); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return p.report, err
+}
+
+// remoteChanges returns the calendar objects changed or removed on the
+// server since the last sync, by task ID, and the sync token to ask
+// with next time, if the server hands them out.
+func (s *Syncer) remoteChanges(ctx context.Context, state *State) (map[uuid.UUID]remoteChange, string, error) {
+	changed, removed, next, err := s.Client.SyncCollection(ctx, state.SyncToken)
+	full := state.SyncToken == ""
+	if errors.Is(err, ErrInvalidSyncToken) {
+		changed, removed, next, err = s.Client.SyncCollection(ctx, "")
+		full = true
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		// No sync-collection support: compare the ETags of all objects.
+		changed, err = s.Client.List(ctx)
+		removed, next, full = nil, "", true
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if full {
+		// A full listing leaves out what was removed.
+		listed := map[string]bool{}
+		for _, r := range changed {
+			listed[r.Href] = true
+		}
+		for _, item := range state.Items {
+			if !listed[item.Href] {
+				removed = append(removed, item.Href)
+			}
+		}
+		for href := range state.Others {
+			if !listed[href] {
+				delete(state.Others, href)
+			}
+		}
+	}
+
+	changes := map[uuid.UUID]remoteChange{}
+	for _, href := range removed {
+		delete(state.Others, href)
+		if id, ok := state.byHref(href); ok {
+			changes[id] = remoteChange{href: href, removed: true}
+		}
+	}
+	for _, r := range changed {
+		if etag, ok := state.Others[r.Href]; ok && etag == r.ETag {
+			continue
+		}
+		if id, ok := state.byHref(r.Href); ok && state.Items[id].ETag == r.ETag {
+			continue
+		}
+
+		data, etag, err := s.Client.Get(ctx, r.Href)
+		if errors.As(err, &status) && status.Code == http.StatusNotFound {
+			// Removed since it was listed; the next sync is told.
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if etag == "" {
+			etag = r.ETag
+		}
+
+		t, ok, err := DecodeTask(data)
+		if err != nil {
+			log.Warn("Skipping unreadable calendar object", "href", r.Href, "err", err)
+		}
+		if err != nil || !ok {
+			state.Others[r.Href] = etag
+			continue
+		}
+		delete(state.Others, r.Href)
+		changes[t.ID] = remoteChange{href: r.Href, etag: etag, data: data, task: t}
+	}
+	return changes, next, nil
+}
+
+// syncOrder returns the IDs of every task on either side: those in the
+// store in its order, then those only synced before or only on the
+// server, by href.
+func syncOrder(local []task.Task, state *State, changes map[uuid.UUID]remoteChange) []uuid.UUID {
+	seen := map[uuid.UUID]bool{}
+	var ids []uuid.UUID
+	for _, t := range local {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			ids = append(ids, t.ID)
+		}
+	}
+
+	href := map[uuid.UUID]string{}
+	for id, item := range state.Items {
+		href[id] = item.Href
+	}
+	for id, c := range changes {
+		href[id] = c.href
+	}
+	var rest []uuid.UUID
+	for id := range href {
+		if !seen[id] {
+			rest = append(rest, id)
+		}
+	}
+	slices.SortFunc(rest, func(a, b uuid.UUID) int { return cmp.Compare(href[a], href[b]) })
+	return append(ids, rest...)
+}
+
+// syncPass carries the tasks being synced from one task to the next.
+type syncPass struct {
+	ctx    context.Context
+	client *Client
+	state  *State
+
+	// tasks are the store's tasks as they are to be saved, and changed
+	// reports whether they need saving.
+	tasks   []task.Task
+	changed bool
+
+	report Report
+}
+
+// sync syncs the task with the given ID.
+func (p *syncPass) sync(id uuid.UUID, changes map[uuid.UUID]remoteChange) error {
+	item := p.state.Items[id]
+	i := slices.IndexFunc(p.tasks, func(t task.Task) bool { return t.ID == id })
+	inLocal := i >= 0
+	var local task.Task
+	if inLocal {
+		local = p.tasks[i]
+	}
+	remote, inRemote := changes[id]
+
+	switch {
+	case item == nil && inRemote && !remote.removed && inLocal:
+		// Added on both sides, as when two stores with the same tasks
+		// are first synced.
+		return p.merge(nil, local, remote)
+
+	case item == nil && inLocal:
+		return p.push(local, p.client.Href(id.String()+objectSuffix), "", "")
+
+	case item == nil && inRemote && !remote.removed:
+		p.put(remote.task)
+		p.remember(remote, remote.task)
+		p.report.Received++
+		return nil
+
+	case item == nil:
+		return nil
+
+	case inRemote && remote.removed:
+		switch {
+		case !inLocal:
+			delete(p.state.Items, id)
+		case !sameTask(local, item.Task):
+			// Changed here after being deleted there: keep the change.
+			return p.push(local, item.Href, "", item.Data)
+		default:
+			p.remove(id)
+			delete(p.state.Items, id)
+			p.report.DeletedLocal++
+		}
+		return nil
+
+	case inRemote && !inLocal:
+		// Changed there after being deleted here: keep the change.
+		p.put(remote.task)
+		p.remember(remote, remote.task)
+		p.report.Received++
+		return nil
+
+	case inRemote:
+		return p.merge(item, local, remote)
+
+	case !inLocal:
+		err := p.client.Delete(p.ctx, item.Href, item.ETag)
+		if errors.Is(err, ErrPreconditionFailed) {
+			p.report.Deferred++
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		delete(p.state.Items, id)
+		p.report.DeletedRemote++
+		return nil
+
+	case !sameTask(local, item.Task):
+		return p.push(local, item.Href, item.ETag, item.Data)
+	}
+	return nil
+}
+
+// merge merges the task as it is in the store and on the server, which
+// both changed it since item was synced, or both added it when item is
+// nil. A field changed on both sides keeps the change made last. The
+// merged task is saved on both sides.
+func (p *syncPass) merge(item *Item, local task.Task, remote remoteChange) error {
+	var base []task.Task
+	theirs := remote.task
+	if item != nil {
+		base = []task.Task{item.Task}
+		theirs = p.asStored(item, remote.task)
+	}
+
+	r := merge.Merge(base, []task.Task{local}, []task.Task{theirs})
+	for i := range r.Conflicts {
+		r.Conflicts[i].Choice = merge.Ours
+		if theirs.UpdatedAt.After(local.UpdatedAt) {
+			r.Conflicts[i].Choice = merge.Theirs
+		}
+	}
+	tasks, err := r.Resolved()
+	if err != nil || len(tasks) != 1 {
+		return err
+	}
+	merged := tasks[0]
+
+	if !sameTask(merged, local) {
+		p.put(merged)
+		if item == nil || !sameTask(local, item.Task) {
+			p.report.Merged++
+		} else {
+			p.report.Received++
+		}
+	}
+	if EncodeTask(merged, remote.data) == EncodeTask(theirs, remote.data) {
+		p.remember(remote, merged)
+		return nil
+	}
+	return p.push(merged, remote.href, remote.etag, remote.data)
+}
+
+// asStored returns the server's new version of the task synced as item
+// in the form the store keeps it in: the fields the server changed
+// since are taken from it, and the rest from the task as it was in the
+// store, so that a value the server merely writes differently is not
+// taken for a change.
+func (p *syncPass) asStored(item *Item, remote task.Task) task.Task {
+	old, ok, err := DecodeTask(item.Data)
+	if err != nil || !ok {
+		return remote
+	}
+	r := merge.Merge([]task.Task{old}, []task.Task{item.Task}, []task.Task{remote})
+	for i := range r.Conflicts {
+		r.Conflicts[i].Choice = merge.Theirs
+	}
+	tasks, err := r.Resolved()
+	if err != nil || len(tasks) != 1 {
+		return remote
+	}
+	return tasks[0]
+}
+
+// push writes t to the server at href, written into prev, the object as
+// it was there. With an etag it replaces the object only if unchanged
+// since, and without one it creates it. A change made meanwhile on the
+// server leaves the task for the next sync to merge.
+func (p *syncPass) push(t task.Task, href, etag, prev string) error {
+	data := EncodeTask(t, prev)
+	newETag, err := p.client.Put(p.ctx, href, data, etag)
+	if errors.Is(err, ErrPreconditionFailed) {
+		p.report.Deferred++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p.state.Items[t.ID] = &Item{Href: href, ETag: newETag, Data: data, Task: t}
+	p.report.Sent++
+	return nil
+}
+
+// remember records that t was synced as the server's object remote.
+func (p *syncPass) remember(remote remoteChange, t task.Task) {
+	p.state.Items[t.ID] = &Item{Href: remote.href, ETag: remote.etag, Data: remote.data, Task: t}
+}
+
+// put replaces the task with t's ID in the store's tasks, or adds t.
+func (p *syncPass) put(t task.Task) {
+	p.changed = true
+	if i := slices.IndexFunc(p.tasks, func(u task.Task) bool { return u.ID == t.ID }); i >= 0 {
+		p.tasks[i] = t
+		return
+	}
+	p.tasks = append(p.tasks, t)
+}
+
+// remove deletes the task with the given ID from the store's tasks.
+func (p *syncPass) remove(id uuid.UUID) {
+	p.changed = true
+	p.tasks = slices.DeleteFunc(p.tasks, func(t task.Task) bool { return t.ID == id })
+}
+
+// sameTask reports whether a and b are the same version of a task.
+func sameTask(a, b task.Task) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
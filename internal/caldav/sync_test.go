@@ -0,0 +1,260 @@
+package caldav
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// foreignTodo is a to-do created in a calendar client, with a UID that
+// is not a UUID and an alarm terminaltask does not know.
+const foreignTodo = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Example//Calendar//EN\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:20261017-buy-milk@example.com\r\n" +
+	"DTSTAMP:20261017T080000Z\r\n" +
+	"SUMMARY:Buy milk\r\n" +
+	"CATEGORIES:Errands,Home\r\n" +
+	"PRIORITY:2\r\n" +
+	"DUE;VALUE=DATE:20261020\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"END:VALARM\r\n" +
+	"END:VTODO\r\n" +
+	"END:VCALENDAR\r\n"
+
+const event = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"DTSTART:20261017T090000Z\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+type syncFixture struct {
+	t      *testing.T
+	server *fakeServer
+	store  *store.MemTaskStore
+	syncer *Syncer
+}
+
+func newSyncFixture(t *testing.T, tasks ...task.Task) *syncFixture {
+	t.Helper()
+	server := newFakeServer(t)
+	mem := store.NewMemTaskStore()
+	if err := mem.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	return &syncFixture{
+		t:      t,
+		server: server,
+		store:  mem,
+		syncer: &Syncer{
+			Client:    server.client(t),
+			Store:     mem,
+			StatePath: filepath.Join(t.TempDir(), "caldav-sync.json"),
+		},
+	}
+}
+
+func (f *syncFixture) sync(want Report) {
+	f.t.Helper()
+	got, err := f.syncer.Sync(context.Background())
+	if err != nil {
+		f.t.Fatalf("Sync() error = %v", err)
+	}
+	if got != want {
+		f.t.Fatalf("Sync() = %+v, want %+v", got, want)
+	}
+}
+
+func (f *syncFixture) tasks() []task.Task {
+	f.t.Helper()
+	tasks, err := f.store.Load()
+	if err != nil {
+		f.t.Fatalf("Load() error = %v", err)
+	}
+	return tasks
+}
+
+func (f *syncFixture) edit(id uuid.UUID, change func(*task.Task)) {
+	f.t.Helper()
+	tasks := f.tasks()
+	for i := range tasks {
+		if tasks[i].ID == id {
+			change(&tasks[i])
+			tasks[i].UpdatedAt = tasks[i].UpdatedAt.Add(time.Minute)
+		}
+	}
+	if err := f.store.Save(tasks); err != nil {
+		f.t.Fatalf("Save() error = %v", err)
+	}
+}
+
+func titlesOf(tasks []task.Task) string {
+	titles := make([]string, len(tasks))
+	for i, t := range tasks {
+		titles[i] = t.TitleStr
+	}
+	return strings.Join(titles, ", ")
+}
+
+func objectHref(id uuid.UUID) string {
+	return fakeCollection + id.String() + objectSuffix
+}
+
+func newSyncTask(title string) task.Task {
+	t := task.NewWithOptions(title, "", time.Time{}, false)
+	t.UpdatedAt = time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	return t
+}
+
+func TestSyncer_TwoWay(t *testing.T) {
+	report := newSyncTask("Write report")
+	gym := newSyncTask("Gym")
+	f := newSyncFixture(t, report, gym)
+
+	// The first sync uploads every task.
+	f.sync(Report{Sent: 2})
+	if got := f.server.hrefs(); len(got) != 2 || got[0] != min(objectHref(report.ID), objectHref(gym.ID)) {
+		t.Fatalf("server objects = %q, want one per task", got)
+	}
+
+	// Changes made in a calendar client come down.
+	f.server.edit(objectHref(report.ID), func(data string) string {
+		return strings.Replace(data, "SUMMARY:Write report", "SUMMARY:Write quarterly report", 1)
+	})
+	f.server.remove(objectHref(gym.ID))
+	f.server.put(fakeCollection+"milk.ics", foreignTodo)
+	f.server.put(fakeCollection+"standup.ics", event)
+	f.sync(Report{Received: 2, DeletedLocal: 1})
+
+	tasks := f.tasks()
+	if got, want := titlesOf(tasks), "Write quarterly report, Buy milk"; got != want {
+		t.Fatalf("tasks = %q, want %q", got, want)
+	}
+	milk := tasks[1]
+	if milk.Priority != task.PriorityHigh || strings.Join(milk.Tags, " ") != "errands home" || milk.DueHasTime {
+		t.Fatalf("foreign to-do read as %+v", milk)
+	}
+
+	// Changes made here go up, keeping what other clients added.
+	f.edit(milk.ID, func(t *task.Task) { t.SetDone(true) })
+	f.edit(report.ID, func(t *task.Task) { t.TitleStr = "Deleted soon" })
+	if err := f.store.Save(f.tasks()[1:]); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	added := newSyncTask("Call Mom")
+	if err := f.store.Save(append(f.tasks(), added)); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	f.sync(Report{Sent: 2, DeletedRemote: 1})
+
+	data, ok := f.server.data(fakeCollection + "milk.ics")
+	if !ok || !strings.Contains(data, "STATUS:COMPLETED") || !strings.Contains(data, "BEGIN:VALARM") ||
+		!strings.Contains(data, "UID:20261017-buy-milk@example.com") {
+		t.Fatalf("completed to-do on the server:\n%s", data)
+	}
+	if _, ok := f.server.data(objectHref(report.ID)); ok {
+		t.Fatal("deleted task is still on the server")
+	}
+	if _, ok := f.server.data(objectHref(added.ID)); !ok {
+		t.Fatal("added task was not uploaded")
+	}
+
+	// A task changed on both sides keeps both changes.
+	f.edit(added.ID, func(t *task.Task) { t.DescStr = "about the weekend" })
+	f.server.edit(objectHref(added.ID), func(data string) string {
+		return strings.Replace(data, "SUMMARY:Call Mom", "SUMMARY:Call Mom and Dad", 1)
+	})
+	f.sync(Report{Sent: 1, Merged: 1})
+	got := f.tasks()[1]
+	if got.TitleStr != "Call Mom and Dad" || got.DescStr != "about the weekend" {
+		t.Fatalf("merged task = %+v", got)
+	}
+	data, _ = f.server.data(objectHref(added.ID))
+	if !strings.Contains(data, "SUMMARY:Call Mom and Dad") || !strings.Contains(data, "DESCRIPTION:about the weekend") {
+		t.Fatalf("merged task on the server:\n%s", data)
+	}
+
+	// With nothing changed, a sync only asks what changed.
+	f.server.resetRequests()
+	f.sync(Report{})
+	if requests := f.server.resetRequests(); len(requests) != 1 || requests["REPORT"] != 1 {
+		t.Fatalf("requests = %v, want a single REPORT", requests)
+	}
+}
+
+func TestSyncer_WithoutSyncTokens(t *testing.T) {
+	report := newSyncTask("Write report")
+	gym := newSyncTask("Gym")
+	f := newSyncFixture(t, report, gym)
+	f.server.noSync = true
+
+	f.sync(Report{Sent: 2})
+	f.server.remove(objectHref(gym.ID))
+	f.server.edit(objectHref(report.ID), func(data string) string {
+		return strings.Replace(data, "SUMMARY:Write report", "SUMMARY:Write quarterly report", 1)
+	})
+	f.sync(Report{Received: 1, DeletedLocal: 1})
+	if got, want := titlesOf(f.tasks()), "Write quarterly report"; got != want {
+		t.Fatalf("tasks = %q, want %q", got, want)
+	}
+
+	// Unchanged objects are not fetched again.
+	f.server.resetRequests()
+	f.sync(Report{})
+	if requests := f.server.resetRequests(); requests["GET"] != 0 {
+		t.Fatalf("requests = %v, want no GET", requests)
+	}
+}
+
+func TestSyncer_ChangeOnServerMeanwhileIsMergedLater(t *testing.T) {
+	report := newSyncTask("Write report")
+	f := newSyncFixture(t, report)
+	f.sync(Report{Sent: 1})
+
+	// Another client saves just before this sync's write.
+	f.edit(report.ID, func(t *task.Task) { t.DescStr = "slides" })
+	f.server.beforeWrite = func(href string) {
+		f.server.beforeWrite = nil
+		f.server.store(href, strings.Replace(f.server.objects[href].data, "SUMMARY:Write report", "SUMMARY:Write Q1 report", 1))
+	}
+	f.sync(Report{Deferred: 1})
+
+	f.sync(Report{Sent: 1, Merged: 1})
+	got := f.tasks()[0]
+	if got.TitleStr != "Write Q1 report" || got.DescStr != "slides" {
+		t.Fatalf("merged task = %+v", got)
+	}
+}
+
+func TestSyncer_ExpiredSyncTokenStartsOver(t *testing.T) {
+	report := newSyncTask("Write report")
+	f := newSyncFixture(t, report)
+	f.sync(Report{Sent: 1})
+
+	state, err := LoadState(f.syncer.StatePath, f.syncer.Client.Collection())
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	state.SyncToken = fakeTokenBase + "999"
+	if err := state.Save(f.syncer.StatePath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	f.sync(Report{})
+
+	state, _ = LoadState(f.syncer.StatePath, f.syncer.Client.Collection())
+	if state.SyncToken == fakeTokenBase+"999" || len(state.Items) != 1 {
+		t.Fatalf("state after an expired token = %+v", state)
+	}
+}
@@ -0,0 +1,429 @@
+package caldav
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	// prodID names terminaltask as the product that last wrote a
+	// calendar object.
+	prodID = "-//terminaltask//terminaltask//EN"
+
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405"
+	icalUTC      = "20060102T150405Z"
+
+	// metaProp holds the parts of a task that VTODO has no property for.
+	metaProp = "X-TERMINALTASK"
+
+	// relDependsOn is the relation of a task to one that blocks it
+	// (RFC 9253).
+	relDependsOn = "DEPENDS-ON"
+)
+
+// uidNamespace derives task IDs for tasks created by other clients,
+// whose UIDs are not UUIDs, so they keep the same ID from one sync to
+// the next.
+var uidNamespace = uuid.MustParse("3c6f5a8e-1b7d-4e2a-9f0c-8d4b2e6a1f37")
+
+// icalPriorities maps priorities to the iCalendar PRIORITY values that
+// stand for them, where 1 is the highest and 9 the lowest.
+var icalPriorities = map[task.Priority]int{
+	task.PriorityUrgent: 1,
+	task.PriorityHigh:   3,
+	task.PriorityMedium: 5,
+	task.PriorityLow:    9,
+}
+
+var icalWeekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// vtodoMeta holds the parts of a task that VTODO has no property for.
+type vtodoMeta struct {
+	List      string         `json:"list,omitempty"`
+	DueZone   string         `json:"dueZone,omitempty"`
+	Subtasks  []task.Subtask `json:"subtasks,omitempty"`
+	AfterDone bool           `json:"afterDone,omitempty"`
+}
+
+// EncodeTask writes t as a VTODO in a calendar object. When prev, the
+// object the task was last synced as, is given, t is written into a copy
+// of it, so that the properties and components terminaltask does not
+// know, such as alarms set in a calendar client, are kept.
+//
+// The due date, start date, completion, priority, tags (as CATEGORIES),
+// blockers (as RELATED-TO;RELTYPE=DEPENDS-ON), and recurrence (as
+// RRULE) use the usual properties; the list, checklist, and due time
+// zone are kept in an X-TERMINALTASK property.
+func EncodeTask(t task.Task, prev string) string {
+	cal, err := ParseCalendar(prev)
+	if err != nil || prev == "" {
+		cal = &Component{Name: "VCALENDAR", Props: []Prop{{Name: "VERSION", Value: "2.0"}}}
+	}
+	cal.Set(Prop{Name: "PRODID", Value: prodID})
+	todo := cal.Child("VTODO")
+	if todo == nil {
+		todo = &Component{Name: "VTODO"}
+		cal.Components = append(cal.Components, todo)
+	}
+
+	if todo.Get("UID") == nil {
+		todo.Set(Prop{Name: "UID", Value: t.ID.String()})
+	}
+	stamp := t.UpdatedAt
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	todo.Set(utcProp("DTSTAMP", stamp))
+	setTime(todo, "CREATED", t.CreatedAt)
+	setTime(todo, "LAST-MODIFIED", t.UpdatedAt)
+	todo.SetText("SUMMARY", t.TitleStr)
+	todo.SetText("DESCRIPTION", t.DescStr)
+
+	switch {
+	case !t.HasDueDate():
+		todo.Del("DUE")
+	case t.DueHasTime:
+		todo.Set(utcProp("DUE", t.DueDate))
+	default:
+		todo.Set(dateProp("DUE", t.DueDate))
+	}
+	if t.HasStartDate() {
+		todo.Set(dateProp("DTSTART", t.StartDate))
+	} else {
+		todo.Del("DTSTART")
+	}
+
+	if t.Done {
+		todo.Set(Prop{Name: "STATUS", Value: "COMPLETED"})
+		completed := t.CompletedAt
+		if completed.IsZero() {
+			completed = stamp
+		}
+		todo.Set(utcProp("COMPLETED", completed))
+		todo.Set(Prop{Name: "PERCENT-COMPLETE", Value: "100"})
+	} else {
+		if status := todo.Get("STATUS"); status == nil || status.Value == "COMPLETED" || status.Value == "CANCELLED" {
+			todo.Set(Prop{Name: "STATUS", Value: "NEEDS-ACTION"})
+		}
+		todo.Del("COMPLETED")
+		if pct := todo.Get("PERCENT-COMPLETE"); pct != nil && pct.Value == "100" {
+			todo.Del("PERCENT-COMPLETE")
+		}
+	}
+
+	if p, ok := icalPriorities[t.Priority]; ok {
+		todo.Set(Prop{Name: "PRIORITY", Value: strconv.Itoa(p)})
+	} else {
+		todo.Del("PRIORITY")
+	}
+
+	if len(t.Tags) > 0 {
+		escaped := make([]string, len(t.Tags))
+		for i, tag := range t.Tags {
+			escaped[i] = escapeText(tag)
+		}
+		todo.Set(Prop{Name: "CATEGORIES", Value: strings.Join(escaped, ",")})
+	} else {
+		todo.Del("CATEGORIES")
+	}
+
+	todo.Props = slices.DeleteFunc(todo.Props, func(p Prop) bool {
+		return p.Name == "RELATED-TO" && strings.EqualFold(p.Param("RELTYPE"), relDependsOn)
+	})
+	for _, id := range t.BlockedBy {
+		todo.Props = append(todo.Props, Prop{
+			Name:   "RELATED-TO",
+			Params: []Param{{Name: "RELTYPE", Value: relDependsOn}},
+			Value:  id.String(),
+		})
+	}
+
+	// A rule terminaltask cannot read is left alone unless the task's
+	// recurrence was changed.
+	var prevRule *task.Recurrence
+	if rrule := todo.Get("RRULE"); rrule != nil {
+		prevRule, _ = parseRRULE(rrule.Value)
+	}
+	if recurrenceString(prevRule) != recurrenceString(t.Recurrence) {
+		if t.Recurrence != nil {
+			todo.Set(Prop{Name: "RRULE", Value: formatRRULE(*t.Recurrence)})
+		} else {
+			todo.Del("RRULE")
+		}
+	}
+
+	meta := vtodoMeta{List: t.List, Subtasks: t.Subtasks}
+	if t.DueHasTime {
+		meta.DueZone = t.DueZone
+	}
+	if t.Recurrence != nil {
+		meta.AfterDone = t.Recurrence.AfterCompletion
+	}
+	if raw, _ := json.Marshal(meta); string(raw) != "{}" {
+		todo.SetText(metaProp, string(raw))
+	} else {
+		todo.Del(metaProp)
+	}
+
+	return cal.String()
+}
+
+// DecodeTask reads the task in the calendar object data. It reports
+// false when the object holds no VTODO, such as an event in the same
+// calendar.
+func DecodeTask(data string) (task.Task, bool, error) {
+	cal, err := ParseCalendar(data)
+	if err != nil {
+		return task.Task{}, false, err
+	}
+	todo := cal.Child("VTODO")
+	if todo == nil {
+		return task.Task{}, false, nil
+	}
+
+	var meta vtodoMeta
+	if p := todo.Get(metaProp); p != nil {
+		if err := json.Unmarshal([]byte(p.Text()), &meta); err != nil {
+			return task.Task{}, false, fmt.Errorf("%s: %w", metaProp, err)
+		}
+	}
+
+	var t task.Task
+	uid := todo.Get("UID")
+	if uid == nil {
+		return task.Task{}, false, fmt.Errorf("%w: VTODO has no UID", ErrInvalidCalendar)
+	}
+	t.ID = taskID(uid.Value)
+	if p := todo.Get("SUMMARY"); p != nil {
+		t.TitleStr = p.Text()
+	}
+	if p := todo.Get("DESCRIPTION"); p != nil {
+		t.DescStr = p.Text()
+	}
+
+	if p := todo.Get("DUE"); p != nil {
+		due, hasTime, err := parseTime(*p)
+		if err != nil {
+			return task.Task{}, false, fmt.Errorf("DUE: %w", err)
+		}
+		t.DueDate, t.DueHasTime = due, hasTime
+		if hasTime {
+			t.DueZone = meta.DueZone
+			if t.DueZone == "" {
+				t.DueZone = p.Param("TZID")
+			}
+			if t.DueZone == "" {
+				t.DueZone = task.LocalZoneName()
+			}
+			t.DueDate = due.In(t.DueLocation())
+		}
+	}
+	if p := todo.Get("DTSTART"); p != nil {
+		start, _, err := parseTime(*p)
+		if err != nil {
+			return task.Task{}, false, fmt.Errorf("DTSTART: %w", err)
+		}
+		t.StartDate = task.CalendarDay(start.In(time.Local))
+	}
+
+	completed := todo.Get("COMPLETED")
+	status := todo.Get("STATUS")
+	t.Done = completed != nil || status != nil && status.Value == "COMPLETED"
+	if t.Done && completed != nil {
+		t.CompletedAt, _, _ = parseTime(*completed)
+	}
+
+	if p := todo.Get("PRIORITY"); p != nil {
+		t.Priority = parsePriority(p.Value)
+	}
+	for _, p := range todo.All("CATEGORIES") {
+		for _, c := range splitList(p.Value) {
+			if tag := task.NormalizeTag(c); tag != "" && !slices.Contains(t.Tags, tag) {
+				t.Tags = append(t.Tags, tag)
+			}
+		}
+	}
+	for _, p := range todo.All("RELATED-TO") {
+		if strings.EqualFold(p.Param("RELTYPE"), relDependsOn) {
+			t.BlockedBy = append(t.BlockedBy, taskID(p.Value))
+		}
+	}
+	if p := todo.Get("RRULE"); p != nil {
+		if r, ok := parseRRULE(p.Value); ok {
+			r.AfterCompletion = meta.AfterDone
+			t.Recurrence = r
+		}
+	}
+
+	if p := todo.Get("CREATED"); p != nil {
+		t.CreatedAt, _, _ = parseTime(*p)
+	}
+	if p := todo.Get("LAST-MODIFIED"); p != nil {
+		t.UpdatedAt, _, _ = parseTime(*p)
+	} else if p := todo.Get("DTSTAMP"); p != nil {
+		t.UpdatedAt, _, _ = parseTime(*p)
+	}
+
+	t.List = meta.List
+	t.Subtasks = meta.Subtasks
+	return t, true, nil
+}
+
+// taskID returns the ID of the task with the given UID: the UID itself
+// when it is a UUID, as for tasks terminaltask created.
+func taskID(uid string) uuid.UUID {
+	if id, err := uuid.Parse(uid); err == nil {
+		return id
+	}
+	return uuid.NewSHA1(uidNamespace, []byte(uid))
+}
+
+func parsePriority(value string) task.Priority {
+	p, err := strconv.Atoi(strings.TrimSpace(value))
+	switch {
+	case err != nil || p <= 0 || p > 9:
+		return task.PriorityNone
+	case p == 1:
+		return task.PriorityUrgent
+	case p < 5:
+		return task.PriorityHigh
+	case p == 5:
+		return task.PriorityMedium
+	default:
+		return task.PriorityLow
+	}
+}
+
+// parseTime reads a DATE or DATE-TIME value. A date is midnight local
+// time on that day, as terminaltask keeps due dates without a time; a
+// floating time, which has no zone, is read as local time.
+func parseTime(p Prop) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.Value)
+	if strings.EqualFold(p.Param("VALUE"), "DATE") || len(value) == len(icalDate) {
+		t, err := time.ParseInLocation(icalDate, value, time.Local)
+		return t, false, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalUTC, value)
+		return t, true, err
+	}
+	loc := time.Local
+	if tzid := p.Param("TZID"); tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(icalDateTime, value, loc)
+	return t, true, err
+}
+
+func utcProp(name string, t time.Time) Prop {
+	return Prop{Name: name, Value: t.UTC().Format(icalUTC)}
+}
+
+func dateProp(name string, t time.Time) Prop {
+	return Prop{Name: name, Params: []Param{{Name: "VALUE", Value: "DATE"}}, Value: t.Format(icalDate)}
+}
+
+// setTime sets a UTC time property, or removes it for the zero time.
+func setTime(c *Component, name string, t time.Time) {
+	if t.IsZero() {
+		c.Del(name)
+		return
+	}
+	c.Set(utcProp(name, t))
+}
+
+// formatRRULE writes r as an RRULE value. AfterCompletion has no RRULE
+// equivalent and is kept with the other parts of the task VTODO has no
+// property for.
+func formatRRULE(r task.Recurrence) string {
+	interval := ""
+	if r.Interval > 1 {
+		interval = ";INTERVAL=" + strconv.Itoa(r.Interval)
+	}
+	switch r.Kind {
+	case task.RecurWeeks:
+		return "FREQ=WEEKLY" + interval
+	case task.RecurWeekdays:
+		days := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			days[i] = icalWeekdays[wd]
+		}
+		return "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ",")
+	case task.RecurMonthlyWeekday:
+		return fmt.Sprintf("FREQ=MONTHLY;BYDAY=%d%s", r.Week, icalWeekdays[r.Weekday])
+	default:
+		return "FREQ=DAILY" + interval
+	}
+}
+
+// parseRRULE reads the RRULEs that terminaltask can repeat a task by. It
+// reports false for the others, such as those with an end date.
+func parseRRULE(value string) (*task.Recurrence, bool) {
+	parts := map[string]string{}
+	for _, part := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		parts[strings.ToUpper(k)] = strings.ToUpper(v)
+	}
+	delete(parts, "WKST")
+
+	interval := 1
+	if v, ok := parts["INTERVAL"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, false
+		}
+		interval = n
+		delete(parts, "INTERVAL")
+	}
+	freq, byday := parts["FREQ"], parts["BYDAY"]
+	delete(parts, "FREQ")
+	delete(parts, "BYDAY")
+	if len(parts) > 0 {
+		return nil, false
+	}
+
+	switch {
+	case freq == "DAILY" && byday == "":
+		return &task.Recurrence{Kind: task.RecurDays, Interval: interval}, true
+
+	case freq == "WEEKLY" && byday == "":
+		return &task.Recurrence{Kind: task.RecurWeeks, Interval: interval}, true
+
+	case freq == "WEEKLY" && interval == 1:
+		r := &task.Recurrence{Kind: task.RecurWeekdays}
+		for _, day := range strings.Split(byday, ",") {
+			wd := slices.Index(icalWeekdays, day)
+			if wd < 0 {
+				return nil, false
+			}
+			r.Weekdays = append(r.Weekdays, time.Weekday(wd))
+		}
+		return r, true
+
+	case freq == "MONTHLY" && interval == 1 && len(byday) > 2:
+		week, err := strconv.Atoi(byday[:len(byday)-2])
+		wd := slices.Index(icalWeekdays, byday[len(byday)-2:])
+		if err != nil || wd < 0 || week == 0 || week < -1 || week > 4 {
+			return nil, false
+		}
+		return &task.Recurrence{Kind: task.RecurMonthlyWeekday, Week: week, Weekday: time.Weekday(wd)}, true
+	}
+	return nil, false
+}
+
+func recurrenceString(r *task.Recurrence) string {
+	if r == nil {
+		return ""
+	}
+	return formatRRULE(*r)
+}
@@ -0,0 +1,99 @@
+package caldav
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+func TestEncodeTask_RoundTrip(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	blocker := uuid.New()
+	want := task.Task{
+		ID:          uuid.New(),
+		TitleStr:    "Write report; draft, then final",
+		DescStr:     "Quarterly numbers\nas discussed",
+		DueDate:     time.Date(2030, 1, 10, 17, 0, 0, 0, berlin),
+		DueHasTime:  true,
+		DueZone:     "Europe/Berlin",
+		StartDate:   time.Date(2030, 1, 6, 0, 0, 0, 0, time.Local),
+		Done:        true,
+		Priority:    task.PriorityHigh,
+		Tags:        []string{"work", "q1"},
+		Subtasks:    []task.Subtask{{ID: uuid.New(), TitleStr: "Collect data", Done: true}},
+		Recurrence:  &task.Recurrence{Kind: task.RecurMonthlyWeekday, Week: -1, Weekday: time.Friday},
+		List:        "work",
+		BlockedBy:   []uuid.UUID{blocker},
+		CreatedAt:   time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC),
+		CompletedAt: time.Date(2030, 1, 3, 9, 0, 0, 0, time.UTC),
+	}
+
+	data := EncodeTask(want, "")
+	for _, line := range []string{
+		"DUE:20300110T160000Z",
+		"DTSTART;VALUE=DATE:20300106",
+		"STATUS:COMPLETED",
+		"PRIORITY:3",
+		"CATEGORIES:work,q1",
+		"RRULE:FREQ=MONTHLY;BYDAY=-1FR",
+		"RELATED-TO;RELTYPE=DEPENDS-ON:" + blocker.String(),
+		`SUMMARY:Write report\; draft\, then final`,
+	} {
+		if !strings.Contains(data, line+"\r\n") {
+			t.Errorf("encoded task has no line %q:\n%s", line, data)
+		}
+	}
+	for _, line := range strings.Split(data, "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %q is longer than %d octets", line, maxLineOctets)
+		}
+	}
+
+	got, ok, err := DecodeTask(data)
+	if err != nil || !ok {
+		t.Fatalf("DecodeTask() = %v, %v", ok, err)
+	}
+	g, _ := json.Marshal(got)
+	w, _ := json.Marshal(want)
+	if string(g) != string(w) {
+		t.Fatalf("round trip changed the task\n got: %s\nwant: %s", g, w)
+	}
+}
+
+func TestEncodeTask_KeepsWhatItDoesNotKnow(t *testing.T) {
+	prev := strings.Replace(foreignTodo, "PRIORITY:2\r\n",
+		"PRIORITY:2\r\nRRULE:FREQ=YEARLY;BYMONTH=3\r\nX-CLIENT-COLOR:teal\r\n", 1)
+	tk, ok, err := DecodeTask(prev)
+	if err != nil || !ok {
+		t.Fatalf("DecodeTask() = %v, %v", ok, err)
+	}
+	if tk.Recurrence != nil || tk.ID != uuid.NewSHA1(uidNamespace, []byte("20261017-buy-milk@example.com")) {
+		t.Fatalf("foreign to-do read as %+v", tk)
+	}
+
+	tk.TitleStr = "Buy oat milk"
+	data := EncodeTask(tk, prev)
+	for _, line := range []string{
+		"UID:20261017-buy-milk@example.com",
+		"RRULE:FREQ=YEARLY;BYMONTH=3",
+		"X-CLIENT-COLOR:teal",
+		"TRIGGER:-PT15M",
+		"SUMMARY:Buy oat milk",
+	} {
+		if !strings.Contains(data, line+"\r\n") {
+			t.Errorf("encoded task has no line %q:\n%s", line, data)
+		}
+	}
+
+	if _, ok, err := DecodeTask(event); ok || err != nil {
+		t.Fatalf("DecodeTask(event) = %v, %v; want no task", ok, err)
+	}
+}
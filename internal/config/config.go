@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/charmbracelet/log"
 )
@@ -16,6 +20,125 @@ type Config struct {
 	// TasksFile is the full path to the tasks JSON file.
 	// Default: ConfigDir/tasks.json.
 	TasksFile string
+
+	// Store selects where tasks are kept: a URI such as
+	// file:///path/to/tasks.json or sqlite:///path/to/tasks.db, or the
+	// name of a store kept in ConfigDir, such as StoreSQLite. See
+	// StoreURI.
+	// Default: $TERMINALTASK_STORE or, if unset, StoreFile.
+	Store string
+
+	// DatabaseFile is the full path to the SQLite database of
+	// StoreSQLite.
+	// Default: ConfigDir/tasks.db.
+	DatabaseFile string
+
+	// TodoTxtFile is the full path to the todo.txt file of
+	// StoreTodoTxt.
+	// Default: $TERMINALTASK_TODO_FILE or, if unset, ConfigDir/todo.txt.
+	TodoTxtFile string
+
+	// MarkdownFile is the full path to the Markdown checklist of
+	// StoreMarkdown.
+	// Default: $TERMINALTASK_MARKDOWN_FILE or, if unset,
+	// ConfigDir/TASKS.md.
+	MarkdownFile string
+
+	// GitDir is the git repository of StoreGit.
+	// Default: $TERMINALTASK_GIT_DIR or, if unset, ConfigDir/repo.
+	GitDir string
+
+	// BackupDir is the directory the file store keeps backups in.
+	// Default: ConfigDir/backups.
+	BackupDir string
+
+	// BackupKeep is the number of backups to keep; zero turns backups
+	// off.
+	// Default: $TERMINALTASK_BACKUPS or, if unset, DefaultBackupKeep.
+	BackupKeep int
+
+	// BackupInterval is the least time between two backups.
+	// Default: $TERMINALTASK_BACKUP_INTERVAL or, if unset,
+	// DefaultBackupInterval.
+	BackupInterval time.Duration
+
+	// Encrypt turns on encryption of the stored tasks.
+	// Default: true when $TERMINALTASK_ENCRYPT is set to a true value
+	// such as "1" or "true".
+	Encrypt bool
+
+	// Passphrase encrypts the tasks when Encrypt is set. When empty, it
+	// is read from KeyFile or else asked for.
+	// Default: $TERMINALTASK_PASSPHRASE.
+	Passphrase string
+
+	// KeyFile is a file whose contents are used as the passphrase.
+	// Default: $TERMINALTASK_KEY_FILE.
+	KeyFile string
+
+	// CalDAVURL is the calendar collection that terminaltask sync syncs
+	// the tasks with, such as https://dav.example.com/calendars/me/tasks/.
+	// Default: $TERMINALTASK_CALDAV_URL.
+	CalDAVURL string
+
+	// CalDAVUser and CalDAVPassword log in to the CalDAV server.
+	// Default: $TERMINALTASK_CALDAV_USER and $TERMINALTASK_CALDAV_PASSWORD.
+	CalDAVUser     string
+	CalDAVPassword string
+
+	// SyncStateFile records each task as of the last CalDAV sync.
+	// Default: ConfigDir/caldav-sync.json.
+	SyncStateFile string
+}
+
+// Default backup retention.
+const (
+	DefaultBackupKeep     = 10
+	DefaultBackupInterval = 15 * time.Minute
+)
+
+// Names of stores accepted by Config.Store in place of a URI. All but
+// StoreMem are kept in ConfigDir.
+const (
+	StoreFile     = "file"
+	StoreJournal  = "journal"
+	StoreSQLite   = "sqlite"
+	StoreTodoTxt  = "todotxt"
+	StoreMarkdown = "markdown"
+	StoreGit      = "git"
+	StoreMem      = "mem"
+)
+
+// StoreURI returns the URI of the store selected by c.Store. Store names
+// stand for the store of that kind at its configured path: StoreFile and
+// StoreJournal keep TasksFile, StoreSQLite keeps DatabaseFile,
+// StoreTodoTxt keeps TodoTxtFile, StoreMarkdown keeps MarkdownFile, and
+// StoreGit keeps the repository in GitDir. StoreMem stands for a store
+// in memory. Anything else is returned as it is.
+func (c Config) StoreURI() string {
+	var path string
+	switch c.Store {
+	case StoreFile, StoreJournal, "":
+		path = c.TasksFile
+	case StoreSQLite:
+		path = c.DatabaseFile
+	case StoreTodoTxt:
+		path = c.TodoTxtFile
+	case StoreMarkdown:
+		path = c.MarkdownFile
+	case StoreGit:
+		path = c.GitDir
+	case StoreMem:
+		return StoreMem + "://"
+	default:
+		return c.Store
+	}
+
+	scheme := c.Store
+	if scheme == "" {
+		scheme = StoreFile
+	}
+	return (&url.URL{Scheme: scheme, Path: filepath.ToSlash(path)}).String()
 }
 
 // Load builds a Config from environment variables and sensible defaults.
@@ -42,6 +165,57 @@ func Load() (Config, error) {
 
 	// Tasks file path (can be overridden later with another env var if desired)
 	cfg.TasksFile = filepath.Join(cfg.ConfigDir, "tasks.json")
+	cfg.DatabaseFile = filepath.Join(cfg.ConfigDir, "tasks.db")
+	cfg.TodoTxtFile = filepath.Join(cfg.ConfigDir, "todo.txt")
+	if envTodo := os.Getenv("TERMINALTASK_TODO_FILE"); envTodo != "" {
+		cfg.TodoTxtFile = envTodo
+	}
+	cfg.MarkdownFile = filepath.Join(cfg.ConfigDir, "TASKS.md")
+	if envMarkdown := os.Getenv("TERMINALTASK_MARKDOWN_FILE"); envMarkdown != "" {
+		cfg.MarkdownFile = envMarkdown
+	}
+	cfg.GitDir = filepath.Join(cfg.ConfigDir, "repo")
+	if envGit := os.Getenv("TERMINALTASK_GIT_DIR"); envGit != "" {
+		cfg.GitDir = envGit
+	}
+
+	cfg.Store = StoreFile
+	if envStore := os.Getenv("TERMINALTASK_STORE"); envStore != "" {
+		cfg.Store = envStore
+	}
+
+	cfg.BackupDir = filepath.Join(cfg.ConfigDir, "backups")
+	cfg.BackupKeep = DefaultBackupKeep
+	if envKeep := os.Getenv("TERMINALTASK_BACKUPS"); envKeep != "" {
+		keep, err := strconv.Atoi(envKeep)
+		if err != nil || keep < 0 {
+			return Config{}, fmt.Errorf("TERMINALTASK_BACKUPS: want a number of backups, got %q", envKeep)
+		}
+		cfg.BackupKeep = keep
+	}
+	cfg.BackupInterval = DefaultBackupInterval
+	if envInterval := os.Getenv("TERMINALTASK_BACKUP_INTERVAL"); envInterval != "" {
+		interval, err := time.ParseDuration(envInterval)
+		if err != nil || interval < 0 {
+			return Config{}, fmt.Errorf("TERMINALTASK_BACKUP_INTERVAL: want a duration such as 1h, got %q", envInterval)
+		}
+		cfg.BackupInterval = interval
+	}
+
+	if envEncrypt := os.Getenv("TERMINALTASK_ENCRYPT"); envEncrypt != "" {
+		encrypt, err := strconv.ParseBool(envEncrypt)
+		if err != nil {
+			return Config{}, fmt.Errorf("TERMINALTASK_ENCRYPT: want true or false, got %q", envEncrypt)
+		}
+		cfg.Encrypt = encrypt
+	}
+	cfg.Passphrase = os.Getenv("TERMINALTASK_PASSPHRASE")
+	cfg.KeyFile = os.Getenv("TERMINALTASK_KEY_FILE")
+
+	cfg.CalDAVURL = os.Getenv("TERMINALTASK_CALDAV_URL")
+	cfg.CalDAVUser = os.Getenv("TERMINALTASK_CALDAV_USER")
+	cfg.CalDAVPassword = os.Getenv("TERMINALTASK_CALDAV_PASSWORD")
+	cfg.SyncStateFile = filepath.Join(cfg.ConfigDir, "caldav-sync.json")
 
 	return cfg, nil
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 // helper to restore environment variables after a test
@@ -91,3 +92,212 @@ func TestLoad_UsesUserConfigDirWhenEnvUnset(t *testing.T) {
 		}
 	})
 }
+
+func TestLoad_Store(t *testing.T) {
+	customDir := filepath.Join(t.TempDir(), "cfg")
+
+	withEnv("TERMINALTASK_CONFIG_DIR", customDir, func() {
+		withEnv("TERMINALTASK_STORE", "", func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if cfg.Store != StoreFile {
+				t.Fatalf("Store = %q, want %q by default", cfg.Store, StoreFile)
+			}
+			if want := filepath.Join(customDir, "tasks.db"); cfg.DatabaseFile != want {
+				t.Fatalf("DatabaseFile = %q, want %q", cfg.DatabaseFile, want)
+			}
+		})
+
+		withEnv("TERMINALTASK_STORE", StoreSQLite, func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if cfg.Store != StoreSQLite {
+				t.Fatalf("Store = %q, want %q", cfg.Store, StoreSQLite)
+			}
+		})
+
+		withEnv("TERMINALTASK_TODO_FILE", "", func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if want := filepath.Join(customDir, "todo.txt"); cfg.TodoTxtFile != want {
+				t.Fatalf("TodoTxtFile = %q, want %q", cfg.TodoTxtFile, want)
+			}
+		})
+
+		todoFile := filepath.Join(t.TempDir(), "todo.txt")
+		withEnv("TERMINALTASK_TODO_FILE", todoFile, func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if cfg.TodoTxtFile != todoFile {
+				t.Fatalf("TodoTxtFile = %q, want %q", cfg.TodoTxtFile, todoFile)
+			}
+		})
+
+		markdownFile := filepath.Join(t.TempDir(), "TASKS.md")
+		withEnv("TERMINALTASK_MARKDOWN_FILE", markdownFile, func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if cfg.MarkdownFile != markdownFile {
+				t.Fatalf("MarkdownFile = %q, want %q", cfg.MarkdownFile, markdownFile)
+			}
+		})
+
+		withEnv("TERMINALTASK_GIT_DIR", "", func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if want := filepath.Join(customDir, "repo"); cfg.GitDir != want {
+				t.Fatalf("GitDir = %q, want %q", cfg.GitDir, want)
+			}
+		})
+
+		gitDir := filepath.Join(t.TempDir(), "repo")
+		withEnv("TERMINALTASK_GIT_DIR", gitDir, func() {
+			withEnv("TERMINALTASK_STORE", StoreGit, func() {
+				cfg, err := Load()
+				if err != nil {
+					t.Fatalf("Load() returned error: %v", err)
+				}
+				if want := "git://" + filepath.ToSlash(gitDir); cfg.StoreURI() != want {
+					t.Fatalf("StoreURI() = %q, want %q", cfg.StoreURI(), want)
+				}
+			})
+		})
+	})
+}
+
+func TestConfig_StoreURI(t *testing.T) {
+	cfg := Config{
+		ConfigDir:    "/home/me/.config/terminaltask",
+		TasksFile:    "/home/me/.config/terminaltask/tasks.json",
+		DatabaseFile: "/home/me/.config/terminaltask/tasks.db",
+		TodoTxtFile:  "/home/me/todo.txt",
+		MarkdownFile: "/home/me/src/project/TASKS.md",
+		GitDir:       "/home/me/.config/terminaltask/repo",
+	}
+
+	tests := []struct {
+		store string
+		want  string
+	}{
+		{"", "file:///home/me/.config/terminaltask/tasks.json"},
+		{StoreFile, "file:///home/me/.config/terminaltask/tasks.json"},
+		{StoreJournal, "journal:///home/me/.config/terminaltask/tasks.json"},
+		{StoreSQLite, "sqlite:///home/me/.config/terminaltask/tasks.db"},
+		{StoreTodoTxt, "todotxt:///home/me/todo.txt"},
+		{StoreMarkdown, "markdown:///home/me/src/project/TASKS.md"},
+		{StoreGit, "git:///home/me/.config/terminaltask/repo"},
+		{StoreMem, "mem://"},
+		{"sqlite:///srv/tasks.db", "sqlite:///srv/tasks.db"},
+		{"floppy", "floppy"},
+	}
+	for _, tt := range tests {
+		cfg.Store = tt.store
+		if got := cfg.StoreURI(); got != tt.want {
+			t.Errorf("StoreURI() with Store %q = %q, want %q", tt.store, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_Backups(t *testing.T) {
+	customDir := filepath.Join(t.TempDir(), "cfg")
+
+	withEnv("TERMINALTASK_CONFIG_DIR", customDir, func() {
+		withEnv("TERMINALTASK_BACKUPS", "", func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if want := filepath.Join(customDir, "backups"); cfg.BackupDir != want {
+				t.Fatalf("BackupDir = %q, want %q", cfg.BackupDir, want)
+			}
+			if cfg.BackupKeep != DefaultBackupKeep || cfg.BackupInterval != DefaultBackupInterval {
+				t.Fatalf("backups = %d every %v, want the defaults", cfg.BackupKeep, cfg.BackupInterval)
+			}
+		})
+
+		withEnv("TERMINALTASK_BACKUPS", "3", func() {
+			withEnv("TERMINALTASK_BACKUP_INTERVAL", "1h", func() {
+				cfg, err := Load()
+				if err != nil {
+					t.Fatalf("Load() returned error: %v", err)
+				}
+				if cfg.BackupKeep != 3 || cfg.BackupInterval != time.Hour {
+					t.Fatalf("backups = %d every %v, want 3 every 1h", cfg.BackupKeep, cfg.BackupInterval)
+				}
+			})
+		})
+
+		withEnv("TERMINALTASK_BACKUPS", "lots", func() {
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want an error for an invalid backup count")
+			}
+		})
+	})
+}
+
+func TestLoad_Encryption(t *testing.T) {
+	customDir := filepath.Join(t.TempDir(), "cfg")
+
+	withEnv("TERMINALTASK_CONFIG_DIR", customDir, func() {
+		withEnv("TERMINALTASK_ENCRYPT", "", func() {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if cfg.Encrypt {
+				t.Fatal("Encrypt = true, want false by default")
+			}
+		})
+
+		withEnv("TERMINALTASK_ENCRYPT", "1", func() {
+			withEnv("TERMINALTASK_KEY_FILE", "/keys/tasks.key", func() {
+				cfg, err := Load()
+				if err != nil {
+					t.Fatalf("Load() returned error: %v", err)
+				}
+				if !cfg.Encrypt || cfg.KeyFile != "/keys/tasks.key" {
+					t.Fatalf("Encrypt = %v, KeyFile = %q; want true, %q", cfg.Encrypt, cfg.KeyFile, "/keys/tasks.key")
+				}
+			})
+		})
+
+		withEnv("TERMINALTASK_ENCRYPT", "maybe", func() {
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want an error for an invalid TERMINALTASK_ENCRYPT")
+			}
+		})
+	})
+}
+
+func TestLoad_CalDAV(t *testing.T) {
+	customDir := filepath.Join(t.TempDir(), "cfg")
+
+	withEnv("TERMINALTASK_CONFIG_DIR", customDir, func() {
+		withEnv("TERMINALTASK_CALDAV_URL", "https://dav.example.com/calendars/me/tasks/", func() {
+			withEnv("TERMINALTASK_CALDAV_USER", "me", func() {
+				cfg, err := Load()
+				if err != nil {
+					t.Fatalf("Load() returned error: %v", err)
+				}
+				if cfg.CalDAVURL != "https://dav.example.com/calendars/me/tasks/" || cfg.CalDAVUser != "me" {
+					t.Fatalf("CalDAVURL = %q, CalDAVUser = %q", cfg.CalDAVURL, cfg.CalDAVUser)
+				}
+				if want := filepath.Join(customDir, "caldav-sync.json"); cfg.SyncStateFile != want {
+					t.Fatalf("SyncStateFile = %q, want %q", cfg.SyncStateFile, want)
+				}
+			})
+		})
+	})
+}
@@ -0,0 +1,430 @@
+// Package merge combines two versions of a task list that diverged from
+// a common base, such as the copies of a tasks file two machines edited
+// while a sync tool could not reach the other. Tasks are matched by ID
+// and merged field by field: a field changed on one side only takes
+// that change, and a field changed differently on both sides is a
+// Conflict for the user to settle.
+package merge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// Side is the version of a conflicting change that is kept.
+type Side int
+
+const (
+	// Unresolved is the choice of a conflict nobody settled yet.
+	Unresolved Side = iota
+	Ours
+	Theirs
+)
+
+// FieldTask is the Field of a conflict in which one side deleted a task
+// that the other side changed.
+const FieldTask = "task"
+
+// ErrUnresolved is returned when asking for the tasks of a merge whose
+// conflicts are not all settled.
+var ErrUnresolved = errors.New("merge has unresolved conflicts")
+
+// Conflict is a field of a task that both sides changed, each in its
+// own way.
+type Conflict struct {
+	ID uuid.UUID
+
+	// Field names what both sides changed, such as "title" or "due
+	// date", or is FieldTask.
+	Field string
+
+	// Base, Ours, and Theirs are the task in each version, nil where it
+	// does not exist: a task added on both sides has no base, and one
+	// side of a FieldTask conflict deleted it.
+	Base, Ours, Theirs *task.Task
+
+	// Choice is the side to keep, Unresolved until the user picks one.
+	Choice Side
+}
+
+// Title returns the title of the conflicting task, preferring ours.
+func (c Conflict) Title() string {
+	if c.Ours != nil {
+		return c.Ours.Title()
+	}
+	return c.Theirs.Title()
+}
+
+// Show describes the value of the conflicting field on the given side.
+func (c Conflict) Show(side Side) string {
+	t := c.Ours
+	if side == Theirs {
+		t = c.Theirs
+	}
+	return c.show(t)
+}
+
+// ShowBase describes the value of the conflicting field before either
+// side changed it.
+func (c Conflict) ShowBase() string {
+	if c.Base == nil {
+		return "none"
+	}
+	return c.show(c.Base)
+}
+
+func (c Conflict) show(t *task.Task) string {
+	if t == nil {
+		return "deleted"
+	}
+	if c.Field == FieldTask {
+		return "changed"
+	}
+	return lookupField(c.Field).show(*t)
+}
+
+// Result is the outcome of a merge.
+type Result struct {
+	// Tasks are the merged tasks, in the order of ours with the tasks
+	// only theirs has after their predecessor there. Conflicting fields
+	// hold our value, and tasks in a FieldTask conflict are kept, until
+	// Resolved applies the choices made.
+	Tasks []task.Task
+
+	Conflicts []Conflict
+
+	// Changes counts the changes taken from theirs without conflict.
+	Changes int
+}
+
+// Unresolved returns the number of conflicts still to be settled.
+func (r Result) Unresolved() int {
+	n := 0
+	for _, c := range r.Conflicts {
+		if c.Choice == Unresolved {
+			n++
+		}
+	}
+	return n
+}
+
+// Resolved returns the merged tasks with the choice made for each
+// conflict applied. It fails with ErrUnresolved while any is left.
+func (r Result) Resolved() ([]task.Task, error) {
+	if n := r.Unresolved(); n > 0 {
+		return nil, fmt.Errorf("%w: %d left", ErrUnresolved, n)
+	}
+
+	tasks := slices.Clone(r.Tasks)
+	for _, c := range r.Conflicts {
+		i := slices.IndexFunc(tasks, func(t task.Task) bool { return t.ID == c.ID })
+		if i < 0 {
+			continue
+		}
+		chosen := c.Ours
+		if c.Choice == Theirs {
+			chosen = c.Theirs
+		}
+		switch {
+		case c.Field != FieldTask:
+			if c.Choice == Theirs {
+				lookupField(c.Field).set(&tasks[i], *c.Theirs)
+			}
+		case chosen == nil:
+			tasks = slices.Delete(tasks, i, i+1)
+		default:
+			tasks[i] = *chosen
+		}
+	}
+	return tasks, nil
+}
+
+// Merge merges ours and theirs, two versions of base. Tasks are matched
+// by ID. A task deleted on one side is deleted unless the other side
+// changed it, which is a conflict; a task added on either side is kept.
+// Within a task each field is merged on its own, except that tags and
+// blockers are merged as sets, so that adding one on each side keeps
+// both, and the update time is the later of the two.
+func Merge(base, ours, theirs []task.Task) Result {
+	var (
+		baseByID   = byID(base)
+		oursByID   = byID(ours)
+		theirsByID = byID(theirs)
+		r          Result
+	)
+
+	for _, o := range ours {
+		b, inBase := baseByID[o.ID]
+		t, inTheirs := theirsByID[o.ID]
+		switch {
+		case inTheirs:
+			var bp *task.Task
+			if inBase {
+				bp = &b
+			}
+			merged, conflicts, changes := mergeTask(bp, o, t)
+			r.Tasks = append(r.Tasks, merged)
+			r.Conflicts = append(r.Conflicts, conflicts...)
+			r.Changes += changes
+
+		case !inBase:
+			// Added on our side.
+			r.Tasks = append(r.Tasks, o)
+
+		case same(b, o):
+			// Deleted on theirs and left alone on ours.
+			r.Changes++
+
+		default:
+			r.Tasks = append(r.Tasks, o)
+			r.Conflicts = append(r.Conflicts, Conflict{ID: o.ID, Field: FieldTask, Base: &b, Ours: &o})
+		}
+	}
+
+	for i, t := range theirs {
+		if _, inOurs := oursByID[t.ID]; inOurs {
+			continue
+		}
+		b, inBase := baseByID[t.ID]
+		switch {
+		case !inBase:
+			r.Changes++
+		case same(b, t):
+			// Deleted on ours and left alone on theirs.
+			continue
+		default:
+			r.Conflicts = append(r.Conflicts, Conflict{ID: t.ID, Field: FieldTask, Base: &b, Theirs: &t})
+		}
+		r.Tasks = slices.Insert(r.Tasks, insertAt(r.Tasks, theirs[:i]), t)
+	}
+	return r
+}
+
+// insertAt returns where to put a task that comes after before in the
+// list it was taken from: after the last of those that is in tasks, or
+// first when there is none.
+func insertAt(tasks, before []task.Task) int {
+	for j := len(before) - 1; j >= 0; j-- {
+		if i := slices.IndexFunc(tasks, func(t task.Task) bool { return t.ID == before[j].ID }); i >= 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// mergeTask merges ours and theirs, two versions of base, which is nil
+// when both sides added the task. It returns the merged task, the
+// fields that conflict, and how many changes were taken from theirs.
+func mergeTask(base *task.Task, ours, theirs task.Task) (task.Task, []Conflict, int) {
+	var b task.Task
+	if base != nil {
+		b = *base
+	}
+
+	merged := ours
+	var (
+		conflicts []Conflict
+		changes   int
+	)
+	for _, f := range fields {
+		bv, ov, tv := f.key(b), f.key(ours), f.key(theirs)
+		switch {
+		case ov == tv || tv == bv:
+		case ov == bv:
+			f.set(&merged, theirs)
+			changes++
+		default:
+			conflicts = append(conflicts, Conflict{
+				ID: ours.ID, Field: f.name, Base: base, Ours: &ours, Theirs: &theirs,
+			})
+		}
+	}
+
+	merged.Tags = mergeSet(b.Tags, ours.Tags, theirs.Tags)
+	merged.BlockedBy = mergeSet(b.BlockedBy, ours.BlockedBy, theirs.BlockedBy)
+	if !slices.Equal(merged.Tags, ours.Tags) || !slices.Equal(merged.BlockedBy, ours.BlockedBy) {
+		changes++
+	}
+	if theirs.UpdatedAt.After(merged.UpdatedAt) {
+		merged.UpdatedAt = theirs.UpdatedAt
+	}
+	return merged, conflicts, changes
+}
+
+// mergeSet merges two versions of the set base: it keeps ours in its
+// order, without what theirs removed, followed by what theirs added.
+func mergeSet[T comparable](base, ours, theirs []T) []T {
+	var merged []T
+	for _, v := range ours {
+		if slices.Contains(base, v) && !slices.Contains(theirs, v) {
+			continue
+		}
+		merged = append(merged, v)
+	}
+	for _, v := range theirs {
+		if !slices.Contains(base, v) && !slices.Contains(merged, v) {
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
+
+// field is a part of a task that is merged as a whole.
+type field struct {
+	name string
+
+	// get returns the value of the field, compared in its JSON form.
+	get func(task.Task) any
+
+	// set copies the field from src to dst.
+	set func(dst *task.Task, src task.Task)
+
+	show func(task.Task) string
+}
+
+func (f field) key(t task.Task) string {
+	b, _ := json.Marshal(f.get(t))
+	return string(b)
+}
+
+var fields = []field{
+	{
+		name: "title",
+		get:  func(t task.Task) any { return t.TitleStr },
+		set:  func(dst *task.Task, src task.Task) { dst.TitleStr = src.TitleStr },
+		show: func(t task.Task) string { return t.TitleStr },
+	},
+	{
+		name: "description",
+		get:  func(t task.Task) any { return t.DescStr },
+		set:  func(dst *task.Task, src task.Task) { dst.DescStr = src.DescStr },
+		show: func(t task.Task) string { return orNone(t.DescStr) },
+	},
+	{
+		name: "due date",
+		get:  func(t task.Task) any { return []any{t.DueDate, t.DueHasTime, t.DueZone} },
+		set: func(dst *task.Task, src task.Task) {
+			dst.DueDate, dst.DueHasTime, dst.DueZone = src.DueDate, src.DueHasTime, src.DueZone
+		},
+		show: func(t task.Task) string {
+			if t.DueDate.IsZero() {
+				return "none"
+			}
+			if t.DueHasTime {
+				return t.DueDate.Format("2006-01-02 15:04")
+			}
+			return t.DueDate.Format("2006-01-02")
+		},
+	},
+	{
+		name: "start date",
+		get:  func(t task.Task) any { return t.StartDate },
+		set:  func(dst *task.Task, src task.Task) { dst.StartDate = src.StartDate },
+		show: func(t task.Task) string {
+			if t.StartDate.IsZero() {
+				return "none"
+			}
+			return t.StartDate.Format("2006-01-02")
+		},
+	},
+	{
+		// Completing a task at different times on both sides agrees on
+		// the task being done, so only Done is compared.
+		name: "done",
+		get:  func(t task.Task) any { return t.Done },
+		set: func(dst *task.Task, src task.Task) {
+			dst.Done, dst.CompletedAt = src.Done, src.CompletedAt
+		},
+		show: func(t task.Task) string {
+			if t.Done {
+				return "done"
+			}
+			return "not done"
+		},
+	},
+	{
+		name: "priority",
+		get:  func(t task.Task) any { return t.Priority },
+		set:  func(dst *task.Task, src task.Task) { dst.Priority = src.Priority },
+		show: func(t task.Task) string { return t.Priority.String() },
+	},
+	{
+		name: "checklist",
+		get:  func(t task.Task) any { return t.Subtasks },
+		set:  func(dst *task.Task, src task.Task) { dst.Subtasks = slices.Clone(src.Subtasks) },
+		show: func(t task.Task) string {
+			items := make([]string, len(t.Subtasks))
+			for i, s := range t.Subtasks {
+				mark := "[ ]"
+				if s.Done {
+					mark = "[x]"
+				}
+				items[i] = mark + " " + s.Title()
+			}
+			return orNone(strings.Join(items, ", "))
+		},
+	},
+	{
+		name: "recurrence",
+		get:  func(t task.Task) any { return t.Recurrence },
+		set: func(dst *task.Task, src task.Task) {
+			dst.Recurrence = nil
+			if src.Recurrence != nil {
+				r := *src.Recurrence
+				dst.Recurrence = &r
+			}
+		},
+		show: func(t task.Task) string {
+			if t.Recurrence == nil {
+				return "none"
+			}
+			return t.Recurrence.String()
+		},
+	},
+	{
+		name: "list",
+		get:  func(t task.Task) any { return t.List },
+		set:  func(dst *task.Task, src task.Task) { dst.List = src.List },
+		show: func(t task.Task) string {
+			if t.List == "" {
+				return task.DefaultList
+			}
+			return t.List
+		},
+	},
+}
+
+func lookupField(name string) field {
+	i := slices.IndexFunc(fields, func(f field) bool { return f.name == name })
+	return fields[i]
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func byID(tasks []task.Task) map[uuid.UUID]task.Task {
+	m := make(map[uuid.UUID]task.Task, len(tasks))
+	for _, t := range tasks {
+		if _, dup := m[t.ID]; !dup {
+			m[t.ID] = t
+		}
+	}
+	return m
+}
+
+// same reports whether a and b are the same version of a task.
+func same(a, b task.Task) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,115 @@
+package merge
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+func titles(tasks []task.Task) []string {
+	out := make([]string, len(tasks))
+	for i, t := range tasks {
+		out[i] = t.Title()
+	}
+	return out
+}
+
+func TestMerge_NonOverlappingEdits(t *testing.T) {
+	report := task.Task{ID: uuid.New(), TitleStr: "report", Tags: []string{"work"}}
+	gym := task.Task{ID: uuid.New(), TitleStr: "gym"}
+	taxes := task.Task{ID: uuid.New(), TitleStr: "taxes"}
+	base := []task.Task{report, gym, taxes}
+
+	// Ours renames the report and tags it; theirs sets its due date,
+	// tags it differently, completes the gym, and deletes the taxes.
+	ourReport := report
+	ourReport.TitleStr = "quarterly report"
+	ourReport.Tags = []string{"work", "urgent"}
+	ourReport.UpdatedAt = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	added := task.Task{ID: uuid.New(), TitleStr: "groceries"}
+	ours := []task.Task{ourReport, gym, taxes}
+
+	theirReport := report
+	theirReport.DueDate = time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	theirReport.Tags = []string{"home"}
+	theirReport.UpdatedAt = time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	theirGym := gym
+	theirGym.Done = true
+	theirs := []task.Task{theirReport, added, theirGym}
+
+	r := Merge(base, ours, theirs)
+	if len(r.Conflicts) != 0 {
+		t.Fatalf("Conflicts = %+v, want none", r.Conflicts)
+	}
+	tasks, err := r.Resolved()
+	if err != nil {
+		t.Fatalf("Resolved() error = %v", err)
+	}
+	if got, want := titles(tasks), []string{"quarterly report", "groceries", "gym"}; !slices.Equal(got, want) {
+		t.Fatalf("merged titles = %q, want %q", got, want)
+	}
+	merged := tasks[0]
+	if !merged.DueDate.Equal(theirReport.DueDate) {
+		t.Fatalf("due date = %v, want theirs", merged.DueDate)
+	}
+	if got, want := merged.Tags, []string{"urgent", "home"}; !slices.Equal(got, want) {
+		t.Fatalf("tags = %q, want %q", got, want)
+	}
+	if !merged.UpdatedAt.Equal(theirReport.UpdatedAt) {
+		t.Fatalf("UpdatedAt = %v, want the later one", merged.UpdatedAt)
+	}
+	if !tasks[2].Done {
+		t.Fatal("gym was not completed")
+	}
+}
+
+func TestMerge_Conflicts(t *testing.T) {
+	report := task.Task{ID: uuid.New(), TitleStr: "report", Priority: task.PriorityLow}
+	gym := task.Task{ID: uuid.New(), TitleStr: "gym"}
+	base := []task.Task{report, gym}
+
+	ourReport, theirReport := report, report
+	ourReport.TitleStr, theirReport.TitleStr = "Q1 report", "annual report"
+	// Both raise the priority the same way, which is no conflict.
+	ourReport.Priority, theirReport.Priority = task.PriorityHigh, task.PriorityHigh
+	theirGym := gym
+	theirGym.DescStr = "legs"
+
+	r := Merge(base, []task.Task{ourReport}, []task.Task{theirReport, theirGym})
+	if len(r.Conflicts) != 2 {
+		t.Fatalf("Conflicts = %+v, want the title and the deleted gym", r.Conflicts)
+	}
+	title, deleted := r.Conflicts[0], r.Conflicts[1]
+	if title.Field != "title" || title.Show(Ours) != "Q1 report" || title.Show(Theirs) != "annual report" {
+		t.Fatalf("first conflict = %+v, want the title", title)
+	}
+	if deleted.Field != FieldTask || deleted.Show(Ours) != "deleted" || deleted.Title() != "gym" {
+		t.Fatalf("second conflict = %+v, want the gym deleted on our side", deleted)
+	}
+
+	if _, err := r.Resolved(); !errors.Is(err, ErrUnresolved) {
+		t.Fatalf("Resolved() error = %v, want ErrUnresolved", err)
+	}
+
+	r.Conflicts[0].Choice = Theirs
+	r.Conflicts[1].Choice = Ours
+	tasks, err := r.Resolved()
+	if err != nil {
+		t.Fatalf("Resolved() error = %v", err)
+	}
+	if got, want := titles(tasks), []string{"annual report"}; !slices.Equal(got, want) {
+		t.Fatalf("resolved titles = %q, want %q", got, want)
+	}
+	if tasks[0].Priority != task.PriorityHigh {
+		t.Fatalf("priority = %v, want high", tasks[0].Priority)
+	}
+
+	r.Conflicts[1].Choice = Theirs
+	if tasks, _ = r.Resolved(); len(tasks) != 2 || tasks[1].DescStr != "legs" {
+		t.Fatalf("keeping their gym: tasks = %+v", tasks)
+	}
+}
@@ -0,0 +1,584 @@
+package taskservice
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	// DefaultWriteDelay is how long CachedTaskService waits for changes
+	// to stop coming before writing them.
+	DefaultWriteDelay = 500 * time.Millisecond
+
+	// maxWriteDelays bounds the wait for a quiet moment, in write
+	// delays, so a steady stream of changes is still written.
+	maxWriteDelays = 10
+)
+
+// CachedTaskService keeps the tasks in memory, indexed by ID and by due
+// date, and writes changes to the store on a background goroutine once
+// they stop coming for a moment. Changing a task only touches memory,
+// so it never waits for the disk: toggling one takes constant time.
+//
+// Changes are written task by task when the store is a
+// store.RecordStore, and as all tasks at once otherwise. Changes that
+// cannot be written are kept and tried again with the next change, and
+// the error is sent on WriteErrors. Close writes what is left.
+type CachedTaskService struct {
+	store store.TaskStore
+	delay time.Duration
+
+	// now returns the current time; tests replace it with a fixed clock.
+	now func() time.Time
+
+	// writeMu serializes access to the store.
+	writeMu sync.Mutex
+
+	mu     sync.Mutex
+	loaded bool
+	// primed is set while the cached tasks were handed to Prime and not
+	// yet returned by LoadTasks.
+	primed bool
+	// tasks are all tasks in order; byID indexes them, and due holds
+	// the IDs of those with a due date, earliest deadline first.
+	tasks []task.Task
+	byID  map[uuid.UUID]int
+	due   []uuid.UUID
+	// pending are the IDs of the tasks changed or deleted since the last
+	// write, in the order first changed, and queued is their set.
+	// reorder is set when the order of the tasks changed too, which
+	// takes writing all of them.
+	pending []uuid.UUID
+	queued  map[uuid.UUID]bool
+	reorder bool
+
+	kick      chan struct{}
+	errs      chan error
+	closing   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCachedTaskService returns a service caching the tasks of s that
+// writes changes delay after the last one. Call Close when done with it.
+func NewCachedTaskService(s store.TaskStore, delay time.Duration) *CachedTaskService {
+	c := &CachedTaskService{
+		store:   s,
+		delay:   delay,
+		now:     time.Now,
+		byID:    map[uuid.UUID]int{},
+		queued:  map[uuid.UUID]bool{},
+		kick:    make(chan struct{}, 1),
+		errs:    make(chan error, 1),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+func (s *CachedTaskService) Name() string {
+	return s.store.Name()
+}
+
+// WriteErrors reports changes that could not be written in the
+// background. Errors arriving while one is still unread are dropped.
+func (s *CachedTaskService) WriteErrors() <-chan error {
+	return s.errs
+}
+
+// LoadTasks writes the pending changes and reads the tasks from the
+// store again, picking up changes other programs made. Changes that
+// could not be written are kept on top of what was read, and tried
+// again shortly. The first call after Prime returns the primed tasks
+// instead.
+func (s *CachedTaskService) LoadTasks() ([]task.Task, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	if s.primed {
+		s.primed = false
+		tasks := slices.Clone(s.tasks)
+		s.mu.Unlock()
+		return tasks, nil
+	}
+	s.mu.Unlock()
+
+	flushErr := s.write()
+	tasks, err := s.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) > 0 {
+		tasks = s.overlayPending(tasks)
+	}
+	s.index(tasks)
+	s.loaded = true
+	if flushErr != nil {
+		s.signal()
+	}
+	return slices.Clone(s.tasks), nil
+}
+
+// SaveTasks replaces all tasks and writes them right away.
+func (s *CachedTaskService) SaveTasks(tasks []task.Task) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	s.index(slices.Clone(tasks))
+	s.loaded = true
+	s.pending, s.queued, s.reorder = nil, map[uuid.UUID]bool{}, false
+	s.mu.Unlock()
+
+	return s.store.Save(tasks)
+}
+
+// Prime caches tasks just loaded from the store, so that they need not
+// be read again: the first LoadTasks returns them instead of reading
+// the store. Call it before using the service.
+func (s *CachedTaskService) Prime(tasks []task.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index(slices.Clone(tasks))
+	s.loaded, s.primed = true, true
+}
+
+// ToggleCompleted is FileTaskService.ToggleCompleted on the cached
+// tasks.
+func (s *CachedTaskService) ToggleCompleted(t task.Task) (task.Task, error) {
+	t.Subtasks = slices.Clone(t.Subtasks)
+	t.SetDone(!t.Done)
+
+	if err := s.ensureLoaded(); err != nil {
+		return t, fmt.Errorf("load tasks: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.byID[t.GetID()]
+	if !ok {
+		return t, fmt.Errorf("toggle task %s: %w", t.GetID(), ErrTaskNotFound)
+	}
+	now := s.now()
+	next := completeRecurring(&t, nil, now)
+
+	stored := s.tasks[i]
+	t.Stamp(&stored, now)
+	markToggled(&stored, t)
+	if next != nil {
+		stored.Recurrence = nil
+	}
+	s.replace(i, stored)
+
+	if next != nil {
+		s.insert(i+1, *next)
+	}
+	return t, nil
+}
+
+// DeleteByID deletes the task with the given ID and drops the links
+// other tasks have to it.
+func (s *CachedTaskService) DeleteByID(id uuid.UUID) error {
+	if err := s.ensureLoaded(); err != nil {
+		return fmt.Errorf("load tasks: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.byID[id]
+	if !ok {
+		return nil
+	}
+	s.remove(i)
+	for i, t := range s.tasks {
+		if t.RemoveBlocker(id) {
+			s.replace(i, t)
+		}
+	}
+	return nil
+}
+
+// UpsertTask is FileTaskService.UpsertTask on the cached tasks.
+func (s *CachedTaskService) UpsertTask(t task.Task) (task.Task, error) {
+	if err := s.ensureLoaded(); err != nil {
+		return t, fmt.Errorf("load tasks: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.List = task.NormalizeList(t.List)
+	var blockers []uuid.UUID
+	for _, id := range t.BlockedBy {
+		if _, ok := s.byID[id]; ok || id == t.GetID() {
+			blockers = append(blockers, id)
+		}
+	}
+	t.BlockedBy = blockers
+	if err := checkDependencies(s.tasks, t); err != nil {
+		return t, err
+	}
+
+	now := s.now()
+	i, ok := s.byID[t.GetID()]
+	if ok {
+		prev := s.tasks[i]
+		next := completeRecurring(&t, &prev, now)
+		t.Stamp(&prev, now)
+		s.replace(i, t)
+		if next != nil {
+			s.insert(i+1, *next)
+		}
+		return t, nil
+	}
+	next := completeRecurring(&t, nil, now)
+	t.Stamp(nil, now)
+	s.insert(len(s.tasks), t)
+	if next != nil {
+		s.insert(len(s.tasks), *next)
+	}
+	return t, nil
+}
+
+// MoveToList is FileTaskService.MoveToList on the cached tasks.
+func (s *CachedTaskService) MoveToList(id uuid.UUID, list string) (task.Task, error) {
+	if err := s.ensureLoaded(); err != nil {
+		return task.Task{}, fmt.Errorf("load tasks: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.byID[id]
+	if !ok {
+		return task.Task{}, fmt.Errorf("move task %s: %w", id, ErrTaskNotFound)
+	}
+	moved := s.tasks[i]
+	moved.List = task.NormalizeList(list)
+	moved.Stamp(&s.tasks[i], s.now())
+	s.replace(i, moved)
+	return moved, nil
+}
+
+// DueBetween returns the tasks due at or after from and before to,
+// earliest first. A task due on a day without a time is due at the end
+// of that day.
+func (s *CachedTaskService) DueBetween(from, to time.Time) []task.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := s.dueIndex(task.Task{DueDate: from, DueHasTime: true})
+	end := s.dueIndex(task.Task{DueDate: to, DueHasTime: true})
+	tasks := make([]task.Task, 0, max(end-start, 0))
+	for _, id := range s.due[start:max(start, end)] {
+		tasks = append(tasks, s.tasks[s.byID[id]])
+	}
+	return tasks
+}
+
+// Flush writes the pending changes now.
+func (s *CachedTaskService) Flush() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.write()
+}
+
+// Close stops the background writer and writes what is left. The
+// service must not be changed afterwards.
+func (s *CachedTaskService) Close() error {
+	s.closeOnce.Do(func() { close(s.closing) })
+	<-s.done
+	return s.Flush()
+}
+
+// run writes changes in the background, once no change came for the
+// write delay, or after maxWriteDelays of them.
+func (s *CachedTaskService) run() {
+	defer close(s.done)
+	for {
+		select {
+		case <-s.kick:
+		case <-s.closing:
+			return
+		}
+
+		quiet := time.NewTimer(s.delay)
+		limit := time.NewTimer(s.delay * maxWriteDelays)
+	wait:
+		for {
+			select {
+			case <-s.kick:
+				quiet.Reset(s.delay)
+			case <-quiet.C:
+				break wait
+			case <-limit.C:
+				break wait
+			case <-s.closing:
+				quiet.Stop()
+				limit.Stop()
+				return
+			}
+		}
+		quiet.Stop()
+		limit.Stop()
+
+		if err := s.Flush(); err != nil {
+			select {
+			case s.errs <- err:
+			default:
+			}
+		}
+	}
+}
+
+// write hands the pending changes to the store. Those that fail stay
+// pending. It must be called with writeMu held.
+func (s *CachedTaskService) write() error {
+	s.mu.Lock()
+	ids, reorder := s.pending, s.reorder
+	if len(ids) == 0 && !reorder {
+		s.mu.Unlock()
+		return nil
+	}
+	s.pending, s.queued, s.reorder = nil, map[uuid.UUID]bool{}, false
+
+	rs, records := s.store.(store.RecordStore)
+	snapshot := reorder || !records
+	var all, puts []task.Task
+	var deletes []uuid.UUID
+	if snapshot {
+		all = slices.Clone(s.tasks)
+	} else {
+		for _, id := range ids {
+			if i, ok := s.byID[id]; ok {
+				puts = append(puts, s.tasks[i])
+			} else {
+				deletes = append(deletes, id)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	err := func() error {
+		if snapshot {
+			return s.store.Save(all)
+		}
+		for _, t := range puts {
+			if err := rs.Put(t); err != nil {
+				return err
+			}
+		}
+		for _, id := range deletes {
+			if err := rs.Delete(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		s.mu.Lock()
+		s.requeue(ids, reorder)
+		s.mu.Unlock()
+		return fmt.Errorf("save tasks: %w", err)
+	}
+	return nil
+}
+
+// ensureLoaded reads the tasks from the store the first time they are
+// needed. The store is read without holding mu, so the cached tasks stay
+// available meanwhile; it must be called without mu held.
+func (s *CachedTaskService) ensureLoaded() error {
+	if s.isLoaded() {
+		return nil
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	// Another call may have loaded the tasks while this one waited.
+	if s.isLoaded() {
+		return nil
+	}
+	tasks, err := s.store.Load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index(tasks)
+	s.loaded = true
+	return nil
+}
+
+func (s *CachedTaskService) isLoaded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loaded
+}
+
+// index makes tasks the cached tasks and rebuilds the indexes.
+func (s *CachedTaskService) index(tasks []task.Task) {
+	s.tasks = tasks
+	s.byID = make(map[uuid.UUID]int, len(tasks))
+	s.due = s.due[:0]
+	for i, t := range tasks {
+		s.byID[t.GetID()] = i
+		if t.HasDueDate() {
+			s.due = append(s.due, t.GetID())
+		}
+	}
+	slices.SortFunc(s.due, func(a, b uuid.UUID) int {
+		return compareDue(s.tasks[s.byID[a]], s.tasks[s.byID[b]])
+	})
+}
+
+// overlayPending returns tasks, as read from the store, with the
+// pending changes made to them again.
+func (s *CachedTaskService) overlayPending(tasks []task.Task) []task.Task {
+	at := make(map[uuid.UUID]int, len(tasks))
+	for i, t := range tasks {
+		at[t.GetID()] = i
+	}
+	deleted := map[uuid.UUID]bool{}
+	for _, id := range s.pending {
+		i, ok := s.byID[id]
+		switch j, found := at[id]; {
+		case !ok:
+			deleted[id] = true
+		case found:
+			tasks[j] = s.tasks[i]
+		default:
+			at[id] = len(tasks)
+			tasks = append(tasks, s.tasks[i])
+		}
+	}
+	if len(deleted) > 0 {
+		tasks = slices.DeleteFunc(tasks, func(t task.Task) bool { return deleted[t.GetID()] })
+	}
+	return tasks
+}
+
+// replace puts t in place of the task at index i and queues it.
+func (s *CachedTaskService) replace(i int, t task.Task) {
+	prev := s.tasks[i]
+	if prev.HasDueDate() != t.HasDueDate() || compareDue(prev, t) != 0 {
+		s.dropDue(prev)
+		s.tasks[i] = t
+		s.addDue(t)
+	} else {
+		s.tasks[i] = t
+	}
+	s.queue(t.GetID())
+}
+
+// insert adds t at index i and queues it. Anywhere but at the end, that
+// changes the order of the tasks.
+func (s *CachedTaskService) insert(i int, t task.Task) {
+	if i < len(s.tasks) {
+		s.reorder = true
+	}
+	s.tasks = slices.Insert(s.tasks, i, t)
+	s.reindexFrom(i)
+	s.addDue(t)
+	s.queue(t.GetID())
+}
+
+// remove deletes the task at index i and queues its deletion.
+func (s *CachedTaskService) remove(i int) {
+	t := s.tasks[i]
+	s.dropDue(t)
+	s.tasks = slices.Delete(s.tasks, i, i+1)
+	delete(s.byID, t.GetID())
+	s.reindexFrom(i)
+	s.queue(t.GetID())
+}
+
+// reindexFrom updates the ID index for the tasks from index i on.
+func (s *CachedTaskService) reindexFrom(i int) {
+	for ; i < len(s.tasks); i++ {
+		s.byID[s.tasks[i].GetID()] = i
+	}
+}
+
+// addDue adds t, which must be in the ID index, to the due date index.
+func (s *CachedTaskService) addDue(t task.Task) {
+	if !t.HasDueDate() {
+		return
+	}
+	i := s.dueIndex(t)
+	s.due = slices.Insert(s.due, i, t.GetID())
+}
+
+// dropDue removes t, as it is in the ID index, from the due date index.
+func (s *CachedTaskService) dropDue(t task.Task) {
+	if !t.HasDueDate() {
+		return
+	}
+	if i := s.dueIndex(t); i < len(s.due) && s.due[i] == t.GetID() {
+		s.due = slices.Delete(s.due, i, i+1)
+	}
+}
+
+// dueIndex returns the position in the due date index of the first
+// task not due before t.
+func (s *CachedTaskService) dueIndex(t task.Task) int {
+	i, _ := slices.BinarySearchFunc(s.due, t, func(id uuid.UUID, t task.Task) int {
+		return compareDue(s.tasks[s.byID[id]], t)
+	})
+	return i
+}
+
+// queue marks the task with the given ID as changed and wakes the
+// background writer.
+func (s *CachedTaskService) queue(id uuid.UUID) {
+	if !s.queued[id] {
+		s.queued[id] = true
+		s.pending = append(s.pending, id)
+	}
+	s.signal()
+}
+
+// requeue puts back changes that could not be written, before those
+// made meanwhile.
+func (s *CachedTaskService) requeue(ids []uuid.UUID, reorder bool) {
+	pending := slices.Clone(ids)
+	queued := make(map[uuid.UUID]bool, len(ids)+len(s.pending))
+	for _, id := range ids {
+		queued[id] = true
+	}
+	for _, id := range s.pending {
+		if !queued[id] {
+			queued[id] = true
+			pending = append(pending, id)
+		}
+	}
+	s.pending, s.queued = pending, queued
+	s.reorder = s.reorder || reorder
+}
+
+// signal wakes the background writer without waiting for it.
+func (s *CachedTaskService) signal() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// compareDue orders tasks by deadline, and tasks due at the same time
+// by ID, so that each task has a single place in the due date index.
+func compareDue(a, b task.Task) int {
+	if c := task.CompareDue(a, b); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
@@ -0,0 +1,379 @@
+package taskservice
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// syncStore is a mockStore safe to use from the background writer.
+type syncStore struct {
+	mu sync.Mutex
+	*mockStore
+}
+
+func (s *syncStore) Load() ([]task.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mockStore.Load()
+}
+
+func (s *syncStore) Save(tasks []task.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mockStore.Save(tasks)
+}
+
+func (s *syncStore) state() (saveCalls int, tasks []task.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveCalls, s.tasks
+}
+
+func (s *syncStore) setSaveErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveErr = err
+}
+
+// newCachedService returns a CachedTaskService over st with its tasks
+// loaded, closed when the test ends.
+func newCachedService(t *testing.T, st store.TaskStore, delay time.Duration) *CachedTaskService {
+	t.Helper()
+	svc := NewCachedTaskService(st, delay)
+	t.Cleanup(func() { _ = svc.Close() })
+	if _, err := svc.LoadTasks(); err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	return svc
+}
+
+func TestCachedTaskService_WritesOnClose(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	b := newTaskWithID(uuid.New(), "b", false)
+	b.BlockedBy = []uuid.UUID{a.GetID()}
+	st := &syncStore{mockStore: newMockStore("mock", []task.Task{a, b})}
+	svc := newCachedService(t, st, time.Hour)
+
+	if _, err := svc.ToggleCompleted(b); err != nil {
+		t.Fatalf("ToggleCompleted() error = %v", err)
+	}
+	c, err := svc.UpsertTask(newTaskWithID(uuid.New(), "c", false))
+	if err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if _, err := svc.MoveToList(c.GetID(), "Work"); err != nil {
+		t.Fatalf("MoveToList() error = %v", err)
+	}
+	if err := svc.DeleteByID(a.GetID()); err != nil {
+		t.Fatalf("DeleteByID() error = %v", err)
+	}
+	if _, err := svc.MoveToList(a.GetID(), "Work"); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("MoveToList(deleted) error = %v, want ErrTaskNotFound", err)
+	}
+
+	if calls, _ := st.state(); calls != 0 {
+		t.Fatalf("Save() was called %d times before the write delay, want none", calls)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	calls, tasks := st.state()
+	if calls != 1 {
+		t.Fatalf("Save() was called %d times, want once for all changes", calls)
+	}
+	if len(tasks) != 2 || tasks[0].GetID() != b.GetID() || tasks[1].GetID() != c.GetID() {
+		t.Fatalf("stored tasks = %+v, want b and c", tasks)
+	}
+	if !tasks[0].Done || len(tasks[0].BlockedBy) != 0 || tasks[1].List != "Work" {
+		t.Fatalf("stored tasks = %+v, want b done and unblocked, c in Work", tasks)
+	}
+}
+
+func TestCachedTaskService_WritesRecords(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	b := newTaskWithID(uuid.New(), "b", false)
+	rs := newRecordStore(t, a, b)
+	svc := newCachedService(t, rs, time.Hour)
+
+	if _, err := svc.ToggleCompleted(a); err != nil {
+		t.Fatalf("ToggleCompleted() error = %v", err)
+	}
+	if err := svc.DeleteByID(b.GetID()); err != nil {
+		t.Fatalf("DeleteByID() error = %v", err)
+	}
+	if err := svc.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if rs.saveCalls != 0 {
+		t.Fatalf("Save() was called %d times, want none", rs.saveCalls)
+	}
+	got := rs.tasks(t)
+	if len(got) != 1 || got[0].GetID() != a.GetID() || !got[0].Done {
+		t.Fatalf("stored tasks = %+v, want a done", got)
+	}
+}
+
+func TestCachedTaskService_DebouncesWrites(t *testing.T) {
+	tk := newTaskWithID(uuid.New(), "flip", false)
+	st := &syncStore{mockStore: newMockStore("mock", []task.Task{tk})}
+	svc := newCachedService(t, st, 20*time.Millisecond)
+
+	for range 5 {
+		var err error
+		if tk, err = svc.ToggleCompleted(tk); err != nil {
+			t.Fatalf("ToggleCompleted() error = %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		calls, tasks := st.state()
+		if calls > 0 {
+			if calls != 1 || !tasks[0].Done {
+				t.Fatalf("Save() calls = %d, stored %+v; want one write of the last state", calls, tasks)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("changes were not written in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCachedTaskService_ReportsWriteErrors(t *testing.T) {
+	tk := newTaskWithID(uuid.New(), "keep me", false)
+	st := &syncStore{mockStore: newMockStore("mock", []task.Task{tk})}
+	diskFull := errors.New("disk full")
+	st.setSaveErr(diskFull)
+	svc := newCachedService(t, st, time.Millisecond)
+
+	if _, err := svc.ToggleCompleted(tk); err != nil {
+		t.Fatalf("ToggleCompleted() error = %v", err)
+	}
+	select {
+	case err := <-svc.WriteErrors():
+		if !errors.Is(err, diskFull) {
+			t.Fatalf("WriteErrors() sent %v, want %v", err, diskFull)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no write error reported")
+	}
+
+	// The change is kept and written once the store works again.
+	st.setSaveErr(nil)
+	tasks, err := svc.LoadTasks()
+	if err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	if !tasks[0].Done {
+		t.Fatalf("LoadTasks() = %+v, want the unwritten change kept", tasks)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, stored := st.state(); !stored[0].Done {
+		t.Fatalf("stored tasks = %+v, want the change written", stored)
+	}
+}
+
+func TestCachedTaskService_NextOccurrenceFollowsTask(t *testing.T) {
+	rule, err := task.ParseRecurrence("every week")
+	if err != nil {
+		t.Fatalf("ParseRecurrence error = %v", err)
+	}
+	orig := newTaskWithID(uuid.New(), "review dashboards", false)
+	orig.Recurrence = rule
+	other := newTaskWithID(uuid.New(), "other", false)
+	rs := newRecordStore(t, orig, other)
+	svc := newCachedService(t, rs, time.Hour)
+
+	if _, err := svc.ToggleCompleted(orig); err != nil {
+		t.Fatalf("ToggleCompleted() error = %v", err)
+	}
+	tasks, err := svc.LoadTasks()
+	if err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	got := rs.tasks(t)
+	if len(got) != 3 || !got[0].Done || !got[1].IsRecurring() || got[2].GetID() != other.GetID() {
+		t.Fatalf("stored tasks = %+v, want the next occurrence right after the completed task", got)
+	}
+	if len(tasks) != 3 || tasks[1].GetID() != got[1].GetID() {
+		t.Fatalf("LoadTasks() = %+v, want the stored order", tasks)
+	}
+}
+
+func TestCachedTaskService_UpsertCompletingRecurringSchedulesNext(t *testing.T) {
+	rule, err := task.ParseRecurrence("every week")
+	if err != nil {
+		t.Fatalf("ParseRecurrence error = %v", err)
+	}
+	orig := newTaskWithID(uuid.New(), "review dashboards", false)
+	orig.Recurrence = rule
+	other := newTaskWithID(uuid.New(), "other", false)
+	st := &syncStore{mockStore: newMockStore("mock", []task.Task{orig, other})}
+	svc := newCachedService(t, st, time.Hour)
+
+	edited := orig
+	edited.SetDone(true)
+	if _, err := svc.UpsertTask(edited); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	_, got := st.state()
+	if len(got) != 3 || !got[0].Done || got[0].IsRecurring() || got[2].GetID() != other.GetID() {
+		t.Fatalf("stored tasks = %+v, want the next occurrence right after the completed task", got)
+	}
+	if got[1].Done || !got[1].IsRecurring() {
+		t.Fatalf("tasks[1] = %+v, want an open recurring task", got[1])
+	}
+}
+
+func TestCachedTaskService_DueBetween(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2030, 1, d, 0, 0, 0, 0, time.Local) }
+	dueOn := func(title string, d int) task.Task {
+		t := newTaskWithID(uuid.New(), title, false)
+		t.DueDate = day(d)
+		return t
+	}
+	later, sooner, undated := dueOn("later", 12), dueOn("sooner", 10), newTaskWithID(uuid.New(), "undated", false)
+	st := &syncStore{mockStore: newMockStore("mock", []task.Task{later, undated, sooner})}
+	svc := newCachedService(t, st, time.Hour)
+
+	titles := func(tasks []task.Task) []string {
+		var out []string
+		for _, t := range tasks {
+			out = append(out, t.Title())
+		}
+		return out
+	}
+	if got := titles(svc.DueBetween(day(1), day(31))); len(got) != 2 || got[0] != "sooner" || got[1] != "later" {
+		t.Fatalf("DueBetween(January) = %q, want sooner, later", got)
+	}
+
+	// Moving a due date moves the task in the index.
+	sooner.DueDate = day(20)
+	if _, err := svc.UpsertTask(sooner); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	undated.DueDate = day(11)
+	if _, err := svc.UpsertTask(undated); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := svc.DeleteByID(later.GetID()); err != nil {
+		t.Fatalf("DeleteByID() error = %v", err)
+	}
+	if got := titles(svc.DueBetween(day(1), day(31))); len(got) != 2 || got[0] != "undated" || got[1] != "sooner" {
+		t.Fatalf("DueBetween(January) = %q, want undated, sooner", got)
+	}
+	if got := svc.DueBetween(day(13), day(20)); len(got) != 0 {
+		t.Fatalf("DueBetween(13th, 20th) = %q, want none; the 20th ends after it", titles(got))
+	}
+}
+
+func TestCachedTaskService_ToggleMissingTask(t *testing.T) {
+	svc := newCachedService(t, &syncStore{mockStore: newMockStore("mock", nil)}, time.Hour)
+
+	if _, err := svc.ToggleCompleted(newTaskWithID(uuid.New(), "gone", false)); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("ToggleCompleted() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestCachedTaskService_Prime(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	rs := newRecordStore(t, a)
+	svc := NewCachedTaskService(rs, time.Hour)
+	t.Cleanup(func() { _ = svc.Close() })
+
+	svc.Prime([]task.Task{a})
+	got, err := svc.LoadTasks()
+	if err != nil || len(got) != 1 || got[0].GetID() != a.GetID() {
+		t.Fatalf("LoadTasks() = %+v, %v; want the primed task", got, err)
+	}
+	if rs.loadCalls != 0 {
+		t.Fatalf("Load() was called %d times, want none after Prime", rs.loadCalls)
+	}
+
+	// Later calls read the store again.
+	if _, err := svc.LoadTasks(); err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	if rs.loadCalls != 1 {
+		t.Fatalf("Load() was called %d times, want once", rs.loadCalls)
+	}
+}
+
+func TestCachedTaskService_LoadsOnceOnFirstUse(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	rs := newRecordStore(t, a)
+	svc := NewCachedTaskService(rs, time.Hour)
+	t.Cleanup(func() { _ = svc.Close() })
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.MoveToList(a.GetID(), "Work"); err != nil {
+				t.Errorf("MoveToList() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if rs.loadCalls != 1 {
+		t.Fatalf("Load() was called %d times, want once", rs.loadCalls)
+	}
+}
+
+func TestCachedTaskService_KeepsChangesOverOtherSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	mine := newTaskWithID(uuid.New(), "mine", false)
+	if err := store.NewFileTaskStore(path).Save([]task.Task{mine}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	svc := newCachedService(t, store.NewFileTaskStore(path), time.Hour)
+
+	if _, err := svc.ToggleCompleted(mine); err != nil {
+		t.Fatalf("ToggleCompleted() error = %v", err)
+	}
+	other := store.NewFileTaskStore(path)
+	theirs, err := other.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := other.Save(append(theirs, newTaskWithID(uuid.New(), "theirs", false))); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if err := svc.Flush(); !errors.Is(err, store.ErrStaleSave) {
+		t.Fatalf("Flush() error = %v, want ErrStaleSave", err)
+	}
+	if _, err := svc.LoadTasks(); err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	tasks, err := store.NewFileTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(tasks) != 2 || !tasks[0].Done || tasks[1].Title() != "theirs" {
+		t.Fatalf("stored tasks = %+v, want both sessions' changes", tasks)
+	}
+}
@@ -1,19 +1,38 @@
 package taskservice
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jacobdanielrose/terminaltask/internal/store"
 	"github.com/jacobdanielrose/terminaltask/internal/task"
 )
 
+// ErrDependencyCycle is returned when saving a task would make it
+// (indirectly) blocked by itself.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// ErrTaskNotFound is returned when an operation names a task that is
+// not in the store.
+var ErrTaskNotFound = errors.New("task not found")
+
+// FileTaskService runs each operation directly against its store. With
+// a store.RecordStore, such as the SQLite, journal, or mem store, an
+// operation reads and writes only the tasks it changes; other stores,
+// such as the file store, are loaded and saved whole every time.
 type FileTaskService struct {
 	store store.TaskStore
+
+	// now returns the current time; tests replace it with a fixed clock.
+	now func() time.Time
 }
 
 func NewFileTaskService(s store.TaskStore) Service {
-	return &FileTaskService{store: s}
+	return &FileTaskService{store: s, now: time.Now}
 }
 
 func (s *FileTaskService) Name() string {
@@ -28,19 +47,53 @@ func (s *FileTaskService) SaveTasks(tasks []task.Task) error {
 	return s.store.Save(tasks)
 }
 
+// ToggleCompleted flips the completion state of t and records when it
+// was completed. Completing or reopening a task applies to all of its
+// subtasks as well. Completing a recurring task moves its recurrence
+// rule onto a new task for the next occurrence, inserted right after
+// the completed one.
+//
+// When the store is a store.RecordStore, only the toggled task is
+// written, unless a next occurrence has to be inserted. Toggling a task
+// that is not in the store fails with ErrTaskNotFound.
 func (s *FileTaskService) ToggleCompleted(t task.Task) (task.Task, error) {
-	t.Done = !t.Done
+	t.Subtasks = slices.Clone(t.Subtasks)
+	t.SetDone(!t.Done)
+
+	now := s.now()
+	next := completeRecurring(&t, nil, now)
+
+	if rs, ok := s.store.(store.RecordStore); ok && next == nil {
+		stored, err := rs.Get(t.GetID())
+		if errors.Is(err, store.ErrTaskNotFound) {
+			return t, fmt.Errorf("toggle task %s: %w", t.GetID(), ErrTaskNotFound)
+		}
+		if err != nil {
+			return t, fmt.Errorf("load task: %w", err)
+		}
+		t.Stamp(&stored, now)
+		markToggled(&stored, t)
+		if err := rs.Put(stored); err != nil {
+			return t, fmt.Errorf("save task: %w", err)
+		}
+		return t, nil
+	}
 
 	tasks, err := s.store.Load()
 	if err != nil {
 		return t, fmt.Errorf("load tasks: %w", err)
 	}
 
-	for i := range tasks {
-		if tasks[i].GetID() == t.GetID() {
-			tasks[i].Done = t.Done
-			break
-		}
+	i := slices.IndexFunc(tasks, func(other task.Task) bool { return other.GetID() == t.GetID() })
+	if i < 0 {
+		return t, fmt.Errorf("toggle task %s: %w", t.GetID(), ErrTaskNotFound)
+	}
+	prev := tasks[i]
+	t.Stamp(&prev, now)
+	markToggled(&tasks[i], t)
+	if next != nil {
+		tasks[i].Recurrence = nil
+		tasks = slices.Insert(tasks, i+1, *next)
 	}
 
 	if err := s.store.Save(tasks); err != nil {
@@ -50,7 +103,51 @@ func (s *FileTaskService) ToggleCompleted(t task.Task) (task.Task, error) {
 	return t, nil
 }
 
+// completeRecurring moves the recurrence rule of t onto a new task for
+// its next occurrence when t is a recurring task being completed, and
+// returns that task. prev is the stored version of t, or nil when t is
+// new or known to be open. It returns nil when t does not recur.
+func completeRecurring(t, prev *task.Task, now time.Time) *task.Task {
+	if !t.Done || !t.IsRecurring() || (prev != nil && prev.Done) {
+		return nil
+	}
+	next := t.NextOccurrence(now)
+	next.Stamp(nil, now)
+	t.Recurrence = nil
+	return &next
+}
+
+// markToggled gives stored the completion state and times of t, the
+// toggled version of it, leaving its other fields as stored.
+func markToggled(stored *task.Task, t task.Task) {
+	stored.Subtasks = slices.Clone(stored.Subtasks)
+	stored.SetDone(t.Done)
+	stored.UpdatedAt, stored.CompletedAt = t.UpdatedAt, t.CompletedAt
+}
+
+// DeleteByID deletes the task with the given ID and drops the links
+// other tasks have to it. When the store is a store.RecordStore, only
+// the tasks that change are written, and only those are read if it is
+// a store.DependentStore.
 func (s *FileTaskService) DeleteByID(id uuid.UUID) error {
+	if rs, ok := s.store.(store.RecordStore); ok {
+		dependents, err := store.Dependents(rs, id)
+		if err != nil {
+			return fmt.Errorf("load tasks: %w", err)
+		}
+		for _, t := range dependents {
+			if t.GetID() != id && t.RemoveBlocker(id) {
+				if err := rs.Put(t); err != nil {
+					return fmt.Errorf("save task: %w", err)
+				}
+			}
+		}
+		if err := rs.Delete(id); err != nil {
+			return fmt.Errorf("delete task: %w", err)
+		}
+		return nil
+	}
+
 	tasks, err := s.store.Load()
 	if err != nil {
 		return fmt.Errorf("load tasks: %w", err)
@@ -59,6 +156,9 @@ func (s *FileTaskService) DeleteByID(id uuid.UUID) error {
 	out := tasks[:0]
 	for _, t := range tasks {
 		if t.GetID() != id {
+			// Drop links to the deleted task so nothing is left
+			// blocked by a task that no longer exists.
+			t.RemoveBlocker(id)
 			out = append(out, t)
 		}
 	}
@@ -70,28 +170,171 @@ func (s *FileTaskService) DeleteByID(id uuid.UUID) error {
 	return nil
 }
 
-func (s *FileTaskService) UpsertTask(t task.Task) error {
+// UpsertTask stores t, replacing the task with the same ID or
+// appending it if there is none, and returns it with its creation,
+// update, and completion times filled in. Links to tasks that do not
+// exist are dropped, and a task whose blocked-by links would form a
+// cycle is rejected with ErrDependencyCycle. Completing a recurring
+// task inserts its next occurrence right after it, as ToggleCompleted
+// does.
+func (s *FileTaskService) UpsertTask(t task.Task) (task.Task, error) {
+	t.List = task.NormalizeList(t.List)
+	if rs, ok := s.store.(store.RecordStore); ok && !(t.Done && t.IsRecurring()) {
+		return s.putRecord(rs, t)
+	}
+
 	tasks, err := s.store.Load()
 	if err != nil {
-		return fmt.Errorf("load tasks: %w", err)
+		return t, fmt.Errorf("load tasks: %w", err)
 	}
 
-	found := false
-	for i := range tasks {
-		if tasks[i].GetID() == t.GetID() {
-			tasks[i] = t
-			found = true
-			break
-		}
+	t.BlockedBy = knownBlockers(tasks, t)
+	if err := checkDependencies(tasks, t); err != nil {
+		return t, err
 	}
 
-	if !found {
+	now := s.now()
+	i := slices.IndexFunc(tasks, func(other task.Task) bool { return other.GetID() == t.GetID() })
+	if i >= 0 {
+		next := completeRecurring(&t, &tasks[i], now)
+		t.Stamp(&tasks[i], now)
+		tasks[i] = t
+		if next != nil {
+			tasks = slices.Insert(tasks, i+1, *next)
+		}
+	} else {
+		next := completeRecurring(&t, nil, now)
+		t.Stamp(nil, now)
 		tasks = append(tasks, t)
+		if next != nil {
+			tasks = append(tasks, *next)
+		}
 	}
 
 	if err := s.store.Save(tasks); err != nil {
-		return fmt.Errorf("save tasks: %w", err)
+		return t, fmt.Errorf("save tasks: %w", err)
 	}
 
-	return nil
+	return t, nil
+}
+
+// putRecord is UpsertTask for a store.RecordStore: it writes t alone,
+// and reads the other tasks only to check its blocked-by links.
+func (s *FileTaskService) putRecord(rs store.RecordStore, t task.Task) (task.Task, error) {
+	var tasks []task.Task
+	if len(t.BlockedBy) > 0 {
+		var err error
+		if tasks, err = rs.Load(); err != nil {
+			return t, fmt.Errorf("load tasks: %w", err)
+		}
+	}
+	t.BlockedBy = knownBlockers(tasks, t)
+	if err := checkDependencies(tasks, t); err != nil {
+		return t, err
+	}
+
+	prev, err := rs.Get(t.GetID())
+	switch {
+	case err == nil:
+		t.Stamp(&prev, s.now())
+	case errors.Is(err, store.ErrTaskNotFound):
+		t.Stamp(nil, s.now())
+	default:
+		return t, fmt.Errorf("load task: %w", err)
+	}
+
+	if err := rs.Put(t); err != nil {
+		return t, fmt.Errorf("save task: %w", err)
+	}
+	return t, nil
+}
+
+// MoveToList moves the task with the given ID into the named list. A
+// list exists as long as some task belongs to it, so moving a task to
+// a new name creates that list.
+func (s *FileTaskService) MoveToList(id uuid.UUID, list string) (task.Task, error) {
+	if rs, ok := s.store.(store.RecordStore); ok {
+		prev, err := rs.Get(id)
+		if errors.Is(err, store.ErrTaskNotFound) {
+			return task.Task{}, fmt.Errorf("move task %s: %w", id, ErrTaskNotFound)
+		}
+		if err != nil {
+			return task.Task{}, fmt.Errorf("load task: %w", err)
+		}
+		moved := prev
+		moved.List = task.NormalizeList(list)
+		moved.Stamp(&prev, s.now())
+		if err := rs.Put(moved); err != nil {
+			return prev, fmt.Errorf("save task: %w", err)
+		}
+		return moved, nil
+	}
+
+	tasks, err := s.store.Load()
+	if err != nil {
+		return task.Task{}, fmt.Errorf("load tasks: %w", err)
+	}
+
+	i := slices.IndexFunc(tasks, func(t task.Task) bool { return t.GetID() == id })
+	if i < 0 {
+		return task.Task{}, fmt.Errorf("move task %s: %w", id, ErrTaskNotFound)
+	}
+
+	prev := tasks[i]
+	tasks[i].List = task.NormalizeList(list)
+	tasks[i].Stamp(&prev, s.now())
+
+	if err := s.store.Save(tasks); err != nil {
+		return prev, fmt.Errorf("save tasks: %w", err)
+	}
+
+	return tasks[i], nil
+}
+
+// knownBlockers returns the blocked-by links of t that refer to other
+// tasks that exist, dropping dangling links.
+func knownBlockers(tasks []task.Task, t task.Task) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, id := range t.BlockedBy {
+		if id == t.GetID() {
+			ids = append(ids, id)
+			continue
+		}
+		for _, other := range tasks {
+			if other.GetID() == id {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// checkDependencies reports an ErrDependencyCycle naming the tasks
+// involved if saving t among tasks would make it blocked by itself.
+func checkDependencies(tasks []task.Task, t task.Task) error {
+	if len(t.BlockedBy) == 0 {
+		return nil
+	}
+
+	all := make([]task.Task, 0, len(tasks)+1)
+	titles := make(map[uuid.UUID]string, len(tasks)+1)
+	for _, other := range tasks {
+		if other.GetID() != t.GetID() {
+			all = append(all, other)
+			titles[other.GetID()] = other.Title()
+		}
+	}
+	all = append(all, t)
+	titles[t.GetID()] = t.Title()
+
+	cycle := task.DependencyCycle(all, t.GetID())
+	if cycle == nil {
+		return nil
+	}
+	names := make([]string, len(cycle))
+	for i, id := range cycle {
+		names[i] = fmt.Sprintf("%q", titles[id])
+	}
+	return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(names, " -> "))
 }
@@ -3,9 +3,12 @@ package taskservice
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/store"
 	"github.com/jacobdanielrose/terminaltask/internal/task"
 )
 
@@ -165,6 +168,121 @@ func TestFileTaskService_ToggleCompleted_TogglesAndPersists(t *testing.T) {
 	}
 }
 
+func TestFileTaskService_ToggleCompleted_AppliesToSubtasks(t *testing.T) {
+	id := uuid.New()
+	orig := newTaskWithID(id, "release", false)
+	orig.Subtasks = []task.Subtask{
+		{TitleStr: "tag", Done: true},
+		{TitleStr: "announce"},
+	}
+	ms := newMockStore("mock", []task.Task{orig})
+	svc := NewFileTaskService(ms)
+
+	updated, err := svc.ToggleCompleted(orig)
+	if err != nil {
+		t.Fatalf("ToggleCompleted() error = %v, want nil", err)
+	}
+
+	for i, s := range updated.Subtasks {
+		if !s.Done {
+			t.Errorf("updated.Subtasks[%d].Done = false, want true", i)
+		}
+	}
+	for i, s := range ms.tasks[0].Subtasks {
+		if !s.Done {
+			t.Errorf("stored Subtasks[%d].Done = false, want true", i)
+		}
+	}
+
+	// The caller's task must not be mutated through the shared slice.
+	if orig.Subtasks[1].Done {
+		t.Errorf("ToggleCompleted mutated the caller's subtasks")
+	}
+}
+
+func TestFileTaskService_ToggleCompleted_SchedulesNextOccurrence(t *testing.T) {
+	rule, err := task.ParseRecurrence("every week")
+	if err != nil {
+		t.Fatalf("ParseRecurrence error = %v", err)
+	}
+
+	due := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	orig := newTaskWithID(id, "review dashboards", false)
+	orig.DueDate = due
+	orig.Recurrence = rule
+	other := newTaskWithID(uuid.New(), "other", false)
+
+	ms := newMockStore("mock", []task.Task{orig, other})
+	svc := &FileTaskService{store: ms, now: func() time.Time { return due }}
+
+	updated, err := svc.ToggleCompleted(orig)
+	if err != nil {
+		t.Fatalf("ToggleCompleted() error = %v, want nil", err)
+	}
+	if updated.IsRecurring() {
+		t.Errorf("completed task kept its recurrence rule")
+	}
+
+	if len(ms.tasks) != 3 {
+		t.Fatalf("store.tasks len = %d, want 3", len(ms.tasks))
+	}
+	if !ms.tasks[0].Done || ms.tasks[0].IsRecurring() {
+		t.Errorf("stored original Done = %v, recurring = %v; want done and not recurring",
+			ms.tasks[0].Done, ms.tasks[0].IsRecurring())
+	}
+
+	next := ms.tasks[1]
+	if next.GetID() == id || next.Done || !next.IsRecurring() {
+		t.Fatalf("tasks[1] = %+v, want a new open recurring task", next)
+	}
+	if want := due.AddDate(0, 0, 7); !next.DueDate.Equal(want) {
+		t.Errorf("next DueDate = %v, want %v", next.DueDate, want)
+	}
+	if ms.tasks[2].GetID() != other.GetID() {
+		t.Errorf("tasks[2] = %q, want the untouched task", ms.tasks[2].Title())
+	}
+}
+
+func TestFileTaskService_UpsertTask_CompletingRecurringSchedulesNext(t *testing.T) {
+	rule, err := task.ParseRecurrence("every week")
+	if err != nil {
+		t.Fatalf("ParseRecurrence error = %v", err)
+	}
+
+	due := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	orig := newTaskWithID(uuid.New(), "review dashboards", false)
+	orig.DueDate = due
+	orig.Recurrence = rule
+	other := newTaskWithID(uuid.New(), "other", false)
+
+	ms := newMockStore("mock", []task.Task{orig, other})
+	svc := &FileTaskService{store: ms, now: func() time.Time { return due }}
+
+	// Marked done in the edit form rather than toggled.
+	edited := orig
+	edited.SetDone(true)
+	saved, err := svc.UpsertTask(edited)
+	if err != nil {
+		t.Fatalf("UpsertTask() error = %v, want nil", err)
+	}
+	if saved.IsRecurring() {
+		t.Errorf("completed task kept its recurrence rule")
+	}
+	// Saving it again while still done schedules nothing more.
+	if _, err := svc.UpsertTask(saved); err != nil {
+		t.Fatalf("UpsertTask() error = %v, want nil", err)
+	}
+
+	if len(ms.tasks) != 3 || !ms.tasks[0].Done || ms.tasks[2].GetID() != other.GetID() {
+		t.Fatalf("stored tasks = %+v, want the next occurrence right after the completed task", ms.tasks)
+	}
+	next := ms.tasks[1]
+	if next.Done || !next.IsRecurring() || !next.DueDate.Equal(due.AddDate(0, 0, 7)) {
+		t.Fatalf("tasks[1] = %+v, want an open recurring task due a week later", next)
+	}
+}
+
 func TestFileTaskService_ToggleCompleted_PropagatesLoadError(t *testing.T) {
 	id := uuid.New()
 	orig := newTaskWithID(id, "broken load", false)
@@ -197,6 +315,18 @@ func TestFileTaskService_ToggleCompleted_PropagatesSaveError(t *testing.T) {
 	}
 }
 
+func TestFileTaskService_ToggleCompleted_MissingTask(t *testing.T) {
+	ms := newMockStore("mock", []task.Task{newTaskWithID(uuid.New(), "other", false)})
+	svc := NewFileTaskService(ms)
+
+	if _, err := svc.ToggleCompleted(newTaskWithID(uuid.New(), "gone", false)); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("ToggleCompleted() error = %v, want ErrTaskNotFound", err)
+	}
+	if ms.saveCalls != 0 {
+		t.Fatalf("Save() was called %d times, want none", ms.saveCalls)
+	}
+}
+
 // -----------------------------------------------------------------------------
 // DeleteByID
 // -----------------------------------------------------------------------------
@@ -266,7 +396,7 @@ func TestFileTaskService_UpsertTask_UpdatesExisting(t *testing.T) {
 
 	updated := newTaskWithID(id, "updated title", true)
 
-	if err := svc.UpsertTask(updated); err != nil {
+	if _, err := svc.UpsertTask(updated); err != nil {
 		t.Fatalf("UpsertTask() error = %v, want nil", err)
 	}
 
@@ -293,7 +423,7 @@ func TestFileTaskService_UpsertTask_AppendsWhenNotFound(t *testing.T) {
 	newID := uuid.New()
 	newTask := newTaskWithID(newID, "new task", true)
 
-	if err := svc.UpsertTask(newTask); err != nil {
+	if _, err := svc.UpsertTask(newTask); err != nil {
 		t.Fatalf("UpsertTask() error = %v, want nil", err)
 	}
 
@@ -318,7 +448,7 @@ func TestFileTaskService_UpsertTask_LoadError(t *testing.T) {
 	ms.loadErr = errors.New("load failed")
 	svc := NewFileTaskService(ms)
 
-	err := svc.UpsertTask(task.Task{})
+	_, err := svc.UpsertTask(task.Task{})
 	if err == nil {
 		t.Fatalf("UpsertTask() error = nil, want non-nil")
 	}
@@ -334,7 +464,7 @@ func TestFileTaskService_UpsertTask_SaveError(t *testing.T) {
 	ms.saveErr = errors.New("save failed")
 	svc := NewFileTaskService(ms)
 
-	err := svc.UpsertTask(orig)
+	_, err := svc.UpsertTask(orig)
 	if err == nil {
 		t.Fatalf("UpsertTask() error = nil, want non-nil")
 	}
@@ -343,6 +473,305 @@ func TestFileTaskService_UpsertTask_SaveError(t *testing.T) {
 	}
 }
 
+func TestFileTaskService_UpsertTask_MaintainsTimestamps(t *testing.T) {
+	created := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := created
+	ms := newMockStore("mock", nil)
+	svc := &FileTaskService{store: ms, now: func() time.Time { return now }}
+
+	tk := newTaskWithID(uuid.New(), "write report", false)
+	saved, err := svc.UpsertTask(tk)
+	if err != nil {
+		t.Fatalf("UpsertTask() error = %v, want nil", err)
+	}
+	if !saved.CreatedAt.Equal(created) || !saved.UpdatedAt.Equal(created) {
+		t.Fatalf("CreatedAt = %v, UpdatedAt = %v, want both %v", saved.CreatedAt, saved.UpdatedAt, created)
+	}
+
+	// The edit menu sends tasks without timestamps; the stored creation
+	// time must survive the update.
+	now = created.Add(24 * time.Hour)
+	edit := newTaskWithID(tk.GetID(), "write final report", true)
+	saved, err = svc.UpsertTask(edit)
+	if err != nil {
+		t.Fatalf("UpsertTask() error = %v, want nil", err)
+	}
+	if !saved.CreatedAt.Equal(created) {
+		t.Errorf("CreatedAt = %v, want %v", saved.CreatedAt, created)
+	}
+	if !saved.UpdatedAt.Equal(now) || !saved.CompletedAt.Equal(now) {
+		t.Errorf("UpdatedAt = %v, CompletedAt = %v, want both %v", saved.UpdatedAt, saved.CompletedAt, now)
+	}
+	if !ms.tasks[0].CompletedAt.Equal(now) {
+		t.Errorf("stored CompletedAt = %v, want %v", ms.tasks[0].CompletedAt, now)
+	}
+}
+
+func TestFileTaskService_ToggleCompleted_RecordsCompletion(t *testing.T) {
+	created := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := created.Add(time.Hour)
+	orig := newTaskWithID(uuid.New(), "task", false)
+	orig.CreatedAt, orig.UpdatedAt = created, created
+
+	ms := newMockStore("mock", []task.Task{orig})
+	svc := &FileTaskService{store: ms, now: func() time.Time { return now }}
+
+	done, err := svc.ToggleCompleted(orig)
+	if err != nil {
+		t.Fatalf("ToggleCompleted() error = %v, want nil", err)
+	}
+	if !done.CompletedAt.Equal(now) || !ms.tasks[0].CompletedAt.Equal(now) {
+		t.Fatalf("CompletedAt = %v (stored %v), want %v", done.CompletedAt, ms.tasks[0].CompletedAt, now)
+	}
+	if !ms.tasks[0].CreatedAt.Equal(created) || !ms.tasks[0].UpdatedAt.Equal(now) {
+		t.Errorf("stored CreatedAt = %v, UpdatedAt = %v, want %v and %v",
+			ms.tasks[0].CreatedAt, ms.tasks[0].UpdatedAt, created, now)
+	}
+
+	reopened, err := svc.ToggleCompleted(done)
+	if err != nil {
+		t.Fatalf("ToggleCompleted() error = %v, want nil", err)
+	}
+	if !reopened.CompletedAt.IsZero() || !ms.tasks[0].CompletedAt.IsZero() {
+		t.Errorf("CompletedAt = %v (stored %v), want zero after reopening", reopened.CompletedAt, ms.tasks[0].CompletedAt)
+	}
+}
+
+func TestFileTaskService_UpsertTask_RejectsDependencyCycle(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	b := newTaskWithID(uuid.New(), "b", false)
+	b.BlockedBy = []uuid.UUID{a.GetID()}
+	ms := newMockStore("mock", []task.Task{a, b})
+	svc := NewFileTaskService(ms)
+
+	a.BlockedBy = []uuid.UUID{b.GetID()}
+	_, err := svc.UpsertTask(a)
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("UpsertTask() error = %v, want ErrDependencyCycle", err)
+	}
+	if wantSub := `"a" -> "b" -> "a"`; !containsSubstr(err.Error(), wantSub) {
+		t.Errorf("UpsertTask() error = %q, want substring %q", err.Error(), wantSub)
+	}
+	if ms.saveCalls != 0 {
+		t.Errorf("Save() was called %d times, want 0", ms.saveCalls)
+	}
+}
+
+func TestFileTaskService_UpsertTask_DropsDanglingBlockers(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	ms := newMockStore("mock", []task.Task{a})
+	svc := NewFileTaskService(ms)
+
+	b := newTaskWithID(uuid.New(), "b", false)
+	b.BlockedBy = []uuid.UUID{a.GetID(), uuid.New()}
+	saved, err := svc.UpsertTask(b)
+	if err != nil {
+		t.Fatalf("UpsertTask() error = %v, want nil", err)
+	}
+	if len(saved.BlockedBy) != 1 || saved.BlockedBy[0] != a.GetID() {
+		t.Errorf("BlockedBy = %v, want only %v", saved.BlockedBy, a.GetID())
+	}
+}
+
+func TestFileTaskService_DeleteByID_RemovesBlockerLinks(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	b := newTaskWithID(uuid.New(), "b", false)
+	b.BlockedBy = []uuid.UUID{a.GetID()}
+	ms := newMockStore("mock", []task.Task{a, b})
+	svc := NewFileTaskService(ms)
+
+	if err := svc.DeleteByID(a.GetID()); err != nil {
+		t.Fatalf("DeleteByID() error = %v, want nil", err)
+	}
+	if len(ms.tasks) != 1 || len(ms.tasks[0].BlockedBy) != 0 {
+		t.Fatalf("stored tasks = %+v, want b with no blockers", ms.tasks)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// MoveToList
+// -----------------------------------------------------------------------------
+
+func TestFileTaskService_MoveToList(t *testing.T) {
+	tk := newTaskWithID(uuid.New(), "task", false)
+	ms := newMockStore("mock", []task.Task{tk})
+	svc := NewFileTaskService(ms)
+
+	moved, err := svc.MoveToList(tk.GetID(), "Work")
+	if err != nil {
+		t.Fatalf("MoveToList() error = %v, want nil", err)
+	}
+	if moved.List != "Work" || ms.tasks[0].List != "Work" {
+		t.Fatalf("List = %q (stored %q), want %q", moved.List, ms.tasks[0].List, "Work")
+	}
+
+	// Moving back to the default list clears the name.
+	if _, err := svc.MoveToList(tk.GetID(), task.DefaultList); err != nil {
+		t.Fatalf("MoveToList() error = %v, want nil", err)
+	}
+	if ms.tasks[0].List != "" {
+		t.Fatalf("stored List = %q, want empty for the default list", ms.tasks[0].List)
+	}
+}
+
+func TestFileTaskService_MoveToList_NotFound(t *testing.T) {
+	ms := newMockStore("mock", nil)
+	svc := NewFileTaskService(ms)
+
+	_, err := svc.MoveToList(uuid.New(), "Work")
+	if !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("MoveToList() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Record stores
+// -----------------------------------------------------------------------------
+
+// recordStore is a store.RecordStore that counts how often all tasks
+// are loaded or saved at once.
+type recordStore struct {
+	*store.MemTaskStore
+	loadCalls int
+	saveCalls int
+}
+
+func newRecordStore(t *testing.T, tasks ...task.Task) *recordStore {
+	t.Helper()
+	rs := &recordStore{MemTaskStore: store.NewMemTaskStore()}
+	if err := rs.MemTaskStore.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	return rs
+}
+
+func (r *recordStore) Load() ([]task.Task, error) {
+	r.loadCalls++
+	return r.MemTaskStore.Load()
+}
+
+func (r *recordStore) Save(tasks []task.Task) error {
+	r.saveCalls++
+	return r.MemTaskStore.Save(tasks)
+}
+
+func (r *recordStore) tasks(t *testing.T) []task.Task {
+	t.Helper()
+	tasks, err := r.MemTaskStore.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return tasks
+}
+
+func TestFileTaskService_RecordStore_WritesSingleTasks(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	b := newTaskWithID(uuid.New(), "b", false)
+	b.BlockedBy = []uuid.UUID{a.GetID()}
+	rs := newRecordStore(t, a, b)
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	svc := &FileTaskService{store: rs, now: func() time.Time { return now }}
+
+	toggled, err := svc.ToggleCompleted(a)
+	if err != nil {
+		t.Fatalf("ToggleCompleted() error = %v, want nil", err)
+	}
+	if !toggled.Done || !toggled.CompletedAt.Equal(now) {
+		t.Fatalf("toggled = %+v, want done at %v", toggled, now)
+	}
+
+	c := newTaskWithID(uuid.New(), "c", false)
+	c.BlockedBy = []uuid.UUID{b.GetID(), uuid.New()}
+	if _, err := svc.UpsertTask(c); err != nil {
+		t.Fatalf("UpsertTask() error = %v, want nil", err)
+	}
+	a.BlockedBy = []uuid.UUID{c.GetID()}
+	if _, err := svc.UpsertTask(a); !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("UpsertTask(cycle) error = %v, want ErrDependencyCycle", err)
+	}
+	if _, err := svc.MoveToList(c.GetID(), "Work"); err != nil {
+		t.Fatalf("MoveToList() error = %v, want nil", err)
+	}
+	if err := svc.DeleteByID(b.GetID()); err != nil {
+		t.Fatalf("DeleteByID() error = %v, want nil", err)
+	}
+
+	if rs.saveCalls != 0 {
+		t.Fatalf("Save() was called %d times, want none", rs.saveCalls)
+	}
+	got := rs.tasks(t)
+	if len(got) != 2 || got[0].GetID() != a.GetID() || got[1].GetID() != c.GetID() {
+		t.Fatalf("stored tasks = %+v, want a and c", got)
+	}
+	if !got[0].Done || len(got[0].BlockedBy) != 0 {
+		t.Errorf("stored a = %+v, want done and unblocked", got[0])
+	}
+	if got[1].List != "Work" || len(got[1].BlockedBy) != 0 || !got[1].CreatedAt.Equal(now) {
+		t.Errorf("stored c = %+v, want in Work with no blockers left", got[1])
+	}
+}
+
+func TestFileTaskService_RecordStore_NextOccurrenceFollowsTask(t *testing.T) {
+	rule, err := task.ParseRecurrence("every week")
+	if err != nil {
+		t.Fatalf("ParseRecurrence error = %v", err)
+	}
+	orig := newTaskWithID(uuid.New(), "review dashboards", false)
+	orig.Recurrence = rule
+	other := newTaskWithID(uuid.New(), "other", false)
+	rs := newRecordStore(t, orig, other)
+	svc := NewFileTaskService(rs)
+
+	if _, err := svc.ToggleCompleted(orig); err != nil {
+		t.Fatalf("ToggleCompleted() error = %v, want nil", err)
+	}
+	got := rs.tasks(t)
+	if len(got) != 3 || !got[0].Done || !got[1].IsRecurring() || got[2].GetID() != other.GetID() {
+		t.Fatalf("stored tasks = %+v, want the next occurrence right after the completed task", got)
+	}
+}
+
+// dependentStore is a recordStore that finds the tasks blocked by a task
+// without loading all of them.
+type dependentStore struct {
+	*recordStore
+}
+
+func (d dependentStore) Dependents(id uuid.UUID) ([]task.Task, error) {
+	tasks, err := d.MemTaskStore.Load()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tasks, func(t task.Task) bool { return !slices.Contains(t.BlockedBy, id) }), nil
+}
+
+func TestFileTaskService_RecordStore_DeleteReadsDependentsOnly(t *testing.T) {
+	a := newTaskWithID(uuid.New(), "a", false)
+	b := newTaskWithID(uuid.New(), "b", false)
+	b.BlockedBy = []uuid.UUID{a.GetID()}
+	rs := newRecordStore(t, a, b)
+	svc := NewFileTaskService(dependentStore{rs})
+
+	if err := svc.DeleteByID(a.GetID()); err != nil {
+		t.Fatalf("DeleteByID() error = %v, want nil", err)
+	}
+	if rs.loadCalls != 0 || rs.saveCalls != 0 {
+		t.Fatalf("Load() and Save() were called %d and %d times, want none", rs.loadCalls, rs.saveCalls)
+	}
+	got := rs.tasks(t)
+	if len(got) != 1 || got[0].GetID() != b.GetID() || len(got[0].BlockedBy) != 0 {
+		t.Fatalf("stored tasks = %+v, want b unblocked", got)
+	}
+}
+
+func TestFileTaskService_RecordStore_ToggleMissingTask(t *testing.T) {
+	svc := NewFileTaskService(newRecordStore(t))
+
+	if _, err := svc.ToggleCompleted(newTaskWithID(uuid.New(), "gone", false)); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("ToggleCompleted() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Small helpers
 // -----------------------------------------------------------------------------
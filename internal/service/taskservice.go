@@ -15,8 +15,18 @@ type Service interface {
 	// Highlevel operations
 	ToggleCompleted(t task.Task) (task.Task, error)
 	DeleteByID(id uuid.UUID) error
-	UpsertTask(t task.Task) error
+	UpsertTask(t task.Task) (task.Task, error)
+
+	// Moves a task into the named list, creating the list if needed
+	MoveToList(id uuid.UUID, list string) (task.Task, error)
 
 	// For logging
 	Name() string
 }
+
+// BackgroundWriter is implemented by services that write changes to the
+// store after their methods return, so that failures are reported later.
+type BackgroundWriter interface {
+	// WriteErrors reports changes that could not be written.
+	WriteErrors() <-chan error
+}
@@ -0,0 +1,232 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// backupIDLayout formats the time a backup was taken into its ID.
+const backupIDLayout = "20060102T150405"
+
+// ErrBackupNotFound is returned when restoring a backup that does not
+// exist.
+var ErrBackupNotFound = errors.New("backup not found")
+
+// BackupPolicy says where to keep earlier versions of a tasks file and
+// how many of them.
+type BackupPolicy struct {
+	// Dir is the directory backups are kept in.
+	Dir string
+
+	// Keep is the number of backups to keep; older ones are removed.
+	// Zero turns backups off.
+	Keep int
+
+	// Interval is the least time between two backups. Saves made sooner
+	// after the newest backup do not take another one, so a burst of
+	// edits does not push all older backups out.
+	Interval time.Duration
+}
+
+// Backup is a copy of a tasks file as it was before a save.
+type Backup struct {
+	ID      string
+	Path    string
+	Created time.Time
+
+	// Tasks is the number of tasks in the backup, or -1 when it cannot
+	// be read.
+	Tasks int
+}
+
+// WithoutBackups returns a store for the same file that takes no
+// backups, for writes whose old contents should not be kept.
+func (fts *FileTaskStore) WithoutBackups() *FileTaskStore {
+	return &FileTaskStore{path: fts.path, name: fts.name, perm: fts.perm}
+}
+
+// BackupDir returns the directory backups are kept in.
+func (fts *FileTaskStore) BackupDir() string {
+	return fts.backups.Dir
+}
+
+// Backups returns the backups of the file, newest first.
+func (fts *FileTaskStore) Backups() ([]Backup, error) {
+	backups, err := fts.listBackups()
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		backups[i].Tasks = -1
+		b, err := os.ReadFile(backups[i].Path)
+		if err != nil {
+			continue
+		}
+		if tasks, _, err := parseTasksFile(b); err == nil {
+			backups[i].Tasks = len(tasks)
+		}
+	}
+	return backups, nil
+}
+
+// Restore replaces the tasks with those in the backup with the given ID.
+// The file is backed up first, so a restore can itself be undone.
+func (fts *FileTaskStore) Restore(id string) (Backup, error) {
+	fts.mu.Lock()
+	defer fts.mu.Unlock()
+
+	unlock, err := lockFile(fts.path + lockSuffix)
+	if err != nil {
+		return Backup{}, err
+	}
+	defer unlock()
+
+	backups, err := fts.listBackups()
+	if err != nil {
+		return Backup{}, err
+	}
+	i := slices.IndexFunc(backups, func(b Backup) bool { return b.ID == id })
+	if i < 0 {
+		return Backup{}, fmt.Errorf("%w: %s", ErrBackupNotFound, id)
+	}
+	restored := backups[i]
+
+	b, err := os.ReadFile(restored.Path)
+	if err != nil {
+		return Backup{}, err
+	}
+	tasks, _, err := parseTasksFile(b)
+	if err != nil {
+		return Backup{}, fmt.Errorf("read backup %s: %w", id, err)
+	}
+	restored.Tasks = len(tasks)
+
+	current, err := readIfExists(fts.path)
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := fts.backup(current, true); err != nil {
+		return Backup{}, fmt.Errorf("back up tasks file: %w", err)
+	}
+	return restored, fts.write(tasks)
+}
+
+// backup keeps current, the contents of the file about to be replaced,
+// as a new backup when the policy asks for one or force is set, and
+// removes the backups that no longer fit. The caller holds the lock.
+func (fts *FileTaskStore) backup(current []byte, force bool) error {
+	if fts.backups.Keep <= 0 || len(bytes.TrimSpace(current)) == 0 {
+		return nil
+	}
+
+	backups, err := fts.listBackups()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if !force && len(backups) > 0 && now.Sub(backups[0].Created) < fts.backups.Interval {
+		return nil
+	}
+
+	if err := os.MkdirAll(fts.backups.Dir, 0o755); err != nil {
+		return err
+	}
+	// Backups taken within the same second get a counter.
+	id := now.Format(backupIDLayout)
+	for n := 2; fileExists(fts.backupPath(id)); n++ {
+		id = fmt.Sprintf("%s-%d", now.Format(backupIDLayout), n)
+	}
+	// Backups are as private as the file they were taken from.
+	if err := os.WriteFile(fts.backupPath(id), current, writePerm(fts.path, fts.perm)); err != nil {
+		return err
+	}
+
+	if backups, err = fts.listBackups(); err != nil {
+		return err
+	}
+	for _, old := range backups[min(fts.backups.Keep, len(backups)):] {
+		if err := os.Remove(old.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// backupName splits the file name of the tasks file around the spot
+// where backups put their ID: tasks.json has backups named
+// tasks-<id>.json.
+func (fts *FileTaskStore) backupName() (prefix, suffix string) {
+	base := filepath.Base(fts.path)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func (fts *FileTaskStore) backupPath(id string) string {
+	prefix, suffix := fts.backupName()
+	return filepath.Join(fts.backups.Dir, prefix+id+suffix)
+}
+
+// listBackups returns the backups in the backup directory, newest
+// first, without reading them.
+func (fts *FileTaskStore) listBackups() ([]Backup, error) {
+	if fts.backups.Dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(fts.backups.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, suffix := fts.backupName()
+	var backups []Backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+		if len(id) < len(backupIDLayout) {
+			continue
+		}
+		created, err := time.ParseInLocation(backupIDLayout, id[:len(backupIDLayout)], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			ID:      id,
+			Path:    filepath.Join(fts.backups.Dir, name),
+			Created: created,
+		})
+	}
+
+	slices.SortFunc(backups, func(a, b Backup) int {
+		if c := b.Created.Compare(a.Created); c != 0 {
+			return c
+		}
+		return compareBackupIDs(b.ID, a.ID)
+	})
+	return backups, nil
+}
+
+// compareBackupIDs orders IDs taken within the same second by their
+// counter, which has no counter for the first one.
+func compareBackupIDs(a, b string) int {
+	if c := len(a) - len(b); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
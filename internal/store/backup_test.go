@@ -0,0 +1,149 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// newTempBackupStore returns a file store in a temporary directory that
+// keeps up to keep backups, taking one on every save.
+func newTempBackupStore(t *testing.T, keep int) *FileTaskStore {
+	t.Helper()
+
+	dir := t.TempDir()
+	return NewFileTaskStoreWithBackups(filepath.Join(dir, "tasks.json"), BackupPolicy{
+		Dir:  filepath.Join(dir, "backups"),
+		Keep: keep,
+	})
+}
+
+// saveTitles saves one task per title.
+func saveTitles(t *testing.T, s *FileTaskStore, titles ...string) {
+	t.Helper()
+
+	tasks := make([]task.Task, len(titles))
+	for i, title := range titles {
+		tasks[i] = task.Task{TitleStr: title}
+	}
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+}
+
+func TestFileTaskStore_Backups_Rotate(t *testing.T) {
+	s := newTempBackupStore(t, 2)
+
+	// The first save has nothing to back up yet.
+	saveTitles(t, s, "a")
+	saveTitles(t, s, "a", "b")
+	saveTitles(t, s, "a", "b", "c")
+	saveTitles(t, s, "a", "b", "c", "d")
+
+	backups, err := s.Backups()
+	if err != nil {
+		t.Fatalf("Backups() error = %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Backups() = %d backups, want 2", len(backups))
+	}
+	// Newest first: the file before the last save, then before that.
+	if backups[0].Tasks != 3 || backups[1].Tasks != 2 {
+		t.Fatalf("backup task counts = %d, %d; want 3, 2", backups[0].Tasks, backups[1].Tasks)
+	}
+	if backups[0].ID == backups[1].ID {
+		t.Fatalf("backups share ID %q", backups[0].ID)
+	}
+}
+
+func TestFileTaskStore_Backups_Interval(t *testing.T) {
+	s := newTempBackupStore(t, 5)
+	s.backups.Interval = time.Hour
+
+	saveTitles(t, s, "a")
+	saveTitles(t, s, "a", "b")
+	saveTitles(t, s, "a", "b", "c")
+
+	backups, err := s.Backups()
+	if err != nil {
+		t.Fatalf("Backups() error = %v", err)
+	}
+	if len(backups) != 1 || backups[0].Tasks != 1 {
+		t.Fatalf("Backups() = %+v, want one backup of the first save", backups)
+	}
+}
+
+func TestFileTaskStore_Backups_Disabled(t *testing.T) {
+	s := newTempBackupStore(t, 0)
+
+	saveTitles(t, s, "a")
+	saveTitles(t, s, "a", "b")
+
+	if _, err := os.Stat(s.backups.Dir); !os.IsNotExist(err) {
+		t.Fatalf("backup dir created with backups off, Stat() error = %v", err)
+	}
+}
+
+func TestFileTaskStore_Restore(t *testing.T) {
+	s := newTempBackupStore(t, 5)
+
+	saveTitles(t, s, "a")
+	saveTitles(t, s, "a", "b")
+	// A stray write wipes the file.
+	if err := os.WriteFile(s.path, []byte("\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	backups, err := s.Backups()
+	if err != nil {
+		t.Fatalf("Backups() error = %v", err)
+	}
+	restored, err := s.Restore(backups[0].ID)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if restored.Tasks != 1 {
+		t.Fatalf("Restore() = %d tasks, want 1", restored.Tasks)
+	}
+
+	loaded, err := NewFileTaskStore(s.path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Title() != "a" {
+		t.Fatalf("Load() = %+v, want the backed up task", loaded)
+	}
+
+	if _, err := s.Restore("20000101T000000"); !errors.Is(err, ErrBackupNotFound) {
+		t.Fatalf("Restore(unknown) error = %v, want ErrBackupNotFound", err)
+	}
+}
+
+func TestFileTaskStore_Restore_BacksUpCurrentFile(t *testing.T) {
+	s := newTempBackupStore(t, 5)
+	s.backups.Interval = time.Hour
+
+	saveTitles(t, s, "a")
+	saveTitles(t, s, "a", "b")
+
+	backups, err := s.Backups()
+	if err != nil {
+		t.Fatalf("Backups() error = %v", err)
+	}
+	if _, err := s.Restore(backups[0].ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	// The restore took a backup despite the interval, so it can be
+	// undone.
+	if backups, err = s.Backups(); err != nil {
+		t.Fatalf("Backups() error = %v", err)
+	}
+	if len(backups) != 2 || backups[0].Tasks != 2 {
+		t.Fatalf("Backups() = %+v, want the replaced file backed up first", backups)
+	}
+}
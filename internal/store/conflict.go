@@ -0,0 +1,186 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/jacobdanielrose/terminaltask/internal/merge"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// mergedSuffix is added to the name of a conflict copy once it has been
+// merged, so it is no longer taken for one.
+const mergedSuffix = ".merged"
+
+// ErrMergeConflict matches the errors Load returns, as a
+// *MergeConflictError, when a conflict copy of the tasks file cannot be
+// merged without asking.
+var ErrMergeConflict = errors.New("tasks file has a conflicting copy")
+
+// MergeConflictError is returned by FileTaskStore.Load when a sync tool
+// left a conflict copy of the tasks file, such as
+// tasks.sync-conflict-20261017-093000-ABCDEFG.json, that changes some
+// fields of some tasks in a different way than the tasks file does.
+// Saving the resolved tasks of the merge puts them in place of both.
+type MergeConflictError struct {
+	Path string
+	Copy string
+
+	// Base, Ours, and Theirs are the versions that were merged: the
+	// newest backup taken before both files were written, if any, the
+	// tasks file, and the copy.
+	Base, Ours, Theirs []task.Task
+
+	Result merge.Result
+}
+
+func (e *MergeConflictError) Error() string {
+	conflicts := "conflicts"
+	if len(e.Result.Conflicts) == 1 {
+		conflicts = "conflict"
+	}
+	return fmt.Sprintf("%s: %d %s with %s", e.Path, len(e.Result.Conflicts), conflicts, filepath.Base(e.Copy))
+}
+
+func (e *MergeConflictError) Is(target error) bool {
+	return target == ErrMergeConflict
+}
+
+// mergeConflictCopies merges the conflict copies of the tasks file into
+// tasks, the tasks in it, whose contents are current. Copies that merge
+// without conflicts are written to the file, which is backed up first,
+// and renamed out of the way. It stops at the first copy with
+// conflicts, which the next save finishes merging. The caller holds the
+// lock.
+func (fts *FileTaskStore) mergeConflictCopies(current []byte, tasks []task.Task) ([]task.Task, error) {
+	copies, err := fts.conflictCopies()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range copies {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		theirs, _, err := parseTasksFile(b)
+		if err != nil {
+			log.Warn("ignoring unreadable conflict copy", "copy", path, "err", err)
+			continue
+		}
+
+		base := fts.mergeBase(path)
+		result := merge.Merge(base, tasks, theirs)
+		if len(result.Conflicts) > 0 {
+			fts.merging = path
+			return nil, &MergeConflictError{
+				Path: fts.path, Copy: path,
+				Base: base, Ours: tasks, Theirs: theirs,
+				Result: result,
+			}
+		}
+
+		merged, err := result.Resolved()
+		if err != nil {
+			return nil, err
+		}
+		if err := fts.backup(current, true); err != nil {
+			return nil, fmt.Errorf("back up tasks file: %w", err)
+		}
+		if err := fts.write(merged); err != nil {
+			return nil, err
+		}
+		if err := retireConflictCopy(path); err != nil {
+			return nil, err
+		}
+		log.Info("merged conflict copy", "copy", path, "into", fts.path, "changes", result.Changes)
+
+		if current, err = readIfExists(fts.path); err != nil {
+			return nil, err
+		}
+		tasks = merged
+	}
+	return tasks, nil
+}
+
+// conflictCopies returns the conflict copies of the tasks file that
+// sync tools left next to it. For tasks.json, they are the files whose
+// names start with tasks, end in .json, and mention a conflict in
+// between, as the copies of Syncthing, Dropbox, and Nextcloud do.
+func (fts *FileTaskStore) conflictCopies() ([]string, error) {
+	dir, name := filepath.Split(fts.path)
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var copies []string
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || n == name || !strings.HasPrefix(n, stem) || !strings.HasSuffix(n, ext) {
+			continue
+		}
+		if strings.Contains(strings.ToLower(n[len(stem):len(n)-len(ext)]), "conflict") {
+			copies = append(copies, filepath.Join(dir, n))
+		}
+	}
+	slices.Sort(copies)
+	return copies, nil
+}
+
+// mergeBase returns the tasks that the tasks file and the conflict copy
+// at path most likely both started from: those in the newest backup
+// taken before either was last written. Without such a backup, the
+// base is empty, and tasks deleted on one side come back.
+func (fts *FileTaskStore) mergeBase(path string) []task.Task {
+	ours, err := os.Stat(fts.path)
+	if err != nil {
+		return nil
+	}
+	theirs, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	before := ours.ModTime()
+	if theirs.ModTime().Before(before) {
+		before = theirs.ModTime()
+	}
+
+	backups, err := fts.listBackups()
+	if err != nil {
+		return nil
+	}
+	for _, b := range backups {
+		if b.Created.After(before) {
+			continue
+		}
+		contents, err := os.ReadFile(b.Path)
+		if err != nil {
+			continue
+		}
+		if tasks, _, err := parseTasksFile(contents); err == nil {
+			return tasks
+		}
+	}
+	return nil
+}
+
+// retireConflictCopy renames a merged conflict copy out of the way,
+// keeping it in case the merge needs checking.
+func retireConflictCopy(path string) error {
+	if err := os.Rename(path, path+mergedSuffix); err != nil {
+		return fmt.Errorf("put away merged conflict copy: %w", err)
+	}
+	return nil
+}
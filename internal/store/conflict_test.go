@@ -0,0 +1,134 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/merge"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// divergedStore returns a store whose tasks file went from base to ours,
+// with a backup of base, and a conflict copy holding theirs next to it,
+// as a sync tool leaves when two machines saved base in different ways.
+func divergedStore(t *testing.T, base, ours, theirs []task.Task) (*FileTaskStore, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+	s := NewFileTaskStoreWithBackups(path, BackupPolicy{Dir: filepath.Join(dir, "backups"), Keep: 5})
+	if err := s.Save(base); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := s.Save(ours); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	copyPath := filepath.Join(dir, "tasks.sync-conflict-20261017-093000-ABCDEFG.json")
+	if err := WriteTasksFile(copyPath, theirs); err != nil {
+		t.Fatalf("WriteTasksFile() error = %v", err)
+	}
+	return NewFileTaskStoreWithBackups(path, s.backups), copyPath
+}
+
+func TestFileTaskStore_LoadMergesConflictCopy(t *testing.T) {
+	report := task.Task{ID: uuid.New(), TitleStr: "report"}
+	gym := task.Task{ID: uuid.New(), TitleStr: "gym"}
+
+	ourReport := report
+	ourReport.TitleStr = "quarterly report"
+	theirGym := gym
+	theirGym.Done = true
+	added := task.Task{ID: uuid.New(), TitleStr: "groceries"}
+
+	s, copyPath := divergedStore(t,
+		[]task.Task{report, gym},
+		[]task.Task{ourReport, gym},
+		[]task.Task{report, theirGym, added},
+	)
+
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []task.Task{ourReport, theirGym, added}
+	assertSameTasks(t, tasks, want)
+
+	// The merge is saved, and the copy put away.
+	if _, err := os.Stat(copyPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("conflict copy still in place: %v", err)
+	}
+	if _, err := os.Stat(copyPath + mergedSuffix); err != nil {
+		t.Fatalf("merged conflict copy not kept: %v", err)
+	}
+	tasks, err = NewFileTaskStore(s.path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, tasks, want)
+}
+
+func TestFileTaskStore_LoadReportsMergeConflicts(t *testing.T) {
+	report := task.Task{ID: uuid.New(), TitleStr: "report"}
+	ourReport, theirReport := report, report
+	ourReport.TitleStr, theirReport.TitleStr = "Q1 report", "annual report"
+
+	s, copyPath := divergedStore(t, []task.Task{report}, []task.Task{ourReport}, []task.Task{theirReport})
+
+	_, err := s.Load()
+	var conflict *MergeConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrMergeConflict) {
+		t.Fatalf("Load() error = %v, want a *MergeConflictError", err)
+	}
+	if conflict.Copy != copyPath || len(conflict.Result.Conflicts) != 1 {
+		t.Fatalf("conflict = %+v, want one conflict with %s", conflict, copyPath)
+	}
+	assertSameTasks(t, conflict.Base, []task.Task{report})
+
+	// Saving the resolved tasks finishes the merge.
+	conflict.Result.Conflicts[0].Choice = merge.Theirs
+	resolved, err := conflict.Result.Resolved()
+	if err != nil {
+		t.Fatalf("Resolved() error = %v", err)
+	}
+	if err := s.Save(resolved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(copyPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("conflict copy still in place after saving the merge: %v", err)
+	}
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, tasks, []task.Task{theirReport})
+}
+
+func TestFileTaskStore_ConflictCopies(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"tasks.json",
+		"tasks.sync-conflict-20261017-093000-ABCDEFG.json",
+		"tasks (Desktop's conflicted copy 2026-10-17).json",
+		"tasks (conflicted copy 2026-10-17 093000).json",
+		"tasks.sync-conflict-20261016-093000-ABCDEFG.json" + mergedSuffix,
+		"tasks-20261017T093000.json",
+		"todo (conflicted copy).json",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	copies, err := NewFileTaskStore(filepath.Join(dir, "tasks.json")).conflictCopies()
+	if err != nil {
+		t.Fatalf("conflictCopies() error = %v", err)
+	}
+	if len(copies) != 3 {
+		t.Fatalf("conflictCopies() = %q, want the three copies", copies)
+	}
+}
@@ -0,0 +1,463 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/merge"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// sealedPrefix starts the description of every encrypted task and
+	// names the format of what follows.
+	sealedPrefix = "terminaltask-sealed:v1:"
+
+	// sealedTitle is the title encrypted tasks show to anything reading
+	// the inner store directly.
+	sealedTitle = "(encrypted)"
+
+	// Key derivation parameters, as recommended for interactive use.
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+
+	// encryptedPerm is the permission files of an encrypted store get.
+	encryptedPerm = 0o600
+)
+
+var (
+	// ErrWrongPassphrase is returned when encrypted tasks cannot be
+	// decrypted, because the passphrase is wrong or the data was
+	// tampered with.
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted data")
+
+	// ErrNotEncrypted is returned when an encrypted store finds tasks
+	// that were stored in plain text.
+	ErrNotEncrypted = errors.New("tasks are not encrypted")
+)
+
+// EncryptedTaskStore encrypts tasks before handing them to another
+// store. Each task is sealed with AES-256-GCM under a key derived from a
+// passphrase with scrypt, and stored as a task that keeps only its ID,
+// so that the inner store can still tell tasks apart. Files of the inner
+// store are readable by their owner only: existing ones are restricted
+// before anything is written to them, and new ones are created that way
+// when the inner store is a FilePermSetter.
+type EncryptedTaskStore struct {
+	inner      TaskStore
+	passphrase []byte
+
+	mu sync.Mutex
+	// salt and key are used to seal tasks; keys caches the key of each
+	// salt seen so far, since deriving one is slow on purpose.
+	salt []byte
+	key  []byte
+	keys map[string][]byte
+	// sealed remembers the sealed form of each task as last loaded or
+	// saved, so unchanged tasks are stored unchanged.
+	sealed map[uuid.UUID]sealedTask
+}
+
+// sealedTask pairs the JSON form of a task with its sealed form.
+type sealedTask struct {
+	plain []byte
+	blob  string
+}
+
+// NewEncryptedTaskStore returns a store that keeps tasks in inner,
+// encrypted with passphrase.
+func NewEncryptedTaskStore(inner TaskStore, passphrase []byte) *EncryptedTaskStore {
+	if ps, ok := inner.(FilePermSetter); ok {
+		ps.SetFilePerm(encryptedPerm)
+	}
+	return &EncryptedTaskStore{
+		inner:      inner,
+		passphrase: passphrase,
+		keys:       map[string][]byte{},
+		sealed:     map[uuid.UUID]sealedTask{},
+	}
+}
+
+func (s *EncryptedTaskStore) Name() string {
+	return s.inner.Name() + " (encrypted)"
+}
+
+// Paths returns the files of the inner store, if it has any.
+func (s *EncryptedTaskStore) Paths() []string {
+	if fb, ok := s.inner.(FileBacked); ok {
+		return fb.Paths()
+	}
+	return nil
+}
+
+// Load opens the tasks of the inner store. When the inner store is
+// damaged, the tasks salvaged from it are opened too, and when it has a
+// conflict copy to merge, the merge is done again on the opened tasks,
+// since the sealed ones only tell which tasks changed, not which
+// fields.
+func (s *EncryptedTaskStore) Load() ([]task.Task, error) {
+	stored, err := s.inner.Load()
+	var (
+		corrupt  *CorruptError
+		conflict *MergeConflictError
+	)
+	switch {
+	case errors.As(err, &corrupt):
+		opened, openErr := s.openAll(corrupt.Tasks)
+		if openErr != nil {
+			return nil, err
+		}
+		salvaged := *corrupt
+		salvaged.Tasks = opened
+		return nil, &salvaged
+
+	case errors.As(err, &conflict):
+		return s.remerge(conflict)
+
+	case err != nil:
+		return nil, err
+	}
+	return s.openAll(stored)
+}
+
+// remerge merges the opened versions of a conflict the inner store
+// could not merge. If they merge cleanly after all, the result is saved,
+// which finishes the merge in the inner store.
+func (s *EncryptedTaskStore) remerge(conflict *MergeConflictError) ([]task.Task, error) {
+	base, err := s.openAll(conflict.Base)
+	if err != nil {
+		// A backup taken under another passphrase is no use as a base.
+		base = nil
+	}
+	theirs, err := s.openAll(conflict.Theirs)
+	if err != nil {
+		return nil, conflict
+	}
+	// Ours last, so that the sealed forms remembered are those of the
+	// tasks file.
+	ours, err := s.openAll(conflict.Ours)
+	if err != nil {
+		return nil, conflict
+	}
+
+	result := merge.Merge(base, ours, theirs)
+	if len(result.Conflicts) > 0 {
+		opened := *conflict
+		opened.Base, opened.Ours, opened.Theirs, opened.Result = base, ours, theirs, result
+		return nil, &opened
+	}
+	merged, err := result.Resolved()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Save(merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// openAll decrypts stored, remembering each task's sealed form.
+func (s *EncryptedTaskStore) openAll(stored []task.Task) ([]task.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]task.Task, len(stored))
+	sealed := make(map[uuid.UUID]sealedTask, len(stored))
+	for i, st := range stored {
+		blob, ok := strings.CutPrefix(st.DescStr, sealedPrefix)
+		if !ok {
+			return nil, fmt.Errorf("task %q: %w", st.Title(), ErrNotEncrypted)
+		}
+		plain, err := s.open(blob)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(plain, &tasks[i]); err != nil {
+			return nil, fmt.Errorf("decode task: %w", err)
+		}
+		sealed[tasks[i].ID] = sealedTask{plain: plain, blob: st.DescStr}
+	}
+	s.sealed = sealed
+	return tasks, nil
+}
+
+func (s *EncryptedTaskStore) Save(tasks []task.Task) error {
+	s.mu.Lock()
+	stored, sealed, err := s.sealAll(tasks)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := restrictFiles(s.Paths()); err != nil {
+		return err
+	}
+	if err := s.inner.Save(stored); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.sealed = sealed
+	s.mu.Unlock()
+	return nil
+}
+
+// sealAll seals tasks, reusing the sealed form of those unchanged since
+// they were last loaded or saved. It must be called with mu held.
+func (s *EncryptedTaskStore) sealAll(tasks []task.Task) ([]task.Task, map[uuid.UUID]sealedTask, error) {
+	stored := make([]task.Task, len(tasks))
+	sealed := make(map[uuid.UUID]sealedTask, len(tasks))
+	for i, t := range tasks {
+		plain, err := json.Marshal(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		prev, ok := s.sealed[t.ID]
+		if !ok || !bytes.Equal(prev.plain, plain) {
+			blob, err := s.seal(plain)
+			if err != nil {
+				return nil, nil, err
+			}
+			prev = sealedTask{plain: plain, blob: sealedPrefix + blob}
+		}
+		sealed[t.ID] = prev
+		stored[i] = task.Task{ID: t.ID, TitleStr: sealedTitle, DescStr: prev.blob}
+	}
+	return stored, sealed, nil
+}
+
+// SealBackups encrypts, in place, the backups of fts that hold tasks in
+// plain text, such as those taken before the tasks were encrypted, and
+// makes every backup readable by its owner only. It returns the number
+// of backups encrypted, and those that could not be read, which are
+// left as they are.
+func (s *EncryptedTaskStore) SealBackups(fts *FileTaskStore) (int, []Backup, error) {
+	backups, err := fts.listBackups()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var (
+		n          int
+		unreadable []Backup
+	)
+	for _, b := range backups {
+		tasks, err := ReadTasksFile(b.Path)
+		if err != nil {
+			unreadable = append(unreadable, b)
+			continue
+		}
+		if len(tasks) == 0 || IsEncrypted(tasks) {
+			if err := restrictFiles([]string{b.Path}); err != nil {
+				return n, unreadable, err
+			}
+			continue
+		}
+
+		s.mu.Lock()
+		stored, _, err := s.sealAll(tasks)
+		s.mu.Unlock()
+		if err != nil {
+			return n, unreadable, err
+		}
+		contents, err := encodeTasks(stored)
+		if err != nil {
+			return n, unreadable, err
+		}
+		if err := writeFileAtomic(b.Path, contents, encryptedPerm); err != nil {
+			return n, unreadable, fmt.Errorf("backup %s: %w", b.ID, err)
+		}
+		n++
+	}
+	return n, unreadable, nil
+}
+
+// Get opens the task with the given ID only. The inner store reads just
+// that task if it is a RecordStore.
+func (s *EncryptedTaskStore) Get(id uuid.UUID) (task.Task, error) {
+	st, err := GetTask(s.inner, id)
+	if err != nil {
+		return task.Task{}, err
+	}
+	blob, ok := strings.CutPrefix(st.DescStr, sealedPrefix)
+	if !ok {
+		return task.Task{}, fmt.Errorf("task %q: %w", st.Title(), ErrNotEncrypted)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plain, err := s.open(blob)
+	if err != nil {
+		return task.Task{}, err
+	}
+	var t task.Task
+	if err := json.Unmarshal(plain, &t); err != nil {
+		return task.Task{}, fmt.Errorf("decode task: %w", err)
+	}
+	s.sealed[t.ID] = sealedTask{plain: plain, blob: st.DescStr}
+	return t, nil
+}
+
+// Put seals t alone and hands it to the inner store, which writes just
+// that task if it is a RecordStore.
+func (s *EncryptedTaskStore) Put(t task.Task) error {
+	plain, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	sealed, ok := s.sealed[t.ID]
+	if !ok || !bytes.Equal(sealed.plain, plain) {
+		var blob string
+		blob, err = s.seal(plain)
+		sealed = sealedTask{plain: plain, blob: sealedPrefix + blob}
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := restrictFiles(s.Paths()); err != nil {
+		return err
+	}
+	if err := PutTask(s.inner, task.Task{ID: t.ID, TitleStr: sealedTitle, DescStr: sealed.blob}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.sealed[t.ID] = sealed
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *EncryptedTaskStore) Delete(id uuid.UUID) error {
+	if err := restrictFiles(s.Paths()); err != nil {
+		return err
+	}
+	if err := DeleteTask(s.inner, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.sealed, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// IsEncrypted reports whether any of tasks, as read from a store
+// directly, was stored by an EncryptedTaskStore.
+func IsEncrypted(tasks []task.Task) bool {
+	for _, t := range tasks {
+		if strings.HasPrefix(t.DescStr, sealedPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// seal encrypts plain, returning the salt, nonce, and ciphertext encoded
+// together.
+func (s *EncryptedTaskStore) seal(plain []byte) (string, error) {
+	if s.key == nil {
+		salt := make([]byte, saltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key, err := s.deriveKey(salt)
+		if err != nil {
+			return "", err
+		}
+		s.salt, s.key = salt, key
+	}
+
+	aead, err := newAEAD(s.key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	out := append(bytes.Clone(s.salt), nonce...)
+	out = aead.Seal(out, nonce, plain, nil)
+	return base64.RawStdEncoding.EncodeToString(out), nil
+}
+
+// open decrypts a blob made by seal. The first key that works is kept
+// for sealing, so saves need not derive a new one.
+func (s *EncryptedTaskStore) open(blob string) ([]byte, error) {
+	data, err := base64.RawStdEncoding.DecodeString(blob)
+	if err != nil || len(data) < saltLen {
+		return nil, ErrWrongPassphrase
+	}
+	salt, data := data[:saltLen], data[saltLen:]
+
+	key, err := s.deriveKey(salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	if s.key == nil {
+		s.salt, s.key = bytes.Clone(salt), key
+	}
+	return plain, nil
+}
+
+// deriveKey returns the key for salt, deriving it the first time.
+func (s *EncryptedTaskStore) deriveKey(salt []byte) ([]byte, error) {
+	if key, ok := s.keys[string(salt)]; ok {
+		return key, nil
+	}
+	key, err := scrypt.Key(s.passphrase, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	s.keys[string(salt)] = key
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// restrictFiles makes the files at paths readable by their owner only.
+// Files that do not exist are skipped.
+func restrictFiles(paths []string) error {
+	for _, p := range paths {
+		if err := os.Chmod(p, encryptedPerm); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedTaskStore_RoundTrip(t *testing.T) {
+	inner, path := newTempStore(t, "tasks.json")
+	s := NewEncryptedTaskStore(inner, []byte("correct horse"))
+
+	original := sampleTasks()
+	if err := s.Save(original); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, secret := range []string{"write report", "quarterly numbers", "finance", "Europe/Berlin"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("tasks file contains %q in plain text", secret)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("tasks file permissions = %o, want 600", perm)
+	}
+
+	reopened := NewEncryptedTaskStore(NewFileTaskStore(path), []byte("correct horse"))
+	loaded, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, original)
+}
+
+func TestEncryptedTaskStore_InnerStoreCreatesPrivateFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	inner := NewFileTaskStoreWithBackups(path, BackupPolicy{Dir: filepath.Join(filepath.Dir(path), "backups"), Keep: 3})
+	NewEncryptedTaskStore(inner, []byte("secret"))
+
+	// Files are private from the moment the inner store creates them,
+	// not only once an encrypted save restricts them.
+	for range 2 {
+		if err := inner.Save(nil); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	backups, err := inner.Backups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Backups() = %v, %v; want one", backups, err)
+	}
+	for _, p := range []string{path, backups[0].Path} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("Stat() error = %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s permissions = %o, want 600", filepath.Base(p), perm)
+		}
+	}
+}
+
+func TestEncryptedTaskStore_SealBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	plain := NewFileTaskStoreWithBackups(path, BackupPolicy{Dir: filepath.Join(filepath.Dir(path), "backups"), Keep: 3})
+	original := sampleTasks()
+	for range 2 {
+		if err := plain.Save(original); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	backups, err := plain.Backups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Backups() = %v, %v; want one", backups, err)
+	}
+
+	s := NewEncryptedTaskStore(plain, []byte("secret"))
+	n, unreadable, err := s.SealBackups(plain)
+	if err != nil || n != 1 || len(unreadable) != 0 {
+		t.Fatalf("SealBackups() = %d, %v, %v; want 1 sealed", n, unreadable, err)
+	}
+	b, err := os.ReadFile(backups[0].Path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(b), "write report") {
+		t.Errorf("backup still holds plain text")
+	}
+	if info, err := os.Stat(backups[0].Path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("backup stat = %v, %v; want permissions 600", info, err)
+	}
+
+	// The sealed backup opens with the passphrase, and sealing again
+	// leaves it alone.
+	sealed, err := ReadTasksFile(backups[0].Path)
+	if err != nil {
+		t.Fatalf("ReadTasksFile() error = %v", err)
+	}
+	opened, err := s.openAll(sealed)
+	if err != nil {
+		t.Fatalf("openAll() error = %v", err)
+	}
+	assertSameTasks(t, opened, original)
+	if n, _, err := s.SealBackups(plain); err != nil || n != 0 {
+		t.Fatalf("second SealBackups() = %d, %v; want none sealed", n, err)
+	}
+}
+
+func TestEncryptedTaskStore_WrongPassphrase(t *testing.T) {
+	inner, path := newTempStore(t, "tasks.json")
+	if err := NewEncryptedTaskStore(inner, []byte("right")).Save(sampleTasks()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	_, err := NewEncryptedTaskStore(NewFileTaskStore(path), []byte("wrong")).Load()
+	if !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("Load() error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestEncryptedTaskStore_PlainTasks(t *testing.T) {
+	inner, _ := newTempStore(t, "tasks.json")
+	if err := inner.Save(sampleTasks()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	_, err := NewEncryptedTaskStore(inner, []byte("secret")).Load()
+	if !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("Load() error = %v, want ErrNotEncrypted", err)
+	}
+	if tasks, _ := inner.Load(); IsEncrypted(tasks) {
+		t.Fatal("IsEncrypted() = true for plain tasks")
+	}
+}
+
+func TestEncryptedTaskStore_WrapsJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	journal := NewJournalTaskStore(path)
+	s := NewEncryptedTaskStore(journal, []byte("secret"))
+
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// Unchanged tasks are stored unchanged, so only the edit is
+	// journaled.
+	tasks[1].TitleStr = "renamed"
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got := opKinds(t, journal); !slices.Equal(got, []OpKind{OpCreate, OpCreate, OpUpdate}) {
+		t.Fatalf("journal = %v, want two creates and an update", got)
+	}
+
+	info, err := os.Stat(path + journalSuffix)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("journal permissions = %o, want 600", perm)
+	}
+
+	loaded, err := NewEncryptedTaskStore(NewJournalTaskStore(path), []byte("secret")).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+}
@@ -1,10 +1,15 @@
 package store
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/jacobdanielrose/terminaltask/internal/task"
 )
@@ -13,48 +18,272 @@ const (
 	DefaultName = "File Store"
 )
 
+// ErrStaleSave is returned when the tasks file was changed by another
+// process after it was last loaded, so saving would overwrite those
+// changes.
+var ErrStaleSave = errors.New("tasks file was changed by another session since it was loaded; reload before saving")
+
+// FileTaskStore keeps tasks in a JSON file. Loads and saves hold an
+// advisory lock on a lock file next to it, and a save is refused with
+// ErrStaleSave when the file no longer matches what was last loaded.
+// Loading a damaged file fails with a *CorruptError holding the tasks
+// that could be salvaged. Conflict copies of the file that sync tools
+// leave next to it are merged into it on load; see
+// MergeConflictError.
 type FileTaskStore struct {
 	path string
 	name string
+
+	// backups says which earlier versions of the file to keep.
+	backups BackupPolicy
+
+	// mu guards revision, the hash of the file as last loaded or saved
+	// by this store. seen is false until then.
+	mu       sync.Mutex
+	revision [sha256.Size]byte
+	seen     bool
+
+	// merging is the conflict copy whose merge the next save finishes,
+	// or empty.
+	merging string
+
+	// perm, when set, is the permissions the file and copies of it are
+	// written with; otherwise they get those of the file.
+	perm os.FileMode
 }
 
-func NewFileTaskStore(path string) TaskStore {
+// NewFileTaskStore returns a file store that keeps tasks in the JSON
+// file at path.
+func NewFileTaskStore(path string) *FileTaskStore {
 	return &FileTaskStore{path: path, name: DefaultName}
 }
 
+// NewFileTaskStoreWithBackups returns a file store that keeps backups of
+// the file as it was before each save, following policy.
+func NewFileTaskStoreWithBackups(path string, policy BackupPolicy) *FileTaskStore {
+	return &FileTaskStore{path: path, name: DefaultName, backups: policy}
+}
+
 func (fts *FileTaskStore) Name() string {
 	return fts.name
 }
 
+func (fts *FileTaskStore) Paths() []string {
+	return []string{fts.path}
+}
+
+func (fts *FileTaskStore) SetFilePerm(perm os.FileMode) {
+	fts.perm = perm
+}
+
 func (fts *FileTaskStore) Load() ([]task.Task, error) {
-	b, err := os.ReadFile(fts.path)
-	if errors.Is(err, os.ErrNotExist) {
-		return []task.Task{}, nil
+	fts.mu.Lock()
+	defer fts.mu.Unlock()
+
+	unlock, err := lockFile(fts.path + lockSuffix)
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
+
+	b, err := readIfExists(fts.path)
 	if err != nil {
 		return nil, err
 	}
+	fts.revision, fts.seen = sha256.Sum256(b), true
+	if b == nil {
+		return []task.Task{}, nil
+	}
+
+	tasks, version, err := parseTasksFile(b)
+	if errors.Is(err, ErrUnsupportedVersion) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fts.quarantine(b, err)
+	}
+	if version != CurrentVersion {
+		if err := fts.upgrade(b, version, tasks); err != nil {
+			return nil, err
+		}
+		if b, err = readIfExists(fts.path); err != nil {
+			return nil, err
+		}
+	}
+	return fts.mergeConflictCopies(b, tasks)
+}
+
+// parseTasksFile decodes the contents of a tasks file written in any
+// supported format version, which it also returns.
+func parseTasksFile(b []byte) ([]task.Task, int, error) {
+	version, raw, err := decodeFile(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version != CurrentVersion {
+		if raw, err = migrate(raw, version, CurrentVersion, migrations); err != nil {
+			return nil, 0, err
+		}
+	}
 
 	var tasks []task.Task
-	if err := json.Unmarshal(b, &tasks); err != nil {
+	if err := json.Unmarshal(raw, &tasks); err != nil {
+		return nil, 0, err
+	}
+	return tasks, version, nil
+}
+
+// upgrade backs up the original contents of a file written in an older
+// format, then rewrites it in the current one.
+func (fts *FileTaskStore) upgrade(original []byte, version int, tasks []task.Task) error {
+	backup := fmt.Sprintf("%s.v%d.bak", fts.path, version)
+	if _, err := os.Stat(backup); err == nil {
+		// Never overwrite an earlier backup.
+		backup = fmt.Sprintf("%s.v%d.%s.bak", fts.path, version, time.Now().Format("20060102T150405"))
+	}
+	if err := os.WriteFile(backup, original, writePerm(fts.path, fts.perm)); err != nil {
+		return fmt.Errorf("back up version %d tasks file: %w", version, err)
+	}
+	return fts.write(tasks)
+}
+
+// Save writes tasks to the file, unless another process changed it
+// since it was last loaded.
+func (fts *FileTaskStore) Save(tasks []task.Task) error {
+	fts.mu.Lock()
+	defer fts.mu.Unlock()
+
+	unlock, err := lockFile(fts.path + lockSuffix)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := readIfExists(fts.path)
+	if err != nil {
+		return err
+	}
+	if fts.seen && sha256.Sum256(current) != fts.revision {
+		return fmt.Errorf("%s: %w", fts.path, ErrStaleSave)
+	}
+	if err := fts.backup(current, false); err != nil {
+		return fmt.Errorf("back up tasks file: %w", err)
+	}
+	if err := fts.write(tasks); err != nil {
+		return err
+	}
+	if fts.merging != "" {
+		if err := retireConflictCopy(fts.merging); err != nil {
+			return err
+		}
+		fts.merging = ""
+	}
+	return nil
+}
+
+// write replaces the file with tasks. The caller holds the lock.
+func (fts *FileTaskStore) write(tasks []task.Task) error {
+	b, err := encodeTasks(tasks)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(fts.path, b, writePerm(fts.path, fts.perm)); err != nil {
+		return err
+	}
+
+	fts.revision, fts.seen = sha256.Sum256(b), true
+	return nil
+}
+
+// ReadTasksFile returns the tasks in the tasks file at path, which is
+// read as it is: unlike loading it through a store, nothing is locked,
+// upgraded, salvaged, or merged. An empty file holds no tasks.
+func ReadTasksFile(path string) ([]task.Task, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
 		return nil, err
 	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return []task.Task{}, nil
+	}
+	tasks, _, err := parseTasksFile(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
 	return tasks, nil
 }
 
-func (fts *FileTaskStore) Save(tasks []task.Task) error {
-	if err := os.MkdirAll(filepath.Dir(fts.path), 0o755); err != nil {
+// WriteTasksFile replaces the file at path with a tasks file holding
+// tasks.
+func WriteTasksFile(path string, tasks []task.Task) error {
+	b, err := encodeTasks(tasks)
+	if err != nil {
 		return err
 	}
+	return writeFileAtomic(path, b, filePerm(path))
+}
 
-	b, err := json.MarshalIndent(tasks, "", " ")
+// encodeTasks returns the contents of a tasks file holding tasks.
+func encodeTasks(tasks []task.Task) ([]byte, error) {
+	raw, err := json.Marshal(tasks)
 	if err != nil {
+		return nil, err
+	}
+	return encodeFile(raw)
+}
+
+// writeFileAtomic replaces the file at path with b through a temporary
+// file of its own, so that an interrupted write never leaves a partial
+// file behind. The file gets perm.
+func writeFileAtomic(path string, b []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
 
-	tmp := fts.path + ".tmp"
-	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
 		return err
 	}
-	return os.Rename(tmp, fts.path)
+	return os.Rename(tmp.Name(), path)
+}
+
+// filePerm returns the permissions of the file at path, or the default
+// ones for a new file when there is none.
+func filePerm(path string) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return 0o644
+}
+
+// writePerm returns perm when it is set, and the permissions filePerm
+// returns for path otherwise.
+func writePerm(path string, perm os.FileMode) os.FileMode {
+	if perm != 0 {
+		return perm
+	}
+	return filePerm(path)
+}
+
+// readIfExists returns the contents of the file at path, or nil when
+// there is no such file.
+func readIfExists(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
 }
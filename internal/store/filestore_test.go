@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -18,13 +19,7 @@ func newTempStore(t *testing.T, filename string) (*FileTaskStore, string) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, filename)
 
-	store := NewFileTaskStore(path)
-	fs, ok := store.(*FileTaskStore)
-	if !ok {
-		t.Fatalf("NewFileTaskStore did not return *FileTaskStore, got %T", store)
-	}
-
-	return fs, path
+	return NewFileTaskStore(path), path
 }
 
 // -----------------------------------------------------------------------------
@@ -32,16 +27,11 @@ func newTempStore(t *testing.T, filename string) (*FileTaskStore, string) {
 // -----------------------------------------------------------------------------
 
 func TestNewFileTaskStore(t *testing.T) {
-	store := NewFileTaskStore("test.json")
-	if store == nil {
+	fs := NewFileTaskStore("test.json")
+	if fs == nil {
 		t.Fatalf("NewFileTaskStore returned nil")
 	}
 
-	fs, ok := store.(*FileTaskStore)
-	if !ok {
-		t.Fatalf("NewFileTaskStore did not return *FileTaskStore, got %T", store)
-	}
-
 	if got, want := fs.Name(), DefaultName; got != want {
 		t.Fatalf("Name() = %q, want %q", got, want)
 	}
@@ -140,21 +130,64 @@ func TestFileTaskStore_Save_RenameError(t *testing.T) {
 	}
 }
 
-func TestFileTaskStore_Save_WriteFileError(t *testing.T) {
+func TestFileTaskStore_Save_OwnTempFile(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "tasks.json")
 
 	s := NewFileTaskStore(path)
 
-	// make dir insteaf of file to force save error
-	tmpPath := path + ".tmp"
-	if err := os.Mkdir(tmpPath, 0o755); err != nil {
+	// A directory at the old fixed temp path no longer gets in the way:
+	// each save writes to a temp file of its own.
+	if err := os.Mkdir(path+".tmp", 0o755); err != nil {
 		t.Fatalf("Mkdir(tmpPath) error = %v, want nil", err)
 	}
 
+	if err := s.Save([]task.Task{{TitleStr: "x"}}); err != nil {
+		t.Fatalf("Save() error = %v, want nil", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "tasks.json.*.tmp"))
+	if len(matches) != 0 {
+		t.Fatalf("temp files left behind: %v", matches)
+	}
+}
+
+func TestFileTaskStore_Save_KeepsPermissions(t *testing.T) {
+	store, path := newTempStore(t, "tasks.json")
+
+	if err := store.Save([]task.Task{{TitleStr: "x"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	if err := store.Save([]task.Task{{TitleStr: "y"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("permissions after Save() = %o, want 600", perm)
+	}
+}
+
+func TestFileTaskStore_Save_LockError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+
+	s := NewFileTaskStore(path)
+
+	// make dir instead of lock file to force lock error
+	if err := os.Mkdir(path+lockSuffix, 0o755); err != nil {
+		t.Fatalf("Mkdir(lockPath) error = %v, want nil", err)
+	}
+
 	err := s.Save([]task.Task{{TitleStr: "x"}})
 	if err == nil {
-		t.Fatalf("Save() error = nil, want non-nil when WriteFile fails")
+		t.Fatalf("Save() error = nil, want non-nil when locking fails")
 	}
 }
 
@@ -353,3 +386,113 @@ func TestFileTaskStore_Load_SaveRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func TestFileTaskStore_Load_SaveRoundTrip_Priority(t *testing.T) {
+	store, _ := newTempStore(t, "tasks.json")
+
+	original := []task.Task{
+		{TitleStr: "urgent", Priority: task.PriorityUrgent},
+		{TitleStr: "none"},
+	}
+
+	if err := store.Save(original); err != nil {
+		t.Fatalf("Save() error = %v, want nil", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	for i := range original {
+		if loaded[i].Priority != original[i].Priority {
+			t.Errorf("task %d Priority = %v, want %v", i, loaded[i].Priority, original[i].Priority)
+		}
+	}
+}
+
+func TestFileTaskStore_Load_SaveRoundTrip_List(t *testing.T) {
+	store, _ := newTempStore(t, "tasks.json")
+
+	original := []task.Task{
+		{TitleStr: "standup", List: "Work"},
+		{TitleStr: "groceries", List: "Personal"},
+		{TitleStr: "inbox"},
+	}
+
+	if err := store.Save(original); err != nil {
+		t.Fatalf("Save() error = %v, want nil", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	for i := range original {
+		if loaded[i].List != original[i].List {
+			t.Errorf("task %d List = %q, want %q", i, loaded[i].List, original[i].List)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Concurrent sessions
+// -----------------------------------------------------------------------------
+
+func TestFileTaskStore_Save_RefusesStaleData(t *testing.T) {
+	first, path := newTempStore(t, "tasks.json")
+	second := NewFileTaskStore(path)
+
+	if err := first.Save([]task.Task{{TitleStr: "shared"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := second.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// The first session saves again; the second one's copy is now stale.
+	if err := first.Save([]task.Task{{TitleStr: "shared"}, {TitleStr: "from first"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	err := second.Save([]task.Task{{TitleStr: "from second"}})
+	if !errors.Is(err, ErrStaleSave) {
+		t.Fatalf("stale Save() error = %v, want ErrStaleSave", err)
+	}
+
+	loaded, err := second.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("Load() = %+v, want the first session's tasks kept", loaded)
+	}
+
+	// After reloading, the second session can save again.
+	if err := second.Save(append(loaded, task.Task{TitleStr: "from second"})); err != nil {
+		t.Fatalf("Save() after reload error = %v", err)
+	}
+}
+
+func TestFileTaskStore_Save_WaitsForLock(t *testing.T) {
+	store, path := newTempStore(t, "tasks.json")
+
+	unlock, err := lockFile(path + lockSuffix)
+	if err != nil {
+		t.Fatalf("lockFile() error = %v", err)
+	}
+
+	saved := make(chan error, 1)
+	go func() { saved <- store.Save([]task.Task{{TitleStr: "x"}}) }()
+
+	select {
+	case err := <-saved:
+		t.Fatalf("Save() returned %v while the file was locked", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	if err := <-saved; err != nil {
+		t.Fatalf("Save() error = %v after unlock", err)
+	}
+}
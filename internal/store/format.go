@@ -0,0 +1,115 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Format versions of the tasks file.
+const (
+	// LegacyVersion is the original format: a bare JSON array of tasks
+	// with no version marker.
+	LegacyVersion = 1
+
+	// CurrentVersion is the format written by Save: the tasks wrapped in
+	// an envelope that records the version.
+	CurrentVersion = 2
+)
+
+// ErrUnsupportedVersion is returned when the tasks file was written by a
+// newer version of terminaltask, or has no migration path to the current
+// format.
+var ErrUnsupportedVersion = errors.New("unsupported tasks file version")
+
+// Record is a single task as stored on disk, before it is decoded into a
+// task.Task. Migrations work on records so they can rename, reshape, or
+// drop keys the current Task type no longer knows about.
+type Record = map[string]any
+
+// Migration upgrades the task records of a file by exactly one format
+// version.
+type Migration func(records []Record) ([]Record, error)
+
+// migrations holds the registered steps, keyed by the version they
+// upgrade from.
+var migrations = map[int]Migration{}
+
+// RegisterMigration registers the step that upgrades files from version
+// from to version from+1. Registering two steps for the same version is
+// a programming error and panics.
+func RegisterMigration(from int, m Migration) {
+	if _, ok := migrations[from]; ok {
+		panic(fmt.Sprintf("store: migration from version %d registered twice", from))
+	}
+	migrations[from] = m
+}
+
+func init() {
+	// Version 2 only introduced the envelope; the records are unchanged.
+	RegisterMigration(LegacyVersion, func(records []Record) ([]Record, error) {
+		return records, nil
+	})
+}
+
+// envelope is the on-disk layout of the tasks file since version 2.
+type envelope struct {
+	Version int             `json:"version"`
+	Tasks   json.RawMessage `json:"tasks"`
+}
+
+// decodeFile splits the contents of a tasks file into its format version
+// and the raw JSON array of tasks. Files that are a bare array are the
+// legacy format.
+func decodeFile(b []byte) (int, json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return LegacyVersion, trimmed, nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(trimmed, &env); err != nil {
+		return 0, nil, err
+	}
+	if env.Version < LegacyVersion {
+		return 0, nil, fmt.Errorf("%w: missing version", ErrUnsupportedVersion)
+	}
+	if len(env.Tasks) == 0 {
+		env.Tasks = json.RawMessage("[]")
+	}
+	return env.Version, env.Tasks, nil
+}
+
+// encodeFile wraps the JSON array of tasks in an envelope of the current
+// version.
+func encodeFile(tasks json.RawMessage) ([]byte, error) {
+	return json.MarshalIndent(envelope{Version: CurrentVersion, Tasks: tasks}, "", " ")
+}
+
+// migrate upgrades raw task records from version from to version to,
+// running each registered step in turn.
+func migrate(raw json.RawMessage, from, to int, steps map[int]Migration) (json.RawMessage, error) {
+	if from > to {
+		return nil, fmt.Errorf("%w: file is version %d, newest supported is %d", ErrUnsupportedVersion, from, to)
+	}
+	if from == to {
+		return raw, nil
+	}
+
+	var records []Record
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, err
+	}
+	for v := from; v < to; v++ {
+		step, ok := steps[v]
+		if !ok {
+			return nil, fmt.Errorf("%w: no migration from version %d", ErrUnsupportedVersion, v)
+		}
+		var err error
+		if records, err = step(records); err != nil {
+			return nil, fmt.Errorf("migrate from version %d: %w", v, err)
+		}
+	}
+	return json.Marshal(records)
+}
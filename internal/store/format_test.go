@@ -0,0 +1,163 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// -----------------------------------------------------------------------------
+// Versioned format
+// -----------------------------------------------------------------------------
+
+func TestFileTaskStore_Save_WritesEnvelope(t *testing.T) {
+	store, path := newTempStore(t, "tasks.json")
+
+	if err := store.Save([]task.Task{{TitleStr: "a"}}); err != nil {
+		t.Fatalf("Save() error = %v, want nil", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var env envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		t.Fatalf("saved file is not an envelope: %v\n%s", err, b)
+	}
+	if env.Version != CurrentVersion {
+		t.Fatalf("version = %d, want %d", env.Version, CurrentVersion)
+	}
+}
+
+func TestFileTaskStore_Load_UpgradesLegacyFile(t *testing.T) {
+	store, path := newTempStore(t, "tasks.json")
+
+	const legacy = `[{"TitleStr": "Old task", "DescStr": "", "DueDate": "2026-01-31T00:00:00Z", "Done": false}]`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+	if len(got) != 1 || got[0].TitleStr != "Old task" {
+		t.Fatalf("Load() = %+v, want the legacy task", got)
+	}
+
+	backup, err := os.ReadFile(path + ".v1.bak")
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if string(backup) != legacy {
+		t.Fatalf("backup = %q, want the original file", backup)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	version, _, err := decodeFile(b)
+	if err != nil || version != CurrentVersion {
+		t.Fatalf("upgraded file version = %d, %v; want %d", version, err, CurrentVersion)
+	}
+
+	// A second load finds nothing to upgrade and leaves the backup alone.
+	if _, err := store.Load(); err != nil {
+		t.Fatalf("second Load() error = %v", err)
+	}
+	backups, _ := filepath.Glob(path + ".v*.bak")
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want exactly one", backups)
+	}
+}
+
+func TestFileTaskStore_Load_UpgradeBackupKeepsPermissions(t *testing.T) {
+	store, path := newTempStore(t, "tasks.json")
+
+	const legacy = `[{"TitleStr": "Private", "DescStr": "", "Done": false}]`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := store.Load(); err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	info, err := os.Stat(path + ".v1.bak")
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("backup permissions = %o, want those of the tasks file, 600", perm)
+	}
+}
+
+func TestFileTaskStore_Load_NewerVersion(t *testing.T) {
+	store, path := newTempStore(t, "tasks.json")
+
+	if err := os.WriteFile(path, []byte(`{"version": 99, "tasks": []}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tasks, err := store.Load()
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("Load() error = %v, want ErrUnsupportedVersion", err)
+	}
+	if tasks != nil {
+		t.Fatalf("Load() tasks = %v, want nil", tasks)
+	}
+	if _, err := os.Stat(path + ".v99.bak"); !os.IsNotExist(err) {
+		t.Fatalf("newer file must not be backed up or rewritten")
+	}
+}
+
+func TestMigrate_RunsStepsInOrder(t *testing.T) {
+	steps := map[int]Migration{
+		1: func(records []Record) ([]Record, error) {
+			for _, r := range records {
+				r["Name"] = r["TitleStr"]
+				delete(r, "TitleStr")
+			}
+			return records, nil
+		},
+		2: func(records []Record) ([]Record, error) {
+			for _, r := range records {
+				r["TitleStr"] = "migrated " + r["Name"].(string)
+				delete(r, "Name")
+			}
+			return records, nil
+		},
+	}
+
+	raw, err := migrate(json.RawMessage(`[{"TitleStr": "a"}]`), 1, 3, steps)
+	if err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+
+	var tasks []task.Task
+	if err := json.Unmarshal(raw, &tasks); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].TitleStr != "migrated a" {
+		t.Fatalf("migrate() = %s, want title \"migrated a\"", raw)
+	}
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	failing := errors.New("boom")
+	steps := map[int]Migration{
+		1: func([]Record) ([]Record, error) { return nil, failing },
+	}
+
+	if _, err := migrate(json.RawMessage(`[]`), 1, 2, steps); !errors.Is(err, failing) {
+		t.Errorf("failing step: error = %v, want %v", err, failing)
+	}
+	if _, err := migrate(json.RawMessage(`[]`), 2, 3, steps); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("missing step: error = %v, want ErrUnsupportedVersion", err)
+	}
+}
@@ -0,0 +1,329 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	DefaultGitName = "Git Store"
+
+	// gitTasksFile is the name of the tasks file in the repository.
+	gitTasksFile = "tasks.json"
+
+	// gitShortHash is the length of abbreviated commit hashes.
+	gitShortHash = 7
+)
+
+// gitFallbackIdentity commits as terminaltask when git has no user
+// configured, so that saving never fails for want of one.
+var gitFallbackIdentity = []string{"-c", "user.name=terminaltask", "-c", "user.email=terminaltask@localhost"}
+
+// ErrTaskNotInRevision is returned when restoring a task from a commit
+// that does not have it.
+var ErrTaskNotInRevision = errors.New("task not found in revision")
+
+// Revision is a commit that changed the tasks file.
+type Revision struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Message string
+}
+
+// Short returns the abbreviated hash of the revision.
+func (r Revision) Short() string {
+	return r.Hash[:min(gitShortHash, len(r.Hash))]
+}
+
+// GitTaskStore keeps tasks in a JSON file in a git repository and commits
+// every save, with a message saying what changed, such as
+// "Completed: Deploy v2". Earlier versions of the whole list or of single
+// tasks can be restored from any commit, and the repository can be
+// pushed to and pulled from a remote with git itself.
+type GitTaskStore struct {
+	dir  string
+	file *FileTaskStore
+	name string
+
+	// mu serializes saves, so that each commit holds its own change.
+	mu sync.Mutex
+}
+
+// NewGitTaskStore returns a store that keeps tasks in the git repository
+// in dir, creating the directory and the repository when needed.
+func NewGitTaskStore(dir string) (*GitTaskStore, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("the git store needs git installed: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &GitTaskStore{
+		dir:  dir,
+		file: &FileTaskStore{path: filepath.Join(dir, gitTasksFile), name: DefaultName},
+		name: DefaultGitName,
+	}
+	// Only a repository of its own will do; dir may well sit inside
+	// some other one.
+	if !fileExists(filepath.Join(dir, ".git")) {
+		if _, err := s.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *GitTaskStore) Name() string {
+	return s.name
+}
+
+func (s *GitTaskStore) Paths() []string {
+	return s.file.Paths()
+}
+
+func (s *GitTaskStore) SetFilePerm(perm os.FileMode) {
+	s.file.SetFilePerm(perm)
+}
+
+// Dir returns the directory of the repository.
+func (s *GitTaskStore) Dir() string {
+	return s.dir
+}
+
+func (s *GitTaskStore) Load() ([]task.Task, error) {
+	return s.file.Load()
+}
+
+// Save writes tasks to the tasks file and commits it, describing what
+// changed since the last commit. Saving unchanged tasks commits nothing.
+func (s *GitTaskStore) Save(tasks []task.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, err := s.committed()
+	if err != nil {
+		return err
+	}
+	return s.commit(tasks, describeChanges(prev, tasks))
+}
+
+// History returns the commits that changed the tasks file, newest
+// first.
+func (s *GitTaskStore) History() ([]Revision, error) {
+	if !s.hasCommits() {
+		return nil, nil
+	}
+	out, err := s.git("log", "--format=%H%x1f%an%x1f%aI%x1f%s", "--", gitTasksFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var revs []Revision
+	for line := range strings.Lines(string(out)) {
+		fields := strings.Split(strings.TrimRight(line, "\n"), "\x1f")
+		if len(fields) != 4 { //nolint:mnd
+			continue
+		}
+		at, _ := time.Parse(time.RFC3339, fields[2])
+		revs = append(revs, Revision{Hash: fields[0], Author: fields[1], Time: at, Message: fields[3]})
+	}
+	return revs, nil
+}
+
+// TasksAt returns the tasks as they were at the given revision, which
+// can be anything git accepts, such as a hash or HEAD~2.
+func (s *GitTaskStore) TasksAt(rev string) ([]task.Task, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid revision %q", rev)
+	}
+	out, err := s.git("show", rev+":"+gitTasksFile)
+	if err != nil {
+		return nil, err
+	}
+	tasks, _, err := parseTasksFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("read tasks at %s: %w", rev, err)
+	}
+	return tasks, nil
+}
+
+// Restore replaces all tasks with those at the given revision and
+// commits the result. It returns the number of tasks restored.
+func (s *GitTaskStore) Restore(rev string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks, err := s.TasksAt(rev)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.file.Load(); err != nil {
+		return 0, err
+	}
+	short, err := s.shortHash(rev)
+	if err != nil {
+		return 0, err
+	}
+	return len(tasks), s.commit(tasks, fmt.Sprintf("Restored %d tasks from %s", len(tasks), short))
+}
+
+// RestoreTask puts the task with the given ID back as it was at the
+// given revision, leaving the other tasks alone, and commits the result.
+// A task deleted since then is added back at the end.
+func (s *GitTaskStore) RestoreTask(rev string, id uuid.UUID) (task.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.TasksAt(rev)
+	if err != nil {
+		return task.Task{}, err
+	}
+	i := indexByID(old, id)
+	if i < 0 {
+		return task.Task{}, fmt.Errorf("%w: %s at %s", ErrTaskNotInRevision, id, rev)
+	}
+	restored := old[i]
+
+	tasks, err := s.file.Load()
+	if err != nil {
+		return task.Task{}, err
+	}
+	if j := indexByID(tasks, id); j >= 0 {
+		tasks[j] = restored
+	} else {
+		tasks = append(tasks, restored)
+	}
+	short, err := s.shortHash(rev)
+	if err != nil {
+		return task.Task{}, err
+	}
+	return restored, s.commit(tasks, fmt.Sprintf("Restored: %s from %s", restored.Title(), short))
+}
+
+// commit saves tasks and commits the tasks file with message, whose
+// first line is the subject. The caller holds mu.
+func (s *GitTaskStore) commit(tasks []task.Task, message string) error {
+	if err := s.file.Save(tasks); err != nil {
+		return err
+	}
+	if _, err := s.git("add", "--", gitTasksFile); err != nil {
+		return err
+	}
+	// Nothing staged means nothing changed.
+	if _, err := s.git("diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	args := []string{"commit", "--quiet", "--no-verify", "-F", "-"}
+	if _, err := s.git("config", "user.email"); err != nil {
+		args = append(slices.Clone(gitFallbackIdentity), args...)
+	}
+	_, err := s.gitInput([]byte(message), args...)
+	return err
+}
+
+// committed returns the tasks in the last commit, or none before the
+// tasks file was first committed.
+func (s *GitTaskStore) committed() ([]task.Task, error) {
+	if _, err := s.git("cat-file", "-e", "HEAD:"+gitTasksFile); err != nil {
+		return nil, nil
+	}
+	return s.TasksAt("HEAD")
+}
+
+func (s *GitTaskStore) hasCommits() bool {
+	_, err := s.git("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
+
+func (s *GitTaskStore) shortHash(rev string) (string, error) {
+	out, err := s.git("rev-parse", fmt.Sprintf("--short=%d", gitShortHash), "--verify", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// git runs git in the repository and returns what it printed.
+func (s *GitTaskStore) git(args ...string) ([]byte, error) {
+	return s.gitInput(nil, args...)
+}
+
+// gitInput runs git in the repository with input on its standard input.
+func (s *GitTaskStore) gitInput(input []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", s.dir}, args...)...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// describeChanges writes a commit message for the change from prev to
+// next: a subject naming the first change, such as "Completed: Deploy
+// v2", and, when there is more than one, a body listing them all.
+func describeChanges(prev, next []task.Task) string {
+	ops, ok := diffTasks(prev, next, time.Time{})
+	if !ok {
+		return "Updated tasks"
+	}
+
+	titles := make(map[uuid.UUID]string, len(prev))
+	for _, t := range prev {
+		titles[t.ID] = t.Title()
+	}
+
+	var changes []string
+	for _, op := range ops {
+		switch op.Kind {
+		case OpCreate:
+			changes = append(changes, "Added: "+op.Task.Title())
+		case OpUpdate:
+			changes = append(changes, "Edited: "+op.Task.Title())
+		case OpToggle:
+			if op.Task.Done {
+				changes = append(changes, "Completed: "+op.Task.Title())
+			} else {
+				changes = append(changes, "Reopened: "+op.Task.Title())
+			}
+		case OpDelete:
+			changes = append(changes, "Deleted: "+titles[op.ID])
+		case OpReorder:
+			changes = append(changes, "Reordered tasks")
+		}
+	}
+
+	switch len(changes) {
+	case 0:
+		return "Saved tasks"
+	case 1:
+		return changes[0]
+	default:
+		more := "change"
+		if len(changes) > 2 { //nolint:mnd
+			more += "s"
+		}
+		return fmt.Sprintf("%s and %d more %s\n\n%s\n",
+			changes[0], len(changes)-1, more, strings.Join(changes, "\n"))
+	}
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// newTempGitStore returns a GitTaskStore in a temporary repository. Git
+// is kept from reading the user's configuration, so commits are made
+// with the fallback identity.
+func newTempGitStore(t *testing.T) *GitTaskStore {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	s, err := NewGitTaskStore(filepath.Join(t.TempDir(), "repo"))
+	if err != nil {
+		t.Fatalf("NewGitTaskStore() error = %v", err)
+	}
+	return s
+}
+
+// messages returns the subjects of the store's commits, newest first.
+func messages(t *testing.T, s *GitTaskStore) []string {
+	t.Helper()
+
+	revs, err := s.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	subjects := make([]string, len(revs))
+	for i, r := range revs {
+		subjects[i] = r.Message
+	}
+	return subjects
+}
+
+func TestGitTaskStore_CommitsEachSave(t *testing.T) {
+	s := newTempGitStore(t)
+
+	if got, want := s.Name(), DefaultGitName; got != want {
+		t.Fatalf("Name() = %q, want %q", got, want)
+	}
+	if revs, err := s.History(); err != nil || len(revs) != 0 {
+		t.Fatalf("History() = %v, %v; want no commits", revs, err)
+	}
+
+	deploy := task.Task{ID: uuid.New(), TitleStr: "Deploy v2"}
+	if err := s.Save([]task.Task{deploy}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	deploy.Done = true
+	deploy.CompletedAt = time.Now()
+	if err := s.Save([]task.Task{deploy}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Saving the same tasks again commits nothing.
+	if err := s.Save([]task.Task{deploy}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	notes := task.Task{ID: uuid.New(), TitleStr: "Write notes"}
+	retro := task.Task{ID: uuid.New(), TitleStr: "Retro"}
+	if err := s.Save([]task.Task{notes, retro}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	want := []string{
+		"Added: Write notes and 2 more changes",
+		"Completed: Deploy v2",
+		"Added: Deploy v2",
+	}
+	if got := messages(t, s); !slices.Equal(got, want) {
+		t.Fatalf("History() messages = %q, want %q", got, want)
+	}
+
+	out, err := s.git("log", "-1", "--format=%b")
+	if err != nil {
+		t.Fatalf("git log error = %v", err)
+	}
+	for _, line := range []string{"Added: Retro", "Deleted: Deploy v2"} {
+		if !strings.Contains(string(out), line) {
+			t.Fatalf("commit body %q does not mention %q", out, line)
+		}
+	}
+}
+
+func TestGitTaskStore_Restore(t *testing.T) {
+	s := newTempGitStore(t)
+
+	first := task.Task{ID: uuid.New(), TitleStr: "first"}
+	second := task.Task{ID: uuid.New(), TitleStr: "second"}
+	if err := s.Save([]task.Task{first, second}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	revs, err := s.History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	original := revs[0]
+
+	renamed := first
+	renamed.TitleStr = "renamed"
+	if err := s.Save([]task.Task{renamed}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	old, err := s.TasksAt(original.Hash)
+	if err != nil {
+		t.Fatalf("TasksAt() error = %v", err)
+	}
+	assertSameTasks(t, old, []task.Task{first, second})
+
+	// Restoring one task leaves the others as they are.
+	restored, err := s.RestoreTask(original.Short(), second.ID)
+	if err != nil {
+		t.Fatalf("RestoreTask() error = %v", err)
+	}
+	if restored.Title() != "second" {
+		t.Fatalf("RestoreTask() = %+v, want the second task", restored)
+	}
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, tasks, []task.Task{renamed, second})
+
+	// Restoring the list brings back everything.
+	n, err := s.Restore(original.Hash)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("Restore() = %d, want 2", n)
+	}
+	if tasks, err = s.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, tasks, []task.Task{first, second})
+
+	got := messages(t, s)
+	if want := "Restored 2 tasks from " + original.Short(); got[0] != want {
+		t.Fatalf("latest commit = %q, want %q", got[0], want)
+	}
+	if want := "Restored: second from " + original.Short(); got[1] != want {
+		t.Fatalf("second latest commit = %q, want %q", got[1], want)
+	}
+
+	if _, err := s.RestoreTask(original.Hash, uuid.New()); !errors.Is(err, ErrTaskNotInRevision) {
+		t.Fatalf("RestoreTask() error = %v, want ErrTaskNotInRevision", err)
+	}
+	if _, err := s.TasksAt("--output=/tmp/x"); err == nil {
+		t.Fatal("TasksAt() accepted an option as revision")
+	}
+}
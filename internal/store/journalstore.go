@@ -0,0 +1,619 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	DefaultJournalName = "Journal Store"
+
+	// journalSuffix is appended to the snapshot path to name the journal.
+	journalSuffix = ".journal"
+
+	// DefaultCompactEvery is the number of journal entries after which
+	// Save folds the journal into a new snapshot.
+	DefaultCompactEvery = 500
+)
+
+// OpKind names the change recorded by a journal Operation.
+type OpKind string
+
+const (
+	OpCreate  OpKind = "create"
+	OpUpdate  OpKind = "update"
+	OpToggle  OpKind = "toggle"
+	OpDelete  OpKind = "delete"
+	OpReorder OpKind = "reorder"
+)
+
+// Operation is one entry of the journal. Create, update, and toggle
+// carry the full task; delete carries its ID; reorder carries the IDs
+// of every task in their new order. Replaying an operation twice has
+// the same effect as replaying it once.
+type Operation struct {
+	Kind  OpKind      `json:"op"`
+	At    time.Time   `json:"at"`
+	ID    uuid.UUID   `json:"id,omitzero"`
+	Index int         `json:"index,omitempty"`
+	Task  *task.Task  `json:"task,omitempty"`
+	Order []uuid.UUID `json:"order,omitempty"`
+}
+
+// JournalTaskStore records every change to the tasks as an operation
+// appended to a journal, instead of rewriting all tasks on each Save.
+// The current tasks are rebuilt by replaying the journal on top of the
+// last snapshot, which is a tasks file in the FileTaskStore format.
+// Every so often, and on Compact, the journal is folded into a new
+// snapshot and emptied.
+//
+// Every operation holds an advisory lock on a lock file next to the
+// journal, and first replays what other processes appended since the
+// files were last read, so that several processes can share the store.
+type JournalTaskStore struct {
+	mu sync.Mutex
+
+	snapshot     *FileTaskStore
+	journalPath  string
+	name         string
+	compactEvery int
+	now          func() time.Time
+
+	// tasks is the current state, or nil until the journal has been
+	// replayed. entries counts the operations in the journal.
+	tasks   []task.Task
+	entries int
+
+	// snapshotInfo and journalInfo describe the files as last read or
+	// written by this store, or are nil when there were none; read is
+	// the length of the journal replayed into tasks.
+	snapshotInfo os.FileInfo
+	journalInfo  os.FileInfo
+	read         int64
+}
+
+// NewJournalTaskStore returns a store that keeps its snapshot at path
+// and its journal next to it, at path + ".journal".
+func NewJournalTaskStore(path string) *JournalTaskStore {
+	return &JournalTaskStore{
+		snapshot:     &FileTaskStore{path: path, name: DefaultName},
+		journalPath:  path + journalSuffix,
+		name:         DefaultJournalName,
+		compactEvery: DefaultCompactEvery,
+		now:          time.Now,
+	}
+}
+
+func (s *JournalTaskStore) Name() string {
+	return s.name
+}
+
+func (s *JournalTaskStore) Paths() []string {
+	return []string{s.snapshot.path, s.journalPath}
+}
+
+func (s *JournalTaskStore) SetFilePerm(perm os.FileMode) {
+	s.snapshot.SetFilePerm(perm)
+}
+
+func (s *JournalTaskStore) Load() ([]task.Task, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := s.catchUp(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(s.tasks), nil
+}
+
+// Save appends the operations that turn the tasks as last loaded into
+// tasks, after those other processes appended meanwhile, so their
+// changes to other tasks are kept. When the tasks cannot be told apart
+// by ID, it writes a snapshot instead, unless other processes changed
+// the tasks since they were last loaded.
+func (s *JournalTaskStore) Save(tasks []task.Task) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.tasks == nil {
+		if err := s.replay(); err != nil {
+			return err
+		}
+	}
+
+	ops, ok := diffTasks(s.tasks, tasks, s.now())
+	changed, err := s.catchUp()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if changed {
+			return fmt.Errorf("%s: %w", s.journalPath, ErrStaleSave)
+		}
+		return s.compact(tasks)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	if err := s.appendOps(ops); err != nil {
+		return err
+	}
+
+	if changed {
+		for _, op := range ops {
+			s.tasks = applyOp(s.tasks, op)
+		}
+	} else {
+		s.tasks = slices.Clone(tasks)
+	}
+	s.entries += len(ops)
+	if s.entries >= s.compactEvery {
+		return s.compact(s.tasks)
+	}
+	return nil
+}
+
+func (s *JournalTaskStore) Get(id uuid.UUID) (task.Task, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return task.Task{}, err
+	}
+	defer unlock()
+
+	if _, err := s.catchUp(); err != nil {
+		return task.Task{}, err
+	}
+	if i := indexByID(s.tasks, id); i >= 0 {
+		return s.tasks[i], nil
+	}
+	return task.Task{}, fmt.Errorf("task %s: %w", id, ErrTaskNotFound)
+}
+
+// Put appends a single operation creating or changing t, without
+// comparing the other tasks.
+func (s *JournalTaskStore) Put(t task.Task) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := s.catchUp(); err != nil {
+		return err
+	}
+	if t.ID == uuid.Nil {
+		// Operations cannot refer to the task.
+		return s.compact(append(slices.Clone(s.tasks), t))
+	}
+
+	op := Operation{Kind: OpCreate, At: s.now(), ID: t.ID, Index: len(s.tasks), Task: &t}
+	if i := indexByID(s.tasks, t.ID); i >= 0 {
+		if sameTask(s.tasks[i], t) {
+			return nil
+		}
+		op.Kind, op.Index = OpUpdate, 0
+		if onlyToggled(s.tasks[i], t) {
+			op.Kind = OpToggle
+		}
+	}
+	return s.record(op)
+}
+
+// Delete appends a single operation deleting the task with the given ID.
+func (s *JournalTaskStore) Delete(id uuid.UUID) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := s.catchUp(); err != nil {
+		return err
+	}
+	if indexByID(s.tasks, id) < 0 {
+		return nil
+	}
+	return s.record(Operation{Kind: OpDelete, At: s.now(), ID: id})
+}
+
+// record appends op to the journal and applies it to the current tasks.
+func (s *JournalTaskStore) record(op Operation) error {
+	if err := s.appendOps([]Operation{op}); err != nil {
+		return err
+	}
+	s.tasks = applyOp(s.tasks, op)
+	s.entries++
+	if s.entries >= s.compactEvery {
+		return s.compact(s.tasks)
+	}
+	return nil
+}
+
+// Compact writes the current tasks as a new snapshot and empties the
+// journal.
+func (s *JournalTaskStore) Compact() error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := s.catchUp(); err != nil {
+		return err
+	}
+	if s.entries == 0 {
+		return nil
+	}
+	return s.compact(s.tasks)
+}
+
+// Close folds the journal into the snapshot, so the tasks file is
+// readable by the file store once the program exits.
+func (s *JournalTaskStore) Close() error {
+	return s.Compact()
+}
+
+// Operations returns every operation recorded since the last snapshot,
+// oldest first.
+func (s *JournalTaskStore) Operations() ([]Operation, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	ops, _, _, err := s.readJournal(0)
+	return ops, err
+}
+
+// lock takes mu and the lock shared with other processes, and returns a
+// function that releases both.
+func (s *JournalTaskStore) lock() (unlock func(), err error) {
+	s.mu.Lock()
+	unlockFile, err := lockFile(s.journalPath + lockSuffix)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	return func() {
+		unlockFile()
+		s.mu.Unlock()
+	}, nil
+}
+
+// compact replaces the snapshot with tasks and empties the journal. The
+// snapshot is written first, so a crash in between only leaves
+// operations that replay to the same tasks.
+func (s *JournalTaskStore) compact(tasks []task.Task) error {
+	if err := s.snapshot.Save(tasks); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Remove(s.journalPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("empty journal: %w", err)
+	}
+	s.tasks = slices.Clone(tasks)
+	s.entries = 0
+	return s.noteFiles(0)
+}
+
+// catchUp brings the current tasks up to date with the files, which
+// other processes may have changed since this store last read them:
+// entries appended to the journal are replayed on top of the tasks,
+// and anything else, such as a new snapshot, means replaying it all.
+// It reports whether the tasks changed. The caller holds the lock.
+func (s *JournalTaskStore) catchUp() (bool, error) {
+	if s.tasks == nil {
+		return true, s.replay()
+	}
+	snapshot, err := statIfExists(s.snapshot.path)
+	if err != nil {
+		return false, err
+	}
+	journal, err := statIfExists(s.journalPath)
+	if err != nil {
+		return false, err
+	}
+	if !sameFileState(snapshot, s.snapshotInfo) || !journalGrew(journal, s.journalInfo, s.read) {
+		return true, s.replay()
+	}
+	if journal == nil || journal.Size() == s.read {
+		return false, nil
+	}
+
+	ops, end, torn, err := s.readJournal(s.read)
+	if err != nil {
+		return false, err
+	}
+	if torn {
+		if err := os.Truncate(s.journalPath, end); err != nil {
+			return false, fmt.Errorf("repair journal: %w", err)
+		}
+	}
+	for _, op := range ops {
+		s.tasks = applyOp(s.tasks, op)
+	}
+	s.entries += len(ops)
+	return len(ops) > 0, s.noteFiles(end)
+}
+
+// replay rebuilds the current tasks from the snapshot and the journal.
+func (s *JournalTaskStore) replay() error {
+	tasks, err := s.snapshot.Load()
+	if err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+
+	ops, end, torn, err := s.readJournal(0)
+	if err != nil {
+		return err
+	}
+	if torn {
+		// Drop a torn final entry left by an interrupted append, so the
+		// next append starts on a fresh line.
+		if err := os.Truncate(s.journalPath, end); err != nil {
+			return fmt.Errorf("repair journal: %w", err)
+		}
+	}
+
+	for _, op := range ops {
+		tasks = applyOp(tasks, op)
+	}
+	if tasks == nil {
+		tasks = []task.Task{}
+	}
+	s.tasks = tasks
+	s.entries = len(ops)
+	return s.noteFiles(end)
+}
+
+// noteFiles records the state of the files after they were read or
+// written, with the first read bytes of the journal in the tasks.
+func (s *JournalTaskStore) noteFiles(read int64) error {
+	var err error
+	if s.snapshotInfo, err = statIfExists(s.snapshot.path); err != nil {
+		return err
+	}
+	if s.journalInfo, err = statIfExists(s.journalPath); err != nil {
+		return err
+	}
+	s.read = read
+	return nil
+}
+
+// readJournal parses the journal from offset on, returning where the
+// last complete entry ends. An incomplete final line, as left by an
+// append that was cut short, is ignored and reported as torn, so it
+// can be cut off.
+func (s *JournalTaskStore) readJournal(offset int64) (ops []Operation, end int64, torn bool, err error) {
+	f, err := os.Open(s.journalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, false, err
+	}
+
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return ops, offset, len(b) > 0, nil
+		}
+		if err != nil {
+			return nil, 0, false, err
+		}
+		offset += int64(len(b))
+
+		b = bytes.TrimSpace(b)
+		if len(b) == 0 {
+			continue
+		}
+		var op Operation
+		if err := json.Unmarshal(b, &op); err != nil {
+			return nil, 0, false, fmt.Errorf("journal %s line %d: %w", s.journalPath, line, err)
+		}
+		ops = append(ops, op)
+	}
+}
+
+// statIfExists returns information about the file at path, or nil when
+// there is no such file.
+func statIfExists(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return info, err
+}
+
+// sameFileState reports whether a and b describe the same, unchanged
+// file, or both no file.
+func sameFileState(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// journalGrew reports whether the journal described by now holds the
+// first read bytes of the one described by before, and perhaps more,
+// rather than having been replaced or emptied.
+func journalGrew(now, before os.FileInfo, read int64) bool {
+	switch {
+	case now == nil:
+		return before == nil
+	case before == nil:
+		return read == 0
+	}
+	return os.SameFile(now, before) && now.Size() >= read
+}
+
+// appendOps writes ops to the end of the journal in a single write and
+// syncs it to disk. The caller holds the lock and has caught up with
+// the journal.
+func (s *JournalTaskStore) appendOps(ops []Operation) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, op := range ops {
+		if err := enc.Encode(op); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(s.journalPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, writePerm(s.snapshot.path, s.snapshot.perm))
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.noteFiles(s.read + int64(buf.Len()))
+}
+
+// diffTasks returns the operations that turn prev into next. It reports
+// false when either list has tasks without a unique ID, which
+// operations cannot refer to.
+func diffTasks(prev, next []task.Task, at time.Time) ([]Operation, bool) {
+	if !uniqueIDs(prev) || !uniqueIDs(next) {
+		return nil, false
+	}
+
+	prevByID := make(map[uuid.UUID]task.Task, len(prev))
+	for _, t := range prev {
+		prevByID[t.ID] = t
+	}
+	nextIDs := make(map[uuid.UUID]bool, len(next))
+
+	var ops []Operation
+	for i, t := range next {
+		nextIDs[t.ID] = true
+		old, ok := prevByID[t.ID]
+		switch {
+		case !ok:
+			ops = append(ops, Operation{Kind: OpCreate, At: at, ID: t.ID, Index: i, Task: &t})
+		case !sameTask(old, t):
+			kind := OpUpdate
+			if onlyToggled(old, t) {
+				kind = OpToggle
+			}
+			ops = append(ops, Operation{Kind: kind, At: at, ID: t.ID, Task: &t})
+		}
+	}
+	for _, t := range prev {
+		if !nextIDs[t.ID] {
+			ops = append(ops, Operation{Kind: OpDelete, At: at, ID: t.ID})
+		}
+	}
+
+	// Record the order too when the changes alone do not produce it.
+	result := slices.Clone(prev)
+	for _, op := range ops {
+		result = applyOp(result, op)
+	}
+	if !slices.Equal(taskIDs(result), taskIDs(next)) {
+		ops = append(ops, Operation{Kind: OpReorder, At: at, Order: taskIDs(next)})
+	}
+	return ops, true
+}
+
+// applyOp returns tasks with op applied.
+func applyOp(tasks []task.Task, op Operation) []task.Task {
+	switch op.Kind {
+	case OpCreate, OpUpdate, OpToggle:
+		if op.Task == nil {
+			return tasks
+		}
+		if i := indexByID(tasks, op.Task.ID); i >= 0 {
+			tasks[i] = *op.Task
+			return tasks
+		}
+		return slices.Insert(tasks, min(max(op.Index, 0), len(tasks)), *op.Task)
+
+	case OpDelete:
+		return slices.DeleteFunc(tasks, func(t task.Task) bool { return t.ID == op.ID })
+
+	case OpReorder:
+		rank := make(map[uuid.UUID]int, len(op.Order))
+		for i, id := range op.Order {
+			rank[id] = i
+		}
+		position := func(t task.Task) int {
+			if r, ok := rank[t.ID]; ok {
+				return r
+			}
+			return len(rank)
+		}
+		slices.SortStableFunc(tasks, func(a, b task.Task) int { return position(a) - position(b) })
+		return tasks
+	}
+	return tasks
+}
+
+// sameTask reports whether a and b would be stored identically.
+func sameTask(a, b task.Task) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// onlyToggled reports whether b is a with just its completion state
+// changed.
+func onlyToggled(a, b task.Task) bool {
+	if a.Done == b.Done {
+		return false
+	}
+	a.Done, a.CompletedAt, a.UpdatedAt = b.Done, b.CompletedAt, b.UpdatedAt
+	return sameTask(a, b)
+}
+
+func uniqueIDs(tasks []task.Task) bool {
+	seen := make(map[uuid.UUID]bool, len(tasks))
+	for _, t := range tasks {
+		if t.ID == uuid.Nil || seen[t.ID] {
+			return false
+		}
+		seen[t.ID] = true
+	}
+	return true
+}
+
+func taskIDs(tasks []task.Task) []uuid.UUID {
+	ids := make([]uuid.UUID, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func indexByID(tasks []task.Task, id uuid.UUID) int {
+	return slices.IndexFunc(tasks, func(t task.Task) bool { return t.ID == id })
+}
@@ -0,0 +1,277 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// newTempJournalStore returns a JournalTaskStore in a temporary
+// directory, along with the path of its snapshot.
+func newTempJournalStore(t *testing.T) (*JournalTaskStore, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	return NewJournalTaskStore(path), path
+}
+
+// opKinds returns the kinds of the operations in the store's journal.
+func opKinds(t *testing.T, s *JournalTaskStore) []OpKind {
+	t.Helper()
+
+	ops, err := s.Operations()
+	if err != nil {
+		t.Fatalf("Operations() error = %v", err)
+	}
+	kinds := make([]OpKind, len(ops))
+	for i, op := range ops {
+		kinds[i] = op.Kind
+	}
+	return kinds
+}
+
+func TestJournalTaskStore_RecordsOperations(t *testing.T) {
+	s, path := newTempJournalStore(t)
+
+	if got, want := s.Name(), DefaultJournalName; got != want {
+		t.Fatalf("Name() = %q, want %q", got, want)
+	}
+
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tasks[0].TitleStr = "write longer report"
+	tasks[1].Done = false
+	tasks[1].CompletedAt = time.Time{}
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tasks = slices.Delete(tasks, 0, 1)
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	want := []OpKind{OpCreate, OpCreate, OpUpdate, OpToggle, OpDelete}
+	if got := opKinds(t, s); !slices.Equal(got, want) {
+		t.Fatalf("journal = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("snapshot written before compaction, Stat() error = %v", err)
+	}
+
+	loaded, err := NewJournalTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+}
+
+func TestJournalTaskStore_UnchangedSaveWritesNothing(t *testing.T) {
+	s, _ := newTempJournalStore(t)
+
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got := opKinds(t, s); len(got) != len(tasks) {
+		t.Fatalf("journal = %v, want only the creates", got)
+	}
+}
+
+func TestJournalTaskStore_Reorder(t *testing.T) {
+	s, path := newTempJournalStore(t)
+
+	tasks := sampleTasks()
+	tasks = append(tasks, task.Task{ID: uuid.New(), TitleStr: "third"})
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tasks[0], tasks[2] = tasks[2], tasks[0]
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if kinds := opKinds(t, s); kinds[len(kinds)-1] != OpReorder {
+		t.Fatalf("journal = %v, want a trailing reorder", kinds)
+	}
+
+	loaded, err := NewJournalTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+}
+
+func TestJournalTaskStore_Compaction(t *testing.T) {
+	s, path := newTempJournalStore(t)
+	s.compactEvery = 3
+
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	tasks = append(tasks, task.Task{ID: uuid.New(), TitleStr: "third"})
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// The third operation reached the limit, so everything is in the
+	// snapshot now.
+	if _, err := os.Stat(path + journalSuffix); !os.IsNotExist(err) {
+		t.Fatalf("journal left after compaction, Stat() error = %v", err)
+	}
+	loaded, err := NewFileTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+
+	// New changes go on top of the snapshot.
+	tasks[2].TitleStr = "renamed"
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got := opKinds(t, s); !slices.Equal(got, []OpKind{OpUpdate}) {
+		t.Fatalf("journal = %v, want [update]", got)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if loaded, err = NewFileTaskStore(path).Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+}
+
+func TestJournalTaskStore_TornTail(t *testing.T) {
+	s, path := newTempJournalStore(t)
+
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// An append cut short by a crash leaves a partial line behind.
+	f, err := os.OpenFile(path+journalSuffix, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	if _, err := f.WriteString(`{"op":"delete","id":"`); err != nil {
+		t.Fatalf("WriteString() error = %v", err)
+	}
+	_ = f.Close()
+
+	reopened := NewJournalTaskStore(path)
+	loaded, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+
+	// The partial line is gone, so later appends stay readable.
+	tasks = tasks[:1]
+	if err := reopened.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if loaded, err = NewJournalTaskStore(path).Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+}
+
+func TestJournalTaskStore_CorruptEntry(t *testing.T) {
+	s, path := newTempJournalStore(t)
+
+	if err := os.WriteFile(path+journalSuffix, []byte("not json\n{}\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := s.Load(); err == nil {
+		t.Fatal("Load() error = nil, want an error for a corrupt entry")
+	}
+}
+
+func TestJournalTaskStore_TasksWithoutIDs(t *testing.T) {
+	s, path := newTempJournalStore(t)
+
+	// Operations need IDs to refer to tasks, so these go straight to a
+	// snapshot.
+	tasks := []task.Task{{TitleStr: "a"}, {TitleStr: "b"}}
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got := opKinds(t, s); len(got) != 0 {
+		t.Fatalf("journal = %v, want empty", got)
+	}
+	loaded, err := NewJournalTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+}
+
+func TestJournalTaskStore_SharedWithAnotherProcess(t *testing.T) {
+	mine, path := newTempJournalStore(t)
+	theirs := NewJournalTaskStore(path)
+
+	tasks := sampleTasks()
+	if err := mine.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	theirTasks, err := theirs.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// They add a task; a save of my stale list keeps it.
+	added := task.Task{ID: uuid.New(), TitleStr: "theirs"}
+	if err := theirs.Save(append(theirTasks, added)); err != nil {
+		t.Fatalf("their Save() error = %v", err)
+	}
+	tasks[0].TitleStr = "mine"
+	if err := mine.Save(tasks); err != nil {
+		t.Fatalf("my Save() error = %v", err)
+	}
+	want := append(slices.Clone(tasks), added)
+	for _, s := range []*JournalTaskStore{mine, theirs, NewJournalTaskStore(path)} {
+		loaded, err := s.Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		assertSameTasks(t, loaded, want)
+	}
+
+	// Compacting folds in what they appended last.
+	want[1].TitleStr = "renamed by them"
+	if err := theirs.Put(want[1]); err != nil {
+		t.Fatalf("their Put() error = %v", err)
+	}
+	if err := mine.Compact(); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	loaded, err := NewFileTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, want)
+
+	// They catch up with the new snapshot before appending again.
+	want[2].TitleStr = "renamed again"
+	if err := theirs.Put(want[2]); err != nil {
+		t.Fatalf("their Put() error = %v", err)
+	}
+	if loaded, err = mine.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, want)
+}
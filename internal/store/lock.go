@@ -0,0 +1,61 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// lockSuffix is appended to a file's path to name its lock file.
+	lockSuffix = ".lock"
+
+	// lockTimeout is how long to wait for another process to release a
+	// lock before giving up.
+	lockTimeout = 5 * time.Second
+
+	// lockRetryInterval is how often a held lock is tried again.
+	lockRetryInterval = 10 * time.Millisecond
+)
+
+// ErrLocked is returned when a lock stays held by another process for
+// longer than lockTimeout.
+var ErrLocked = errors.New("locked by another process")
+
+// lockFile takes an exclusive advisory lock on the file at path,
+// creating it if needed, and returns a function that releases it. The
+// lock file itself is left in place, since removing it would let two
+// processes lock different files of the same name.
+func lockFile(path string) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("%s: %w", path, ErrLocked)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
@@ -0,0 +1,588 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	DefaultMarkdownName = "Markdown Store"
+
+	// markdownDate is the layout of due dates in a Markdown file.
+	markdownDate = "2006-01-02"
+
+	// markdownIndent indents the description and checklist of a task.
+	markdownIndent = "  "
+
+	// markdownHeading starts a new Markdown file.
+	markdownHeading = "# Tasks"
+
+	// markdownDetailsStart and markdownDetailsEnd enclose the details
+	// block at the end of a Markdown file.
+	markdownDetailsStart = "<!-- terminaltask details"
+	markdownDetailsEnd   = "-->"
+)
+
+// markdownNamespace derives IDs for items that do not carry one yet,
+// such as those written by hand, so they keep the same ID from one load
+// to the next.
+var markdownNamespace = uuid.MustParse("5a0c3d1e-8f4b-4c7e-9d2a-6b1f0e3c7a94")
+
+var (
+	markdownItemRe    = regexp.MustCompile(`^([-*+]) \[([ xX])\](?:\s+(.*))?$`)
+	markdownMetaRe    = regexp.MustCompile(`\s*<!--\s*terminaltask:\s*(.*?)\s*-->\s*$`)
+	markdownDueRe     = regexp.MustCompile(`\s*\bdue:\s?(\d{4}-\d{2}-\d{2})\b`)
+	markdownFenceList = []string{"```", "~~~"}
+)
+
+// MarkdownTaskStore keeps tasks as a checklist in a Markdown file, such
+// as a TASKS.md kept in a repository:
+//
+//   - [ ] Write report due:2030-01-10 <!-- terminaltask: 9ad7890f-… -->
+//     Quarterly numbers, as discussed.
+//   - [x] Collect data
+//   - [ ] Draw charts
+//
+// The indented lines under an item are its description, and checklist
+// items among them are its subtasks. The HTML comment on an item's line
+// holds its ID only; the rest of the task is kept in a block at the end
+// of the file, one line per task, so that editing a task changes no
+// more than its own lines:
+//
+//	<!-- terminaltask details
+//	{"id":"9ad7890f-…","priority":"high","tags":["work"]}
+//	-->
+//
+// Markdown shows neither. Lines that are not tasks, such as headings and
+// prose, are left as they are; new tasks are added next to the task
+// before them.
+type MarkdownTaskStore struct {
+	path string
+	name string
+
+	// mu guards revision, the hash of the file as last loaded or saved
+	// by this store. seen is false until then.
+	mu       sync.Mutex
+	revision [sha256.Size]byte
+	seen     bool
+
+	// perm, when set, is the permissions the file is written with;
+	// otherwise it keeps its own.
+	perm os.FileMode
+}
+
+// markdownNode is a line of prose, or a task with the lines under it.
+type markdownNode struct {
+	prose  string
+	isTask bool
+	id     uuid.UUID
+	bullet string
+}
+
+// markdownMeta holds the parts of a task the checklist does not show.
+type markdownMeta struct {
+	ID          uuid.UUID     `json:"id"`
+	DueAt       string        `json:"dueAt,omitempty"`
+	Priority    task.Priority `json:"priority,omitzero"`
+	Tags        []string      `json:"tags,omitempty"`
+	List        string        `json:"list,omitempty"`
+	Start       string        `json:"start,omitempty"`
+	Repeat      string        `json:"repeat,omitempty"`
+	BlockedBy   []uuid.UUID   `json:"blockedBy,omitempty"`
+	CreatedAt   time.Time     `json:"created,omitzero"`
+	UpdatedAt   time.Time     `json:"updated,omitzero"`
+	CompletedAt time.Time     `json:"completed,omitzero"`
+}
+
+// NewMarkdownTaskStore returns a store that keeps tasks in the Markdown
+// file at path.
+func NewMarkdownTaskStore(path string) *MarkdownTaskStore {
+	return &MarkdownTaskStore{path: path, name: DefaultMarkdownName}
+}
+
+func (s *MarkdownTaskStore) Name() string {
+	return s.name
+}
+
+func (s *MarkdownTaskStore) Paths() []string {
+	return []string{s.path}
+}
+
+func (s *MarkdownTaskStore) SetFilePerm(perm os.FileMode) {
+	s.perm = perm
+}
+
+func (s *MarkdownTaskStore) Load() ([]task.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path + lockSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	b, err := readIfExists(s.path)
+	if err != nil {
+		return nil, err
+	}
+	_, tasks, err := parseMarkdown(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	s.revision, s.seen = sha256.Sum256(b), true
+	return tasks, nil
+}
+
+// Save writes tasks into the file, keeping the lines that are not
+// tasks, unless another program changed it since it was last loaded.
+func (s *MarkdownTaskStore) Save(tasks []task.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path + lockSuffix)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := readIfExists(s.path)
+	if err != nil {
+		return err
+	}
+	if s.seen && sha256.Sum256(current) != s.revision {
+		return fmt.Errorf("%s: %w", s.path, ErrStaleSave)
+	}
+	nodes, _, err := parseMarkdown(current)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.path, err)
+	}
+	if current == nil {
+		nodes = []markdownNode{{prose: markdownHeading}}
+	}
+
+	b, err := renderMarkdown(arrangeMarkdown(nodes, tasks), tasks)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, b, writePerm(s.path, s.perm)); err != nil {
+		return err
+	}
+	s.revision, s.seen = sha256.Sum256(b), true
+	return nil
+}
+
+// parseMarkdown splits a Markdown file into prose and tasks, returning
+// the tasks in the order they appear. Errors name the line they were
+// found on.
+func parseMarkdown(b []byte) ([]markdownNode, []task.Task, error) {
+	var (
+		nodes     []markdownNode
+		tasks     []task.Task
+		fenced    bool
+		seenIDs   = map[uuid.UUID]bool{}
+		seenLines = map[string]int{}
+	)
+
+	lines := strings.Split(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	details, err := parseMarkdownDetails(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], "\r")
+		if isMarkdownFence(line) {
+			fenced = !fenced
+		}
+		if !fenced && strings.TrimSpace(line) == markdownDetailsStart {
+			// The block is written anew at the end of the file.
+			for i < len(lines) && strings.TrimSpace(lines[i]) != markdownDetailsEnd {
+				i++
+			}
+			i++
+			continue
+		}
+		m := markdownItemRe.FindStringSubmatch(line)
+		if fenced || m == nil {
+			nodes = append(nodes, markdownNode{prose: lines[i]})
+			i++
+			continue
+		}
+
+		// The item goes on as long as the lines are indented, including
+		// blank lines between them.
+		var body []string
+		j := i + 1
+		for j < len(lines) {
+			next := strings.TrimRight(lines[j], "\r")
+			if isMarkdownIndented(next) {
+				body = append(body, next)
+				j++
+				continue
+			}
+			k := j
+			for k < len(lines) && strings.TrimSpace(lines[k]) == "" {
+				k++
+			}
+			if k == j || k == len(lines) || !isMarkdownIndented(lines[k]) {
+				break
+			}
+			for ; j < k; j++ {
+				body = append(body, "")
+			}
+		}
+
+		t, err := parseMarkdownTask(m[2], m[3], body, details)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if t.ID == uuid.Nil {
+			t.ID = uuid.NewSHA1(markdownNamespace, []byte(line+"\x00"+strconv.Itoa(seenLines[line])))
+			seenLines[line]++
+		}
+		for n := 1; seenIDs[t.ID]; n++ {
+			t.ID = uuid.NewSHA1(t.ID, []byte(strconv.Itoa(n)))
+		}
+		seenIDs[t.ID] = true
+		for n, sub := range t.Subtasks {
+			t.Subtasks[n].ID = uuid.NewSHA1(t.ID, []byte(strconv.Itoa(n)+" "+sub.TitleStr))
+		}
+
+		nodes = append(nodes, markdownNode{isTask: true, id: t.ID, bullet: m[1]})
+		tasks = append(tasks, t)
+		i = j
+	}
+	return nodes, tasks, nil
+}
+
+// parseMarkdownDetails reads the details block of a Markdown file,
+// returning the details it holds by task ID. Errors name the line they
+// were found on.
+func parseMarkdownDetails(lines []string) (map[uuid.UUID]markdownMeta, error) {
+	details := map[uuid.UUID]markdownMeta{}
+	fenced, in := false, false
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case in && line == markdownDetailsEnd:
+			in = false
+		case in && line != "":
+			var meta markdownMeta
+			if err := json.Unmarshal([]byte(line), &meta); err != nil {
+				return nil, fmt.Errorf("line %d: read task details: %w", i+1, err)
+			}
+			details[meta.ID] = meta
+		case in:
+			// Blank lines in the block are skipped.
+		case isMarkdownFence(line):
+			fenced = !fenced
+		case !fenced && line == markdownDetailsStart:
+			in = true
+		}
+	}
+	return details, nil
+}
+
+// parseMarkdownTask reads a task from the text after its checkbox and
+// the indented lines under it, taking the rest of it from details.
+func parseMarkdownTask(mark, text string, body []string, details map[uuid.UUID]markdownMeta) (task.Task, error) {
+	t := task.Task{Done: mark != " "}
+
+	var meta markdownMeta
+	if m := markdownMetaRe.FindStringSubmatchIndex(text); m != nil {
+		raw := text[m[2]:m[3]]
+		if strings.HasPrefix(raw, "{") {
+			// Older files keep all the details on the item's line.
+			if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+				return task.Task{}, fmt.Errorf("read task details: %w", err)
+			}
+		} else {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return task.Task{}, fmt.Errorf("read task ID: %w", err)
+			}
+			meta.ID = id
+		}
+		if d, ok := details[meta.ID]; ok {
+			meta = d
+		}
+		text = text[:m[0]]
+	}
+
+	if m := markdownDueRe.FindStringSubmatchIndex(text); m != nil {
+		day, err := time.ParseInLocation(markdownDate, text[m[2]:m[3]], time.Local)
+		if err == nil {
+			if err := t.SetDue(day, meta.DueAt); err != nil {
+				return task.Task{}, err
+			}
+			text = text[:m[0]] + text[m[1]:]
+		}
+	}
+	t.TitleStr = strings.TrimSpace(text)
+
+	var desc []string
+	for _, line := range body {
+		if rest, ok := strings.CutPrefix(line, "\t"); ok {
+			line = rest
+		} else {
+			line = strings.TrimPrefix(line, markdownIndent)
+		}
+		if m := markdownItemRe.FindStringSubmatch(line); m != nil {
+			t.Subtasks = append(t.Subtasks, task.Subtask{TitleStr: strings.TrimSpace(m[3]), Done: m[2] != " "})
+			continue
+		}
+		if isMarkdownEscapedItem(line) {
+			line = line[1:]
+		}
+		desc = append(desc, line)
+	}
+	t.DescStr = strings.Trim(strings.Join(desc, "\n"), "\n")
+
+	t.ID = meta.ID
+	t.Priority = meta.Priority
+	t.Tags = meta.Tags
+	t.List = meta.List
+	t.BlockedBy = meta.BlockedBy
+	t.CreatedAt, t.UpdatedAt, t.CompletedAt = meta.CreatedAt, meta.UpdatedAt, meta.CompletedAt
+	if meta.Start != "" {
+		start, err := time.ParseInLocation(markdownDate, meta.Start, time.Local)
+		if err != nil {
+			return task.Task{}, fmt.Errorf("read start date: %w", err)
+		}
+		t.StartDate = start
+	}
+	if meta.Repeat != "" {
+		r, err := task.ParseRecurrence(meta.Repeat)
+		if err != nil {
+			return task.Task{}, err
+		}
+		t.Recurrence = r
+	}
+	return t, nil
+}
+
+// arrangeMarkdown fits tasks into the nodes of a file: removed tasks go
+// away, tasks between the same prose take the order of tasks, and new
+// tasks are placed after the task before them.
+func arrangeMarkdown(nodes []markdownNode, tasks []task.Task) []markdownNode {
+	order := make(map[uuid.UUID]int, len(tasks))
+	for i, t := range tasks {
+		order[t.ID] = i
+	}
+
+	nodes = slices.DeleteFunc(nodes, func(n markdownNode) bool {
+		_, ok := order[n.id]
+		return n.isTask && !ok
+	})
+
+	// Reorder each run of tasks separated by nothing but blank lines.
+	for start := 0; start < len(nodes); {
+		if !nodes[start].isTask {
+			start++
+			continue
+		}
+		var slots []int
+		end := start
+		for ; end < len(nodes); end++ {
+			if nodes[end].isTask {
+				slots = append(slots, end)
+			} else if strings.TrimSpace(nodes[end].prose) != "" {
+				break
+			}
+		}
+		run := make([]markdownNode, len(slots))
+		for i, slot := range slots {
+			run[i] = nodes[slot]
+		}
+		slices.SortStableFunc(run, func(a, b markdownNode) int {
+			return order[a.id] - order[b.id]
+		})
+		for i, slot := range slots {
+			nodes[slot] = run[i]
+		}
+		start = end
+	}
+
+	index := func(id uuid.UUID) int {
+		return slices.IndexFunc(nodes, func(n markdownNode) bool { return n.isTask && n.id == id })
+	}
+	for i, t := range tasks {
+		if index(t.ID) >= 0 {
+			continue
+		}
+		node := markdownNode{isTask: true, id: t.ID, bullet: "-"}
+
+		switch {
+		case i > 0:
+			at := index(tasks[i-1].ID) + 1
+			nodes = slices.Insert(nodes, at, node)
+		case slices.ContainsFunc(nodes, func(n markdownNode) bool { return n.isTask }):
+			at := slices.IndexFunc(nodes, func(n markdownNode) bool { return n.isTask })
+			nodes = slices.Insert(nodes, at, node)
+		default:
+			if len(nodes) > 0 && strings.TrimSpace(nodes[len(nodes)-1].prose) != "" {
+				nodes = append(nodes, markdownNode{})
+			}
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// renderMarkdown writes nodes back into a file, with each task as found
+// in tasks, followed by the details block.
+func renderMarkdown(nodes []markdownNode, tasks []task.Task) ([]byte, error) {
+	byID := make(map[uuid.UUID]task.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	var (
+		b       strings.Builder
+		details []string
+		last    string
+	)
+	for _, n := range nodes {
+		if !n.isTask {
+			b.WriteString(n.prose)
+			b.WriteByte('\n')
+			last = n.prose
+			continue
+		}
+		t := byID[n.id]
+		lines := formatMarkdownTask(n.bullet, t)
+		for _, line := range lines {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+		last = lines[len(lines)-1]
+
+		detail, err := formatMarkdownDetails(t)
+		if err != nil {
+			return nil, err
+		}
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+
+	if len(details) > 0 {
+		if strings.TrimSpace(last) != "" {
+			b.WriteByte('\n')
+		}
+		b.WriteString(markdownDetailsStart + "\n")
+		for _, line := range details {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString(markdownDetailsEnd + "\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// formatMarkdownDetails returns the line of the details block holding
+// the parts of t the checklist does not show, or "" when it has none
+// besides its ID.
+func formatMarkdownDetails(t task.Task) (string, error) {
+	meta := markdownMeta{
+		ID:          t.ID,
+		DueAt:       t.DueClock(),
+		Priority:    t.Priority,
+		Tags:        t.Tags,
+		List:        t.List,
+		BlockedBy:   t.BlockedBy,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+		CompletedAt: t.CompletedAt,
+	}
+	if t.HasStartDate() {
+		meta.Start = t.StartDate.Format(markdownDate)
+	}
+	if t.Recurrence != nil {
+		meta.Repeat = t.Recurrence.String()
+	}
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	if bare, _ := json.Marshal(markdownMeta{ID: t.ID}); string(raw) == string(bare) {
+		return "", nil
+	}
+	return string(raw), nil
+}
+
+// formatMarkdownTask writes t as a checklist item and the lines under
+// it.
+func formatMarkdownTask(bullet string, t task.Task) []string {
+	words := []string{bullet, markdownCheckbox(t.Done)}
+	if title := strings.Join(strings.Fields(t.TitleStr), " "); title != "" {
+		words = append(words, title)
+	}
+	if t.HasDueDate() {
+		day := t.DueDate
+		if t.DueHasTime {
+			day = t.DueDate.In(t.DueLocation())
+		}
+		words = append(words, "due:"+day.Format(markdownDate))
+	}
+	words = append(words, "<!-- terminaltask: "+t.ID.String()+" -->")
+	lines := []string{strings.Join(words, " ")}
+
+	if t.DescStr != "" {
+		for _, line := range strings.Split(t.DescStr, "\n") {
+			if strings.TrimSpace(line) == "" {
+				lines = append(lines, "")
+				continue
+			}
+			// A line that reads as a checklist item would come back as
+			// a subtask, so it gets a backslash, as Markdown escapes.
+			if markdownItemRe.MatchString(line) || isMarkdownEscapedItem(line) {
+				line = `\` + line
+			}
+			lines = append(lines, markdownIndent+line)
+		}
+	}
+	for _, sub := range t.Subtasks {
+		lines = append(lines, markdownIndent+"- "+markdownCheckbox(sub.Done)+" "+sub.TitleStr)
+	}
+	return lines
+}
+
+func markdownCheckbox(done bool) string {
+	if done {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// isMarkdownEscapedItem reports whether line is a checklist item with
+// one or more backslashes before it.
+func isMarkdownEscapedItem(line string) bool {
+	return strings.HasPrefix(line, `\`) && markdownItemRe.MatchString(strings.TrimLeft(line, `\`))
+}
+
+func isMarkdownIndented(line string) bool {
+	return strings.TrimSpace(line) != "" &&
+		(strings.HasPrefix(line, markdownIndent) || strings.HasPrefix(line, "\t"))
+}
+
+func isMarkdownFence(line string) bool {
+	line = strings.TrimSpace(line)
+	return slices.ContainsFunc(markdownFenceList, func(fence string) bool {
+		return strings.HasPrefix(line, fence)
+	})
+}
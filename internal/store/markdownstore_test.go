@@ -0,0 +1,278 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// newTempMarkdownStore returns a MarkdownTaskStore in a temporary
+// directory, along with the path of its file.
+func newTempMarkdownStore(t *testing.T) (*MarkdownTaskStore, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "TASKS.md")
+	return NewMarkdownTaskStore(path), path
+}
+
+// handWrittenMarkdown is a TASKS.md as someone might write it.
+const handWrittenMarkdown = `# Release 1.2
+
+Things to finish before tagging.
+
+- [ ] Write changelog due: 2030-01-10
+  Summarize the merged PRs.
+
+  Mention the new stores.
+  - [x] Collect PRs
+  - [ ] Draft
+- [X] Bump version
+
+## Notes
+
+` + "```" + `
+- [ ] not a task, just an example
+` + "```" + `
+`
+
+// -----------------------------------------------------------------------------
+// Parsing
+// -----------------------------------------------------------------------------
+
+func TestMarkdownTaskStore_ReadsChecklist(t *testing.T) {
+	s, path := newTempMarkdownStore(t)
+	if err := os.WriteFile(path, []byte(handWrittenMarkdown), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Load() returned %d tasks, want 2: %+v", len(tasks), tasks)
+	}
+
+	changelog := tasks[0]
+	if changelog.Title() != "Write changelog" || changelog.Done {
+		t.Fatalf("first task = %+v", changelog)
+	}
+	if want := "Summarize the merged PRs.\n\nMention the new stores."; changelog.Description() != want {
+		t.Fatalf("Description() = %q, want %q", changelog.Description(), want)
+	}
+	if got, want := changelog.DueDate, time.Date(2030, 1, 10, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Fatalf("DueDate = %v, want %v", got, want)
+	}
+	if done, total := changelog.Progress(); done != 1 || total != 2 {
+		t.Fatalf("Progress() = %d/%d, want 1/2", done, total)
+	}
+	if bump := tasks[1]; bump.Title() != "Bump version" || !bump.Done {
+		t.Fatalf("second task = %+v", bump)
+	}
+
+	// IDs of items without one stay the same between loads.
+	again, err := NewMarkdownTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, again, tasks)
+}
+
+func TestMarkdownTaskStore_BadDetails(t *testing.T) {
+	s, path := newTempMarkdownStore(t)
+	content := "# Tasks\n\n- [ ] broken <!-- terminaltask: {not json} -->\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, err := s.Load()
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("Load() error = %v, want one naming line 3", err)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Saving
+// -----------------------------------------------------------------------------
+
+func TestMarkdownTaskStore_SaveLoadRoundTrip(t *testing.T) {
+	s, path := newTempMarkdownStore(t)
+
+	if got, want := s.Name(), DefaultMarkdownName; got != want {
+		t.Fatalf("Name() = %q, want %q", got, want)
+	}
+
+	original := sampleTasks()
+	original[0].DueDate = time.Date(2030, 1, 10, 0, 0, 0, 0, time.Local)
+	original[0].Subtasks = []task.Subtask{{TitleStr: "gather numbers", Done: true}}
+	original[1].DescStr = "daily\n\n  indented notes"
+	if err := original[1].SetDue(original[1].DueDate, "9:00 Europe/Berlin"); err != nil {
+		t.Fatalf("SetDue() error = %v", err)
+	}
+	// Checklist items get their IDs from their task.
+	for _, tk := range original {
+		for i, sub := range tk.Subtasks {
+			tk.Subtasks[i].ID = uuid.NewSHA1(tk.ID, []byte(strconv.Itoa(i)+" "+sub.TitleStr))
+		}
+	}
+
+	if err := s.Save(original); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.HasPrefix(string(b), markdownHeading+"\n\n- [ ] write report due:2030-01-10 <!-- terminaltask: ") {
+		t.Fatalf("file starts with %q", b)
+	}
+
+	loaded, err := NewMarkdownTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, original)
+}
+
+func TestMarkdownTaskStore_EscapesChecklistInDescription(t *testing.T) {
+	s, path := newTempMarkdownStore(t)
+
+	desc := "steps:\n- [ ] not a subtask\n* [x] nor this\n\\- [ ] a backslash stays"
+	original := []task.Task{{ID: uuid.New(), TitleStr: "plan", DescStr: desc}}
+	if err := s.Save(original); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(b), "\n  \\- [ ] not a subtask\n") {
+		t.Fatalf("file = %q, want the item in the description escaped", b)
+	}
+
+	loaded, err := NewMarkdownTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, original)
+}
+
+func TestMarkdownTaskStore_KeepsProse(t *testing.T) {
+	s, path := newTempMarkdownStore(t)
+	if err := os.WriteFile(path, []byte(handWrittenMarkdown), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Swap the tasks, then add one after the first.
+	tasks[0], tasks[1] = tasks[1], tasks[0]
+	tasks = append(tasks[:1], append([]task.Task{{ID: uuid.New(), TitleStr: "Tag release"}}, tasks[1:]...)...)
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	lines := strings.Split(string(b), "\n")
+	for _, want := range []struct {
+		line   int
+		prefix string
+	}{
+		{0, "# Release 1.2"},
+		{2, "Things to finish before tagging."},
+		{4, "- [x] Bump version <!-- terminaltask: "},
+		{5, "- [ ] Tag release <!-- terminaltask: "},
+		{6, "- [ ] Write changelog due:2030-01-10 <!-- terminaltask: "},
+		{7, "  Summarize the merged PRs."},
+		{8, ""},
+		{9, "  Mention the new stores."},
+		{10, "  - [x] Collect PRs"},
+		{11, "  - [ ] Draft"},
+		{13, "## Notes"},
+		{16, "- [ ] not a task, just an example"},
+	} {
+		if !strings.HasPrefix(lines[want.line], want.prefix) || (want.prefix == "" && lines[want.line] != "") {
+			t.Fatalf("line %d = %q, want it to start with %q\n%s", want.line+1, lines[want.line], want.prefix, b)
+		}
+	}
+
+	loaded, err := NewMarkdownTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+}
+
+func TestMarkdownTaskStore_DetailsBlock(t *testing.T) {
+	s, path := newTempMarkdownStore(t)
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if want := "- [ ] write report due:2030-01-10 <!-- terminaltask: " + tasks[0].ID.String() + " -->\n"; !strings.Contains(string(before), want) {
+		t.Fatalf("file does not contain %q:\n%s", want, before)
+	}
+
+	// Touching a task changes its line in the details block only.
+	tasks[0].UpdatedAt = tasks[0].UpdatedAt.Add(time.Hour)
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	oldLines, newLines := strings.Split(string(before), "\n"), strings.Split(string(after), "\n")
+	if len(oldLines) != len(newLines) {
+		t.Fatalf("Save() changed the number of lines:\n%s\n---\n%s", before, after)
+	}
+	var changed []string
+	for i := range newLines {
+		if oldLines[i] != newLines[i] {
+			changed = append(changed, newLines[i])
+		}
+	}
+	if len(changed) != 1 || !strings.HasPrefix(changed[0], `{"id":"`+tasks[0].ID.String()) {
+		t.Fatalf("changed lines = %q, want the task's details line only", changed)
+	}
+
+	loaded, err := NewMarkdownTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 2 || !loaded[0].UpdatedAt.Equal(tasks[0].UpdatedAt) || loaded[0].Priority != tasks[0].Priority {
+		t.Fatalf("Load() = %+v, want the details from the block", loaded)
+	}
+}
+
+func TestMarkdownTaskStore_ReadsDetailsOnItemLine(t *testing.T) {
+	s, path := newTempMarkdownStore(t)
+	id := uuid.New()
+	content := "# Tasks\n\n- [ ] old <!-- terminaltask: {\"id\":\"" + id.String() + "\",\"priority\":\"high\"} -->\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != id || tasks[0].Priority != task.PriorityHigh {
+		t.Fatalf("Load() = %+v, want the task with its details", tasks)
+	}
+}
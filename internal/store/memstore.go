@@ -0,0 +1,94 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const DefaultMemName = "Memory Store"
+
+// MemTaskStore keeps tasks in memory only; they are gone once the
+// program exits. It suits trying terminaltask out and tests.
+type MemTaskStore struct {
+	mu    sync.Mutex
+	tasks []task.Task
+}
+
+// NewMemTaskStore returns an empty in-memory store.
+func NewMemTaskStore() *MemTaskStore {
+	return &MemTaskStore{}
+}
+
+func (s *MemTaskStore) Name() string {
+	return DefaultMemName
+}
+
+func (s *MemTaskStore) Load() ([]task.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]task.Task, len(s.tasks))
+	for i, t := range s.tasks {
+		tasks[i] = cloneTask(t)
+	}
+	return tasks, nil
+}
+
+func (s *MemTaskStore) Save(tasks []task.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks = make([]task.Task, len(tasks))
+	for i, t := range tasks {
+		s.tasks[i] = cloneTask(t)
+	}
+	return nil
+}
+
+func (s *MemTaskStore) Get(id uuid.UUID) (task.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := indexByID(s.tasks, id); i >= 0 {
+		return cloneTask(s.tasks[i]), nil
+	}
+	return task.Task{}, fmt.Errorf("task %s: %w", id, ErrTaskNotFound)
+}
+
+func (s *MemTaskStore) Put(t task.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := indexByID(s.tasks, t.ID); i >= 0 {
+		s.tasks[i] = cloneTask(t)
+		return nil
+	}
+	s.tasks = append(s.tasks, cloneTask(t))
+	return nil
+}
+
+func (s *MemTaskStore) Delete(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks = slices.DeleteFunc(s.tasks, func(t task.Task) bool { return t.ID == id })
+	return nil
+}
+
+// cloneTask copies t, so that changes made to the copy through its
+// slices and pointers do not reach t.
+func cloneTask(t task.Task) task.Task {
+	t.Tags = slices.Clone(t.Tags)
+	t.Subtasks = slices.Clone(t.Subtasks)
+	t.BlockedBy = slices.Clone(t.BlockedBy)
+	if t.Recurrence != nil {
+		r := *t.Recurrence
+		r.Weekdays = slices.Clone(r.Weekdays)
+		t.Recurrence = &r
+	}
+	return t
+}
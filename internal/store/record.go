@@ -0,0 +1,111 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// ErrTaskNotFound is returned when reading a task that is not in the
+// store.
+var ErrTaskNotFound = errors.New("task not found")
+
+// RecordStore is implemented by stores that can read and write a single
+// task without loading or saving all of them, such as a database that
+// updates one row or a journal that appends one entry. Changing one
+// task among many then costs about as much as the task itself.
+type RecordStore interface {
+	TaskStore
+
+	// Get returns the task with the given ID, or an error wrapping
+	// ErrTaskNotFound if there is none.
+	Get(id uuid.UUID) (task.Task, error)
+
+	// Put replaces the task with t's ID, keeping its place, or adds t
+	// after all other tasks.
+	Put(t task.Task) error
+
+	// Delete removes the task with the given ID. Deleting a task that
+	// is not in the store does nothing.
+	Delete(id uuid.UUID) error
+}
+
+// DependentStore is implemented by record stores that can find the
+// tasks blocked by a task without loading all of them, such as a
+// database with an index on blockers.
+type DependentStore interface {
+	RecordStore
+
+	// Dependents returns the tasks blocked by the task with the given
+	// ID, in order.
+	Dependents(id uuid.UUID) ([]task.Task, error)
+}
+
+// Dependents returns the tasks in s blocked by the task with the given
+// ID, reading only those if s is a DependentStore and all tasks
+// otherwise.
+func Dependents(s TaskStore, id uuid.UUID) ([]task.Task, error) {
+	if ds, ok := s.(DependentStore); ok {
+		return ds.Dependents(id)
+	}
+	tasks, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tasks, func(t task.Task) bool {
+		return !slices.Contains(t.BlockedBy, id)
+	}), nil
+}
+
+// GetTask returns the task with the given ID from s, reading only that
+// task if s is a RecordStore and all of them otherwise.
+func GetTask(s TaskStore, id uuid.UUID) (task.Task, error) {
+	if rs, ok := s.(RecordStore); ok {
+		return rs.Get(id)
+	}
+	tasks, err := s.Load()
+	if err != nil {
+		return task.Task{}, err
+	}
+	if i := indexByID(tasks, id); i >= 0 {
+		return tasks[i], nil
+	}
+	return task.Task{}, fmt.Errorf("task %s: %w", id, ErrTaskNotFound)
+}
+
+// PutTask stores t in s, replacing the task with its ID or adding it
+// after all other tasks. Unless s is a RecordStore, it saves all tasks.
+func PutTask(s TaskStore, t task.Task) error {
+	if rs, ok := s.(RecordStore); ok {
+		return rs.Put(t)
+	}
+	tasks, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if i := indexByID(tasks, t.ID); i >= 0 {
+		tasks[i] = t
+	} else {
+		tasks = append(tasks, t)
+	}
+	return s.Save(tasks)
+}
+
+// DeleteTask removes the task with the given ID from s. Unless s is a
+// RecordStore, it saves all remaining tasks.
+func DeleteTask(s TaskStore, id uuid.UUID) error {
+	if rs, ok := s.(RecordStore); ok {
+		return rs.Delete(id)
+	}
+	tasks, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if indexByID(tasks, id) < 0 {
+		return nil
+	}
+	return s.Save(slices.DeleteFunc(tasks, func(t task.Task) bool { return t.ID == id }))
+}
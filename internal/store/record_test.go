@@ -0,0 +1,150 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+func TestRecords(t *testing.T) {
+	stores := []struct {
+		name string
+		open func(t *testing.T) TaskStore
+	}{
+		{"memory", func(t *testing.T) TaskStore { return NewMemTaskStore() }},
+		{"sqlite", func(t *testing.T) TaskStore {
+			s, _ := newTempSQLiteStore(t)
+			return s
+		}},
+		{"journal", func(t *testing.T) TaskStore {
+			s, _ := newTempJournalStore(t)
+			return s
+		}},
+		{"encrypted", func(t *testing.T) TaskStore {
+			s, _ := newTempJournalStore(t)
+			return NewEncryptedTaskStore(s, []byte("correct horse"))
+		}},
+		// The file store has no records, so the tasks are saved whole.
+		{"file", func(t *testing.T) TaskStore {
+			s, _ := newTempStore(t, "tasks.json")
+			return s
+		}},
+	}
+
+	for _, tc := range stores {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.open(t)
+			tasks := sampleTasks()
+			if err := s.Save(tasks); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			got, err := GetTask(s, tasks[1].ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			assertSameTasks(t, []task.Task{got}, tasks[1:2])
+			if _, err := GetTask(s, uuid.New()); !errors.Is(err, ErrTaskNotFound) {
+				t.Fatalf("GetTask(unknown) error = %v, want ErrTaskNotFound", err)
+			}
+
+			// A changed task keeps its place; a new one goes last.
+			tasks[0].TitleStr = "write longer report"
+			tasks[0].Tags = []string{"work"}
+			if err := PutTask(s, tasks[0]); err != nil {
+				t.Fatalf("PutTask() error = %v", err)
+			}
+			added := tasks[0]
+			added.ID = uuid.New()
+			added.TitleStr = "send report"
+			added.BlockedBy = []uuid.UUID{tasks[0].ID}
+			if err := PutTask(s, added); err != nil {
+				t.Fatalf("PutTask(new) error = %v", err)
+			}
+			tasks = append(tasks, added)
+
+			dependents, err := Dependents(s, tasks[0].ID)
+			if err != nil {
+				t.Fatalf("Dependents() error = %v", err)
+			}
+			if len(dependents) != 2 || dependents[0].ID != tasks[1].ID || dependents[1].ID != added.ID {
+				t.Fatalf("Dependents() = %+v, want the tasks blocked by the first", dependents)
+			}
+
+			if err := DeleteTask(s, tasks[1].ID); err != nil {
+				t.Fatalf("DeleteTask() error = %v", err)
+			}
+			if err := DeleteTask(s, uuid.New()); err != nil {
+				t.Fatalf("DeleteTask(unknown) error = %v", err)
+			}
+			tasks = slices.Delete(tasks, 1, 2)
+
+			loaded, err := s.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			assertSameTasks(t, loaded, tasks)
+		})
+	}
+}
+
+func TestJournalTaskStore_PutAppendsOneEntry(t *testing.T) {
+	s, path := newTempJournalStore(t)
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tasks[0].Done = true
+	tasks[0].CompletedAt = time.Date(2030, 1, 4, 9, 0, 0, 0, time.Local)
+	if err := s.Put(tasks[0]); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	// Putting it unchanged records nothing.
+	if err := s.Put(tasks[0]); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Delete(tasks[1].ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	want := []OpKind{OpCreate, OpCreate, OpToggle, OpDelete}
+	if got := opKinds(t, s); !slices.Equal(got, want) {
+		t.Fatalf("journal = %v, want %v", got, want)
+	}
+
+	loaded, err := NewJournalTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks[:1])
+}
+
+func TestSQLiteTaskStore_PutKeepsOtherRows(t *testing.T) {
+	s, path := newTempSQLiteStore(t)
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tasks[1].Subtasks = tasks[1].Subtasks[:1]
+	tasks[1].Tags = []string{"meetings"}
+	if err := s.Put(tasks[1]); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reopened, err := NewSQLiteTaskStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteTaskStore() error = %v", err)
+	}
+	defer reopened.Close()
+	loaded, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks)
+}
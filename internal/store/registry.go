@@ -0,0 +1,265 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Options of the built-in stores, given as URI query parameters.
+const (
+	OptionBackups        = "backups"
+	OptionBackupInterval = "backup_interval"
+	OptionBackupDir      = "backup_dir"
+	OptionCompactEvery   = "compact_every"
+	OptionImport         = "import"
+)
+
+var (
+	// ErrUnknownStore is returned when opening a store whose URI names
+	// no registered scheme.
+	ErrUnknownStore = errors.New("unknown store")
+
+	// ErrUnknownOption is returned when a store URI has an option its
+	// store does not accept.
+	ErrUnknownOption = errors.New("unknown store option")
+)
+
+// Opener opens a store at path, a file or directory, with the options
+// given in its URI.
+type Opener func(path string, opts url.Values) (TaskStore, error)
+
+// Backend is a kind of store that can be opened from a URI such as
+// file:///home/me/tasks.json?backups=5.
+type Backend struct {
+	// Scheme is the URI scheme naming the backend.
+	Scheme string
+
+	// Example is a URI shown to users as an example of the scheme.
+	Example string
+
+	// Options lists the query parameters the backend accepts.
+	Options []string
+
+	Open Opener
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{}
+)
+
+// Register makes a backend available to Open under its scheme. It
+// panics when the scheme is taken, as that is a programming error.
+func Register(b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if _, dup := backends[b.Scheme]; dup {
+		panic("store: Register called twice for scheme " + b.Scheme)
+	}
+	backends[b.Scheme] = b
+}
+
+// Schemes returns the registered schemes in sorted order.
+func Schemes() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	schemes := make([]string, 0, len(backends))
+	for scheme := range backends {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// Open opens the store named by uri, such as sqlite:///home/me/tasks.db.
+// Options missing from the URI are taken from defaults, which may hold
+// options meant for other backends; those in the URI itself must be
+// ones the backend accepts. Stores that need releasing implement
+// io.Closer.
+func Open(uri string, defaults url.Values) (TaskStore, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" {
+		return nil, fmt.Errorf("%w %q: want a URI such as %s (schemes: %s)",
+			ErrUnknownStore, uri, "file:///path/to/tasks.json", strings.Join(Schemes(), ", "))
+	}
+
+	backendsMu.RLock()
+	b, ok := backends[strings.ToLower(u.Scheme)]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w scheme %q in %q (schemes: %s)",
+			ErrUnknownStore, u.Scheme, uri, strings.Join(Schemes(), ", "))
+	}
+
+	opts := u.Query()
+	for name := range opts {
+		if !slices.Contains(b.Options, name) {
+			accepted := "none"
+			if len(b.Options) > 0 {
+				accepted = strings.Join(b.Options, ", ")
+			}
+			return nil, fmt.Errorf("%w %q for %s:// (accepted: %s)", ErrUnknownOption, name, b.Scheme, accepted)
+		}
+	}
+	for name, values := range defaults {
+		if !opts.Has(name) && slices.Contains(b.Options, name) {
+			opts[name] = values
+		}
+	}
+
+	path, err := uriPath(u)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" && b.Scheme != schemeMem {
+		return nil, fmt.Errorf("store %q names no path; for example %s", uri, b.Example)
+	}
+	return b.Open(path, opts)
+}
+
+// uriPath returns the path a store URI points at. Besides absolute paths
+// like file:///home/me/tasks.json, it accepts relative ones like
+// file:tasks.json or file://tasks.json, ones starting with ~/ for the
+// home directory, and Windows ones like file:///C:/tasks.json.
+func uriPath(u *url.URL) (string, error) {
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+	}
+	// file:///C:/tasks.json names a Windows drive.
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// Schemes of the built-in stores.
+const (
+	schemeFile     = "file"
+	schemeJournal  = "journal"
+	schemeSQLite   = "sqlite"
+	schemeTodoTxt  = "todotxt"
+	schemeMarkdown = "markdown"
+	schemeGit      = "git"
+	schemeMem      = "mem"
+)
+
+func init() {
+	Register(Backend{
+		Scheme:  schemeFile,
+		Example: "file:///path/to/tasks.json?backups=10&backup_interval=15m",
+		Options: []string{OptionBackups, OptionBackupInterval, OptionBackupDir},
+		Open: func(path string, opts url.Values) (TaskStore, error) {
+			policy := BackupPolicy{Dir: filepath.Join(filepath.Dir(path), "backups")}
+			if dir := opts.Get(OptionBackupDir); dir != "" {
+				policy.Dir = dir
+			}
+			if v := opts.Get(OptionBackups); v != "" {
+				keep, err := strconv.Atoi(v)
+				if err != nil || keep < 0 {
+					return nil, fmt.Errorf("option %s: want a number of backups, got %q", OptionBackups, v)
+				}
+				policy.Keep = keep
+			}
+			if v := opts.Get(OptionBackupInterval); v != "" {
+				interval, err := time.ParseDuration(v)
+				if err != nil || interval < 0 {
+					return nil, fmt.Errorf("option %s: want a duration such as 1h, got %q", OptionBackupInterval, v)
+				}
+				policy.Interval = interval
+			}
+			return NewFileTaskStoreWithBackups(path, policy), nil
+		},
+	})
+
+	Register(Backend{
+		Scheme:  schemeJournal,
+		Example: "journal:///path/to/tasks.json",
+		Options: []string{OptionCompactEvery},
+		Open: func(path string, opts url.Values) (TaskStore, error) {
+			s := NewJournalTaskStore(path)
+			if v := opts.Get(OptionCompactEvery); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 {
+					return nil, fmt.Errorf("option %s: want a positive number, got %q", OptionCompactEvery, v)
+				}
+				s.compactEvery = n
+			}
+			return s, nil
+		},
+	})
+
+	Register(Backend{
+		Scheme:  schemeSQLite,
+		Example: "sqlite:///path/to/tasks.db?import=/path/to/tasks.json",
+		Options: []string{OptionImport},
+		Open: func(path string, opts url.Values) (TaskStore, error) {
+			db, err := NewSQLiteTaskStore(path)
+			if err != nil {
+				return nil, err
+			}
+			if from := opts.Get(OptionImport); from != "" {
+				n, err := db.ImportFile(from)
+				if err != nil {
+					_ = db.Close()
+					return nil, fmt.Errorf("import %s: %w", from, err)
+				}
+				if n > 0 {
+					log.Info("imported tasks", "count", n, "from", from, "into", path)
+				}
+			}
+			return db, nil
+		},
+	})
+
+	Register(Backend{
+		Scheme:  schemeTodoTxt,
+		Example: "todotxt:///path/to/todo.txt",
+		Open: func(path string, _ url.Values) (TaskStore, error) {
+			return NewTodoTxtTaskStore(path), nil
+		},
+	})
+
+	Register(Backend{
+		Scheme:  schemeMarkdown,
+		Example: "markdown:///path/to/TASKS.md",
+		Open: func(path string, _ url.Values) (TaskStore, error) {
+			return NewMarkdownTaskStore(path), nil
+		},
+	})
+
+	Register(Backend{
+		Scheme:  schemeGit,
+		Example: "git:///path/to/repository",
+		Open: func(path string, _ url.Values) (TaskStore, error) {
+			return NewGitTaskStore(path)
+		},
+	})
+
+	Register(Backend{
+		Scheme:  schemeMem,
+		Example: "mem://",
+		Open: func(string, url.Values) (TaskStore, error) {
+			return NewMemTaskStore(), nil
+		},
+	})
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+func TestOpen_UnknownStore(t *testing.T) {
+	for _, uri := range []string{"floppy", "floppy:///tasks", ""} {
+		_, err := Open(uri, nil)
+		if !errors.Is(err, ErrUnknownStore) {
+			t.Fatalf("Open(%q) error = %v, want ErrUnknownStore", uri, err)
+		}
+		// The error tells users what they could have written.
+		if !strings.Contains(err.Error(), "sqlite") {
+			t.Fatalf("Open(%q) error = %q, want it to list the schemes", uri, err)
+		}
+	}
+}
+
+func TestOpen_Options(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.ToSlash(filepath.Join(dir, "tasks.json"))
+
+	if _, err := Open("todotxt://"+path+"?backups=3", nil); !errors.Is(err, ErrUnknownOption) {
+		t.Fatalf("Open() with an option todotxt does not take: error = %v, want ErrUnknownOption", err)
+	}
+	if _, err := Open("file://"+path+"?backups=many", nil); err == nil {
+		t.Fatal("Open() accepted backups=many")
+	}
+	if _, err := Open("file://", nil); err == nil {
+		t.Fatal("Open() accepted a file store without a path")
+	}
+
+	// Options in the URI win over the defaults; defaults meant for other
+	// stores are ignored.
+	defaults := url.Values{
+		OptionBackups:      {"1"},
+		OptionBackupDir:    {filepath.Join(dir, "ignored")},
+		OptionCompactEvery: {"7"},
+	}
+	opened, err := Open("file://"+path+"?backup_dir="+url.QueryEscape(filepath.Join(dir, "kept")), defaults)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	fs, ok := opened.(*FileTaskStore)
+	if !ok {
+		t.Fatalf("Open() = %T, want *FileTaskStore", opened)
+	}
+	if got, want := fs.BackupDir(), filepath.Join(dir, "kept"); got != want {
+		t.Fatalf("BackupDir() = %q, want %q", got, want)
+	}
+	if got := fs.Paths(); len(got) != 1 || got[0] != filepath.FromSlash(path) {
+		t.Fatalf("Paths() = %q, want %q", got, path)
+	}
+	if fs.backups.Keep != 1 {
+		t.Fatalf("backups kept = %d, want 1 from the defaults", fs.backups.Keep)
+	}
+}
+
+func TestOpen_Mem(t *testing.T) {
+	opened, err := Open("mem://", nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got, want := opened.Name(), DefaultMemName; got != want {
+		t.Fatalf("Name() = %q, want %q", got, want)
+	}
+
+	tasks := []task.Task{{ID: uuid.New(), TitleStr: "a", Tags: []string{"x"}}}
+	if err := opened.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	tasks[0].Tags[0] = "changed after saving"
+
+	loaded, err := opened.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Tags[0] != "x" {
+		t.Fatalf("Load() = %+v, want the task as it was saved", loaded)
+	}
+}
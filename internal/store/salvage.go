@@ -0,0 +1,323 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// ErrCorrupt matches the errors Load returns when the tasks file is
+// damaged, as a *CorruptError.
+var ErrCorrupt = errors.New("tasks file is damaged")
+
+// Damage is a part of a tasks file that could not be read.
+type Damage struct {
+	// Offset is the byte offset in the file where the damaged record, or
+	// the damage itself when it lies between records, starts.
+	Offset int64
+	Reason string
+}
+
+func (d Damage) String() string {
+	return fmt.Sprintf("byte %d: %s", d.Offset, d.Reason)
+}
+
+// CorruptError is returned by FileTaskStore.Load when the tasks file
+// cannot be read as a whole, such as after a bad hand edit. It holds
+// the tasks that could still be read; saving them puts them in place of
+// the damaged file.
+type CorruptError struct {
+	Path string
+
+	// Tasks are the records that could be read, in file order, and
+	// Damage says where and why the others could not.
+	Tasks  []task.Task
+	Damage []Damage
+
+	// Quarantined is an untouched copy of the damaged file, and Repaired
+	// a tasks file holding only Tasks. Both sit next to Path.
+	Quarantined string
+	Repaired    string
+}
+
+func (e *CorruptError) Error() string {
+	records := "records"
+	if len(e.Damage) == 1 {
+		records = "record"
+	}
+	return fmt.Sprintf("%s: %d damaged %s, %d tasks recovered into %s",
+		e.Path, len(e.Damage), records, len(e.Tasks), e.Repaired)
+}
+
+func (e *CorruptError) Is(target error) bool {
+	return target == ErrCorrupt
+}
+
+// quarantine salvages what it can from b, the contents of the damaged
+// tasks file, which failed to parse with cause. It keeps a copy of b and
+// writes the salvaged tasks to a repaired copy, both named after the
+// hash of b so that loading the same damage again adds no files, and
+// leaves the tasks file itself alone. The caller holds the lock.
+func (fts *FileTaskStore) quarantine(b []byte, cause error) error {
+	tasks, damage := salvageTasksFile(b, cause)
+
+	sum := sha256.Sum256(b)
+	tag := hex.EncodeToString(sum[:4])
+	e := &CorruptError{
+		Path:        fts.path,
+		Tasks:       tasks,
+		Damage:      damage,
+		Quarantined: fmt.Sprintf("%s.%s.damaged", fts.path, tag),
+		Repaired:    fmt.Sprintf("%s.%s.repaired", fts.path, tag),
+	}
+
+	// The copies may hold private tasks, so they get the file's
+	// permissions.
+	perm := writePerm(fts.path, fts.perm)
+	if !fileExists(e.Quarantined) {
+		if err := os.WriteFile(e.Quarantined, b, perm); err != nil {
+			return fmt.Errorf("quarantine damaged tasks file: %w", err)
+		}
+	}
+	repaired, err := encodeTasks(tasks)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(e.Repaired, repaired, perm); err != nil {
+		return fmt.Errorf("write repaired tasks file: %w", err)
+	}
+	return e
+}
+
+// versionKey finds the format version in an envelope too damaged to
+// decode.
+var versionKey = regexp.MustCompile(`"version"\s*:\s*(\d+)`)
+
+// salvageTasksFile reads every task record in b that decodes on its own
+// and reports where and why each other one does not. Records are told
+// apart by their brackets; when those do not match up, the next record
+// is taken to start on the next line that is indented like the records
+// before it. Damage outside the records is reported as the cause the
+// whole file failed to parse with.
+func salvageTasksFile(b []byte, cause error) ([]task.Task, []Damage) {
+	start, version := locateTasks(b)
+	if start < 0 {
+		return nil, []Damage{{Offset: 0, Reason: "no list of tasks found: " + cause.Error()}}
+	}
+
+	var (
+		tasks  []task.Task
+		damage []Damage
+		indent []byte
+		pos    = start + 1
+	)
+	for {
+		pos = skipSeparators(b, pos)
+		if pos >= len(b) {
+			damage = append(damage, Damage{Offset: int64(len(b)), Reason: "list of tasks is never closed"})
+			break
+		}
+		if b[pos] == ']' {
+			break
+		}
+		if indent == nil {
+			indent = lineIndent(b, pos)
+		}
+
+		end, closed := valueEnd(b, pos)
+		if !closed {
+			damage = append(damage, Damage{Offset: int64(pos), Reason: "record is never closed"})
+			if pos = nextRecord(b, pos, indent); pos < 0 {
+				break
+			}
+			continue
+		}
+
+		t, err := decodeRecord(b[pos:end], version)
+		var syntax *json.SyntaxError
+		switch {
+		case err == nil:
+			tasks = append(tasks, t)
+			pos = end
+		case errors.As(err, &syntax):
+			// The brackets of a record that is not valid JSON cannot
+			// be trusted to say where it ends.
+			damage = append(damage, Damage{Offset: int64(pos), Reason: err.Error()})
+			if next := nextRecord(b, pos, indent); next >= 0 {
+				pos = next
+			} else {
+				pos = end
+			}
+		default:
+			damage = append(damage, Damage{Offset: int64(pos), Reason: err.Error()})
+			pos = end
+		}
+	}
+
+	if len(damage) == 0 {
+		offset := int64(0)
+		var syntax *json.SyntaxError
+		if err := json.Unmarshal(b, new(any)); errors.As(err, &syntax) {
+			offset = syntax.Offset
+		}
+		damage = append(damage, Damage{Offset: offset, Reason: cause.Error()})
+	}
+	return tasks, damage
+}
+
+// locateTasks returns the offset of the opening bracket of the list of
+// task records in b and the format version of the file, or -1 when
+// there is no list to be found.
+func locateTasks(b []byte) (int, int) {
+	pos := skipSeparators(b, 0)
+	if pos < len(b) && b[pos] == '[' {
+		return pos, LegacyVersion
+	}
+
+	version := CurrentVersion
+	if m := versionKey.FindSubmatch(b); m != nil {
+		if v, err := strconv.Atoi(string(m[1])); err == nil {
+			version = v
+		}
+	}
+	key := bytes.Index(b, []byte(`"tasks"`))
+	if key < 0 {
+		return -1, version
+	}
+	pos = skipSpace(b, key+len(`"tasks"`))
+	if pos < len(b) && b[pos] == ':' {
+		pos = skipSpace(b, pos+1)
+	}
+	if pos >= len(b) || b[pos] != '[' {
+		return -1, version
+	}
+	return pos, version
+}
+
+// decodeRecord decodes a single task record of a file in the given
+// format version.
+func decodeRecord(rec []byte, version int) (task.Task, error) {
+	if rec[0] != '{' {
+		return task.Task{}, errors.New("not a task")
+	}
+	if version != CurrentVersion {
+		raw, err := migrate(append(append([]byte{'['}, rec...), ']'), version, CurrentVersion, migrations)
+		if err != nil {
+			return task.Task{}, err
+		}
+		var tasks []task.Task
+		if err := json.Unmarshal(raw, &tasks); err != nil {
+			return task.Task{}, err
+		}
+		if len(tasks) != 1 {
+			return task.Task{}, errors.New("record was dropped by a migration")
+		}
+		return tasks[0], nil
+	}
+
+	var t task.Task
+	err := json.Unmarshal(rec, &t)
+	return t, err
+}
+
+// valueEnd returns the offset just past the JSON value starting at
+// pos, and whether the value is closed before the end of b. Objects and
+// arrays end at their matching bracket; anything else ends before the
+// next comma, closing bracket, or line break.
+func valueEnd(b []byte, pos int) (int, bool) {
+	if b[pos] != '{' && b[pos] != '[' {
+		end := pos + 1
+		for end < len(b) && b[end] != ',' && b[end] != ']' && b[end] != '}' && b[end] != '\n' {
+			end++
+		}
+		return end, true
+	}
+
+	depth, inString := 0, false
+	for i := pos; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return len(b), false
+}
+
+// nextRecord returns where the record after the damaged one at pos
+// starts: the next line indented by indent that opens an object, or,
+// without an indent to go by, the next object that follows a "},".
+// It returns -1 when there is none.
+func nextRecord(b []byte, pos int, indent []byte) int {
+	if indent != nil {
+		line := append(append([]byte{'\n'}, indent...), '{')
+		if i := bytes.Index(b[pos+1:], line); i >= 0 {
+			return pos + 1 + i + len(line) - 1
+		}
+		return -1
+	}
+
+	for i := pos + 1; i < len(b); i++ {
+		if b[i] != '{' {
+			continue
+		}
+		j := bytes.TrimRight(b[:i], " \t\r\n")
+		if !bytes.HasSuffix(j, []byte(",")) {
+			continue
+		}
+		if bytes.HasSuffix(bytes.TrimRight(j[:len(j)-1], " \t\r\n"), []byte("}")) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lineIndent returns the white space before pos on its line, or nil
+// when anything else comes before pos there.
+func lineIndent(b []byte, pos int) []byte {
+	start := bytes.LastIndexByte(b[:pos], '\n')
+	if start < 0 {
+		return nil
+	}
+	indent := b[start+1 : pos]
+	if len(bytes.TrimLeft(indent, " \t")) > 0 {
+		return nil
+	}
+	return indent
+}
+
+func skipSpace(b []byte, pos int) int {
+	for pos < len(b) && (b[pos] == ' ' || b[pos] == '\t' || b[pos] == '\r' || b[pos] == '\n') {
+		pos++
+	}
+	return pos
+}
+
+// skipSeparators skips white space and the commas between records.
+func skipSeparators(b []byte, pos int) int {
+	for {
+		pos = skipSpace(b, pos)
+		if pos >= len(b) || b[pos] != ',' {
+			return pos
+		}
+		pos++
+	}
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+func TestFileTaskStore_LoadSalvagesDamagedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	first := task.Task{ID: uuid.New(), TitleStr: "first"}
+	second := task.Task{ID: uuid.New(), TitleStr: "second"}
+	third := task.Task{ID: uuid.New(), TitleStr: "third"}
+	if err := NewFileTaskStore(path).Save([]task.Task{first, second, third}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Break the second record the way a hand edit might: a title whose
+	// closing quote went missing.
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	damaged := bytes.Replace(b, []byte(`"TitleStr": "second"`), []byte(`"TitleStr": "second`), 1)
+	if err := os.WriteFile(path, damaged, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	recordStart := bytes.LastIndexByte(damaged[:bytes.Index(damaged, []byte(`"second`))], '{')
+
+	s := NewFileTaskStore(path)
+	_, err = s.Load()
+	var corrupt *CorruptError
+	if !errors.As(err, &corrupt) || !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Load() error = %v, want a *CorruptError", err)
+	}
+	assertSameTasks(t, corrupt.Tasks, []task.Task{first, third})
+	if len(corrupt.Damage) != 1 || corrupt.Damage[0].Offset != int64(recordStart) {
+		t.Fatalf("Damage = %v, want one record at byte %d", corrupt.Damage, recordStart)
+	}
+
+	// The damaged file is left alone and copied aside with the same
+	// permissions, next to a copy holding what was recovered.
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, damaged) {
+		t.Fatal("Load() changed the damaged file")
+	}
+	if got, _ := os.ReadFile(corrupt.Quarantined); !bytes.Equal(got, damaged) {
+		t.Fatal("quarantined copy differs from the damaged file")
+	}
+	if info, err := os.Stat(corrupt.Quarantined); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("quarantined copy: %v, %v; want mode 0600", info, err)
+	}
+	repaired, err := NewFileTaskStore(corrupt.Repaired).Load()
+	if err != nil {
+		t.Fatalf("loading the repaired copy: error = %v", err)
+	}
+	assertSameTasks(t, repaired, []task.Task{first, third})
+
+	// Loading the same damage again adds no files.
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if _, err := s.Load(); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("second Load() error = %v, want ErrCorrupt", err)
+	}
+	if again, _ := os.ReadDir(filepath.Dir(path)); len(again) != len(entries) {
+		t.Fatalf("second Load() left %d files, want %d", len(again), len(entries))
+	}
+
+	// Saving the recovered tasks replaces the damaged file.
+	if err := s.Save(corrupt.Tasks); err != nil {
+		t.Fatalf("Save() of recovered tasks error = %v", err)
+	}
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() after repair error = %v", err)
+	}
+	assertSameTasks(t, tasks, []task.Task{first, third})
+}
+
+func TestSalvageTasksFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		titles  []string
+		offsets []int64
+		reason  string
+	}{
+		{
+			name:    "wrong type in a legacy file",
+			file:    `[{"TitleStr":"a"},{"TitleStr":"b","Done":"yes"},{"TitleStr":"c"}]`,
+			titles:  []string{"a", "c"},
+			offsets: []int64{18},
+			reason:  "cannot unmarshal",
+		},
+		{
+			name:    "unclosed string on one line",
+			file:    `[{"TitleStr":"a"},{"TitleStr":"b},{"TitleStr":"c"}]`,
+			titles:  []string{"a", "c"},
+			offsets: []int64{18},
+		},
+		{
+			name: "missing brace in an envelope",
+			file: "{\n \"version\": 2,\n \"tasks\": [\n  {\n   \"TitleStr\": \"a\"\n  ,\n" +
+				"  {\n   \"TitleStr\": \"b\"\n  }\n ]\n}\n",
+			titles:  []string{"b"},
+			offsets: []int64{31},
+		},
+		{
+			name:    "cut short",
+			file:    `{"version": 2, "tasks": [{"TitleStr":"a"}, {"TitleStr":"b`,
+			titles:  []string{"a"},
+			offsets: []int64{43},
+			reason:  "never closed",
+		},
+		{
+			name:    "damage between records",
+			file:    `{"version": 2, "tasks": [{"TitleStr":"a"}]} trailing`,
+			titles:  []string{"a"},
+			offsets: []int64{45},
+		},
+		{
+			name:    "no tasks at all",
+			file:    `hello`,
+			offsets: []int64{0},
+			reason:  "no list of tasks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, cause := parseTasksFile([]byte(tt.file))
+			if cause == nil {
+				t.Fatal("parseTasksFile() accepted the damaged file")
+			}
+			tasks, damage := salvageTasksFile([]byte(tt.file), cause)
+
+			var titles []string
+			for _, tk := range tasks {
+				titles = append(titles, tk.Title())
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.titles, ",") {
+				t.Fatalf("salvaged %q, want %q", titles, tt.titles)
+			}
+			var offsets []int64
+			for _, d := range damage {
+				offsets = append(offsets, d.Offset)
+			}
+			if len(offsets) != len(tt.offsets) || len(offsets) > 0 && offsets[0] != tt.offsets[0] {
+				t.Fatalf("damage %v, want it at bytes %v", damage, tt.offsets)
+			}
+			if !strings.Contains(damage[0].Reason, tt.reason) {
+				t.Fatalf("damage reason %q, want it to mention %q", damage[0].Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestEncryptedTaskStore_LoadSalvagesDamagedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	secret := task.Task{ID: uuid.New(), TitleStr: "secret"}
+	if err := NewEncryptedTaskStore(NewFileTaskStore(path), []byte("pw")).Save([]task.Task{secret}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	end := bytes.LastIndexByte(b, ']')
+	damaged := append(b[:end:end], []byte(",\n  {\"ID\": 7}\n ]\n}\n")...)
+	if err := os.WriteFile(path, damaged, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, err = NewEncryptedTaskStore(NewFileTaskStore(path), []byte("pw")).Load()
+	var corrupt *CorruptError
+	if !errors.As(err, &corrupt) {
+		t.Fatalf("Load() error = %v, want a *CorruptError", err)
+	}
+	assertSameTasks(t, corrupt.Tasks, []task.Task{secret})
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqliteMigrations holds the schema changes of the SQLite store in
+// order. The schema version recorded in the database (PRAGMA
+// user_version) is the number of migrations applied so far; append new
+// steps to the end and never edit released ones.
+var sqliteMigrations = []string{
+	// 1: tasks and their tags, checklist items, and blockers.
+	`
+	CREATE TABLE tasks (
+		id            TEXT    PRIMARY KEY,
+		position      INTEGER NOT NULL,
+		title         TEXT    NOT NULL,
+		description   TEXT    NOT NULL,
+		due_date      INTEGER,
+		due_offset    INTEGER NOT NULL DEFAULT 0,
+		due_has_time  INTEGER NOT NULL DEFAULT 0,
+		due_zone      TEXT    NOT NULL DEFAULT '',
+		start_date    INTEGER,
+		start_offset  INTEGER NOT NULL DEFAULT 0,
+		done          INTEGER NOT NULL DEFAULT 0,
+		priority      INTEGER NOT NULL DEFAULT 0,
+		recurrence    TEXT,
+		list          TEXT    NOT NULL DEFAULT '',
+		created_at    INTEGER,
+		updated_at    INTEGER,
+		completed_at  INTEGER
+	);
+
+	CREATE TABLE task_tags (
+		task_id  TEXT    NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		tag      TEXT    NOT NULL,
+		PRIMARY KEY (task_id, position)
+	);
+
+	CREATE TABLE subtasks (
+		id       TEXT    NOT NULL,
+		task_id  TEXT    NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		title    TEXT    NOT NULL,
+		done     INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (task_id, position)
+	);
+
+	CREATE TABLE task_blockers (
+		task_id    TEXT    NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		position   INTEGER NOT NULL,
+		blocker_id TEXT    NOT NULL,
+		PRIMARY KEY (task_id, position)
+	);
+
+	CREATE TABLE meta (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	`,
+
+	// 2: indexes for looking tasks up by due date and completion.
+	`
+	CREATE INDEX idx_tasks_due_date ON tasks (due_date);
+	CREATE INDEX idx_tasks_done ON tasks (done);
+	`,
+
+	// 3: an index for looking up the tasks blocked by a task.
+	`
+	CREATE INDEX idx_task_blockers_blocker ON task_blockers (blocker_id);
+	`,
+}
+
+// migrateSQLite brings the schema of db up to date, applying each
+// pending migration in its own transaction.
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("%w: database schema is version %d, newest supported is %d",
+			ErrUnsupportedVersion, version, len(sqliteMigrations))
+	}
+
+	for v := version; v < len(sqliteMigrations); v++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[v]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migrate schema to version %d: %w", v+1, err)
+		}
+		// PRAGMA does not accept bound parameters.
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migrate schema to version %d: %w", v+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migrate schema to version %d: %w", v+1, err)
+		}
+	}
+	return nil
+}
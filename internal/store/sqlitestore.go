@@ -0,0 +1,494 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+
+	// Pure-Go SQLite driver, so builds keep working with CGO_ENABLED=0.
+	_ "modernc.org/sqlite"
+)
+
+const (
+	DefaultSQLiteName = "SQLite Store"
+
+	// metaImportedFrom records the file tasks were imported from, so
+	// ImportFile only runs once per database.
+	metaImportedFrom = "imported_from"
+)
+
+// SQLiteTaskStore keeps tasks in a SQLite database, with tags,
+// checklist items, and blockers in tables of their own.
+type SQLiteTaskStore struct {
+	db   *sql.DB
+	path string
+	name string
+}
+
+// NewSQLiteTaskStore opens the database at path, creating it if needed,
+// and brings its schema up to date. Call Close when done with it.
+func NewSQLiteTaskStore(path string) (*SQLiteTaskStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	dsn := (&url.URL{
+		Scheme:   "file",
+		Path:     path,
+		RawQuery: "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+	}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	// A single connection keeps writes serialized within the process.
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &SQLiteTaskStore{db: db, path: path, name: DefaultSQLiteName}, nil
+}
+
+func (s *SQLiteTaskStore) Name() string {
+	return s.name
+}
+
+func (s *SQLiteTaskStore) Paths() []string {
+	return []string{s.path}
+}
+
+// Close closes the underlying database.
+func (s *SQLiteTaskStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteTaskStore) Load() ([]task.Task, error) {
+	return s.query("")
+}
+
+// Get reads the task with the given ID and its child rows only.
+func (s *SQLiteTaskStore) Get(id uuid.UUID) (task.Task, error) {
+	tasks, err := s.query(id.String())
+	if err != nil {
+		return task.Task{}, err
+	}
+	if len(tasks) == 0 {
+		return task.Task{}, fmt.Errorf("task %s: %w", id, ErrTaskNotFound)
+	}
+	return tasks[0], nil
+}
+
+// query reads the task with the given ID, or every task when id is
+// empty, in order.
+func (s *SQLiteTaskStore) query(id string) ([]task.Task, error) {
+	where, args := "", []any(nil)
+	if id != "" {
+		where, args = "WHERE id = ?", []any{id}
+	}
+	rows, err := s.db.Query(`
+		SELECT id, title, description,
+		       due_date, due_offset, due_has_time, due_zone,
+		       start_date, start_offset,
+		       done, priority, recurrence, list,
+		       created_at, updated_at, completed_at
+		FROM tasks
+		`+where+`
+		ORDER BY position`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []task.Task{}
+	byID := map[uuid.UUID]int{}
+	for rows.Next() {
+		var (
+			t                           task.Task
+			id                          string
+			due, start                  sql.NullInt64
+			dueOffset, startOffset      int
+			recurrence                  sql.NullString
+			created, updated, completed sql.NullInt64
+		)
+		if err := rows.Scan(
+			&id, &t.TitleStr, &t.DescStr,
+			&due, &dueOffset, &t.DueHasTime, &t.DueZone,
+			&start, &startOffset,
+			&t.Done, &t.Priority, &recurrence, &t.List,
+			&created, &updated, &completed,
+		); err != nil {
+			return nil, err
+		}
+		if t.ID, err = uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("task %q: %w", id, err)
+		}
+		t.DueDate = fromSQLTime(due, dueOffset)
+		t.StartDate = fromSQLTime(start, startOffset)
+		t.CreatedAt = fromSQLTime(created, localOffset)
+		t.UpdatedAt = fromSQLTime(updated, localOffset)
+		t.CompletedAt = fromSQLTime(completed, localOffset)
+		if recurrence.Valid {
+			if err := json.Unmarshal([]byte(recurrence.String), &t.Recurrence); err != nil {
+				return nil, fmt.Errorf("task %q recurrence: %w", id, err)
+			}
+		}
+
+		byID[t.ID] = len(tasks)
+		tasks = append(tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadChildren(tasks, byID, id); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// loadChildren fills in the tags, checklist items, and blockers of the
+// loaded tasks: those of the task with the given ID, or of every task
+// when id is empty.
+func (s *SQLiteTaskStore) loadChildren(tasks []task.Task, byID map[uuid.UUID]int, id string) error {
+	where, args := "", []any(nil)
+	if id != "" {
+		where, args = "WHERE task_id = ?", []any{id}
+	}
+
+	err := s.eachChild(`SELECT task_id, tag FROM task_tags `+where+` ORDER BY task_id, position`, args,
+		tasks, byID, func(t *task.Task, rows *sql.Rows) error {
+			var tag string
+			if err := rows.Scan(new(string), &tag); err != nil {
+				return err
+			}
+			t.Tags = append(t.Tags, tag)
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	err = s.eachChild(`SELECT task_id, id, title, done FROM subtasks `+where+` ORDER BY task_id, position`, args,
+		tasks, byID, func(t *task.Task, rows *sql.Rows) error {
+			var (
+				sub task.Subtask
+				id  string
+			)
+			if err := rows.Scan(new(string), &id, &sub.TitleStr, &sub.Done); err != nil {
+				return err
+			}
+			subID, err := uuid.Parse(id)
+			if err != nil {
+				return fmt.Errorf("subtask %q: %w", id, err)
+			}
+			sub.ID = subID
+			t.Subtasks = append(t.Subtasks, sub)
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	return s.eachChild(`SELECT task_id, blocker_id FROM task_blockers `+where+` ORDER BY task_id, position`, args,
+		tasks, byID, func(t *task.Task, rows *sql.Rows) error {
+			var blocker string
+			if err := rows.Scan(new(string), &blocker); err != nil {
+				return err
+			}
+			id, err := uuid.Parse(blocker)
+			if err != nil {
+				return fmt.Errorf("blocker %q: %w", blocker, err)
+			}
+			t.BlockedBy = append(t.BlockedBy, id)
+			return nil
+		})
+}
+
+// eachChild runs query with args, whose first column is the ID of the
+// task a row belongs to, and calls scan with that task for every row.
+func (s *SQLiteTaskStore) eachChild(
+	query string,
+	args []any,
+	tasks []task.Task,
+	byID map[uuid.UUID]int,
+	scan func(*task.Task, *sql.Rows) error,
+) error {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		// Read the task ID first; scan reads the whole row again.
+		var taskID string
+		dest := make([]any, len(cols))
+		dest[0] = &taskID
+		for i := 1; i < len(dest); i++ {
+			dest[i] = new(any)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		id, err := uuid.Parse(taskID)
+		if err != nil {
+			return fmt.Errorf("task %q: %w", taskID, err)
+		}
+		i, ok := byID[id]
+		if !ok {
+			continue
+		}
+		if err := scan(&tasks[i], rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Save replaces every stored task with tasks, in one transaction. Tasks
+// without an ID are given one, since the ID is the table's key.
+func (s *SQLiteTaskStore) Save(tasks []task.Task) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.Exec(`DELETE FROM tasks`); err != nil {
+		return err
+	}
+	for i, t := range tasks {
+		if t.ID == uuid.Nil {
+			t.ID = uuid.New()
+		}
+		if err := insertTask(tx, i, t); err != nil {
+			return fmt.Errorf("save task %q: %w", t.Title(), err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Put writes t in one transaction, touching only its own rows. A new
+// task goes after all others.
+func (s *SQLiteTaskStore) Put(t task.Task) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var position int
+	err = tx.QueryRow(`SELECT position FROM tasks WHERE id = ?`, t.ID.String()).Scan(&position)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM tasks`).Scan(&position); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	}
+
+	// Deleting the row deletes its child rows too.
+	if _, err := tx.Exec(`DELETE FROM tasks WHERE id = ?`, t.ID.String()); err != nil {
+		return err
+	}
+	if err := insertTask(tx, position, t); err != nil {
+		return fmt.Errorf("save task %q: %w", t.Title(), err)
+	}
+	return tx.Commit()
+}
+
+// Dependents reads the tasks blocked by the task with the given ID, and
+// their child rows, only.
+func (s *SQLiteTaskStore) Dependents(id uuid.UUID) ([]task.Task, error) {
+	rows, err := s.db.Query(`
+		SELECT id FROM tasks
+		WHERE id IN (SELECT task_id FROM task_blockers WHERE blocker_id = ?)
+		ORDER BY position`, id.String())
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var dependent string
+		if err := rows.Scan(&dependent); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		ids = append(ids, dependent)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tasks := []task.Task{}
+	for _, dependent := range ids {
+		found, err := s.query(dependent)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, found...)
+	}
+	return tasks, nil
+}
+
+// Delete removes the task with the given ID and its child rows.
+func (s *SQLiteTaskStore) Delete(id uuid.UUID) error {
+	_, err := s.db.Exec(`DELETE FROM tasks WHERE id = ?`, id.String())
+	return err
+}
+
+// insertTask writes t and its child rows at the given position.
+func insertTask(tx *sql.Tx, position int, t task.Task) error {
+	var recurrence sql.NullString
+	if t.Recurrence != nil {
+		b, err := json.Marshal(t.Recurrence)
+		if err != nil {
+			return err
+		}
+		recurrence = sql.NullString{String: string(b), Valid: true}
+	}
+
+	due, dueOffset := sqlTime(t.DueDate)
+	start, startOffset := sqlTime(t.StartDate)
+	created, _ := sqlTime(t.CreatedAt)
+	updated, _ := sqlTime(t.UpdatedAt)
+	completed, _ := sqlTime(t.CompletedAt)
+
+	id := t.ID.String()
+	_, err := tx.Exec(`
+		INSERT INTO tasks (
+			id, position, title, description,
+			due_date, due_offset, due_has_time, due_zone,
+			start_date, start_offset,
+			done, priority, recurrence, list,
+			created_at, updated_at, completed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, position, t.TitleStr, t.DescStr,
+		due, dueOffset, t.DueHasTime, t.DueZone,
+		start, startOffset,
+		t.Done, t.Priority, recurrence, t.List,
+		created, updated, completed,
+	)
+	if err != nil {
+		return err
+	}
+
+	for i, tag := range t.Tags {
+		if _, err := tx.Exec(`INSERT INTO task_tags (task_id, position, tag) VALUES (?, ?, ?)`,
+			id, i, tag); err != nil {
+			return err
+		}
+	}
+	for i, sub := range t.Subtasks {
+		if _, err := tx.Exec(`INSERT INTO subtasks (id, task_id, position, title, done) VALUES (?, ?, ?, ?, ?)`,
+			sub.ID.String(), id, i, sub.TitleStr, sub.Done); err != nil {
+			return err
+		}
+	}
+	for i, blocker := range t.BlockedBy {
+		if _, err := tx.Exec(`INSERT INTO task_blockers (task_id, position, blocker_id) VALUES (?, ?, ?)`,
+			id, i, blocker.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportFile copies the tasks of the JSON tasks file at path into the
+// database, once, reading the file as it is and leaving it untouched.
+// It returns the number of tasks imported, which is zero when an import
+// already happened, the file does not exist, or the database already
+// holds tasks. Either way the database is marked as imported, so tasks
+// kept in it are never mixed with those of a tasks file created later.
+func (s *SQLiteTaskStore) ImportFile(path string) (int, error) {
+	var from string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = ?`, metaImportedFrom).Scan(&from)
+	switch {
+	case err == nil:
+		return 0, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, err
+	}
+
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM tasks`).Scan(&count); err != nil {
+		return 0, err
+	}
+	var tasks []task.Task
+	if count == 0 {
+		tasks, err = ReadTasksFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, err
+		}
+	}
+
+	if len(tasks) > 0 {
+		if err := s.Save(tasks); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := s.db.Exec(`INSERT INTO meta (key, value) VALUES (?, ?)`, metaImportedFrom, path); err != nil {
+		return 0, err
+	}
+	return len(tasks), nil
+}
+
+// localOffset marks times that are shown in local time, so their
+// original zone offset is not kept.
+const localOffset = -1
+
+// sqlTime splits t into Unix nanoseconds and its zone offset in
+// seconds. The zero time is stored as NULL.
+func sqlTime(t time.Time) (sql.NullInt64, int) {
+	if t.IsZero() {
+		return sql.NullInt64{}, 0
+	}
+	_, offset := t.Zone()
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}, offset
+}
+
+// fromSQLTime rebuilds a time stored by sqlTime. Like times decoded from
+// JSON, it is in the local zone when that has the recorded offset, and
+// in a fixed zone with that offset otherwise. Due and start dates rely
+// on this to stay on the calendar day they were picked on.
+func fromSQLTime(n sql.NullInt64, offset int) time.Time {
+	if !n.Valid {
+		return time.Time{}
+	}
+	t := time.Unix(0, n.Int64)
+	if _, local := t.Zone(); offset == localOffset || offset == local {
+		return t
+	}
+	return t.In(time.FixedZone("", offset))
+}
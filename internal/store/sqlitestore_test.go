@@ -0,0 +1,309 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// newTempSQLiteStore opens a SQLiteTaskStore in a temporary directory
+// and closes it when the test ends.
+func newTempSQLiteStore(t *testing.T) (*SQLiteTaskStore, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	s, err := NewSQLiteTaskStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteTaskStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s, path
+}
+
+// sampleTasks returns tasks using every field the stores persist.
+func sampleTasks() []task.Task {
+	berlin := time.FixedZone("", 2*60*60)
+	stamp := time.Date(2030, 1, 2, 9, 30, 0, 0, time.Local)
+	rule, _ := task.ParseRecurrence("every 2 weeks")
+	first := uuid.New()
+
+	return []task.Task{
+		{
+			ID:        first,
+			TitleStr:  "write report",
+			DescStr:   "quarterly numbers",
+			DueDate:   time.Date(2030, 1, 10, 0, 0, 0, 0, berlin),
+			Priority:  task.PriorityHigh,
+			Tags:      []string{"work", "finance"},
+			List:      "Work",
+			CreatedAt: stamp,
+			UpdatedAt: stamp,
+		},
+		{
+			ID:         uuid.New(),
+			TitleStr:   "standup",
+			DescStr:    "daily",
+			DueDate:    time.Date(2030, 1, 3, 9, 0, 0, 0, berlin),
+			DueHasTime: true,
+			DueZone:    "Europe/Berlin",
+			StartDate:  time.Date(2030, 1, 1, 0, 0, 0, 0, time.Local),
+			Done:       true,
+			Subtasks: []task.Subtask{
+				{ID: uuid.New(), TitleStr: "notes", Done: true},
+				{ID: uuid.New(), TitleStr: "follow up"},
+			},
+			Recurrence:  rule,
+			BlockedBy:   []uuid.UUID{first},
+			CompletedAt: stamp,
+		},
+	}
+}
+
+// assertSameTasks compares tasks through their JSON form, which is
+// what the file store persists.
+func assertSameTasks(t *testing.T, got, want []task.Task) {
+	t.Helper()
+
+	g, _ := json.Marshal(got)
+	w, _ := json.Marshal(want)
+	if string(g) != string(w) {
+		t.Fatalf("tasks differ\n got: %s\nwant: %s", g, w)
+	}
+}
+
+func TestSQLiteTaskStore_SaveLoadRoundTrip(t *testing.T) {
+	s, path := newTempSQLiteStore(t)
+
+	if got, want := s.Name(), DefaultSQLiteName; got != want {
+		t.Fatalf("Name() = %q, want %q", got, want)
+	}
+
+	original := sampleTasks()
+	if err := s.Save(original); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reopened, err := NewSQLiteTaskStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteTaskStore() error = %v", err)
+	}
+	defer reopened.Close()
+
+	loaded, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, original)
+}
+
+func TestSQLiteTaskStore_SaveReplacesTasks(t *testing.T) {
+	s, _ := newTempSQLiteStore(t)
+
+	tasks := sampleTasks()
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// Dropping a task removes its child rows with it.
+	if err := s.Save(tasks[:1]); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, tasks[:1])
+
+	var subtasks int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM subtasks`).Scan(&subtasks); err != nil {
+		t.Fatalf("count subtasks: %v", err)
+	}
+	if subtasks != 0 {
+		t.Errorf("subtasks rows = %d after deleting their task, want 0", subtasks)
+	}
+}
+
+func TestSQLiteTaskStore_Load_Empty(t *testing.T) {
+	s, _ := newTempSQLiteStore(t)
+
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if tasks == nil || len(tasks) != 0 {
+		t.Fatalf("Load() = %v, want empty slice", tasks)
+	}
+}
+
+func TestSQLiteTaskStore_SaveAssignsMissingIDs(t *testing.T) {
+	s, _ := newTempSQLiteStore(t)
+
+	if err := s.Save([]task.Task{{TitleStr: "a"}, {TitleStr: "b"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].ID == uuid.Nil || loaded[0].ID == loaded[1].ID {
+		t.Fatalf("Load() = %+v, want two tasks with distinct IDs", loaded)
+	}
+}
+
+func TestSQLiteTaskStore_Schema(t *testing.T) {
+	s, _ := newTempSQLiteStore(t)
+
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		t.Fatalf("read user_version: %v", err)
+	}
+	if version != len(sqliteMigrations) {
+		t.Errorf("schema version = %d, want %d", version, len(sqliteMigrations))
+	}
+
+	for _, index := range []string{"idx_tasks_due_date", "idx_tasks_done", "idx_task_blockers_blocker"} {
+		var name string
+		err := s.db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'index' AND name = ?`, index).Scan(&name)
+		if err != nil {
+			t.Errorf("index %s missing: %v", index, err)
+		}
+	}
+
+	// Migrating an up-to-date database is a no-op.
+	if err := migrateSQLite(s.db); err != nil {
+		t.Errorf("migrateSQLite() on current schema error = %v", err)
+	}
+}
+
+func TestSQLiteTaskStore_NewerSchema(t *testing.T) {
+	s, path := newTempSQLiteStore(t)
+	if _, err := s.db.Exec(`PRAGMA user_version = 99`); err != nil {
+		t.Fatalf("set user_version: %v", err)
+	}
+	_ = s.Close()
+
+	if _, err := NewSQLiteTaskStore(path); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("NewSQLiteTaskStore() error = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestSQLiteTaskStore_ImportFile(t *testing.T) {
+	s, _ := newTempSQLiteStore(t)
+	jsonStore, jsonPath := newTempStore(t, "tasks.json")
+
+	original := sampleTasks()
+	if err := jsonStore.Save(original); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	n, err := s.ImportFile(jsonPath)
+	if err != nil {
+		t.Fatalf("ImportFile() error = %v", err)
+	}
+	if n != len(original) {
+		t.Fatalf("ImportFile() = %d, want %d", n, len(original))
+	}
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, original)
+
+	// The import only happens once, even after the tasks are gone.
+	if err := s.Save(nil); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if n, err := s.ImportFile(jsonPath); n != 0 || err != nil {
+		t.Fatalf("second ImportFile() = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestSQLiteTaskStore_ImportFile_NotEmptyOrMissing(t *testing.T) {
+	s, dbPath := newTempSQLiteStore(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+
+	// Nothing to import: the database is used as it is from then on.
+	if n, err := s.ImportFile(path); n != 0 || err != nil {
+		t.Fatalf("ImportFile(missing) = %d, %v; want 0, nil", n, err)
+	}
+	mine := []task.Task{{ID: uuid.New(), TitleStr: "already here"}}
+	if err := s.Save(mine); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A tasks file created later, say by a run with the file store, is
+	// not imported on top, and opening the store still works.
+	if err := os.WriteFile(path, []byte(`[{"TitleStr": "old"}]`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	reopened, err := Open("sqlite://"+dbPath, url.Values{OptionImport: {path}})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reopened.(*SQLiteTaskStore).Close()
+	loaded, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, mine)
+}
+
+func TestSQLiteTaskStore_ImportFile_MarksStoreWithTasks(t *testing.T) {
+	s, _ := newTempSQLiteStore(t)
+	mine := []task.Task{{ID: uuid.New(), TitleStr: "already here"}}
+	if err := s.Save(mine); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(path, []byte(`[{"TitleStr": "old"}]`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if n, err := s.ImportFile(path); n != 0 || err != nil {
+		t.Fatalf("ImportFile() = %d, %v; want 0, nil", n, err)
+	}
+	// Even once the tasks are gone.
+	if err := s.Save(nil); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if n, err := s.ImportFile(path); n != 0 || err != nil {
+		t.Fatalf("second ImportFile() = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestSQLiteTaskStore_ImportFile_LeavesFileUntouched(t *testing.T) {
+	s, _ := newTempSQLiteStore(t)
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	// A legacy tasks file would be upgraded and backed up if loaded
+	// through a file store.
+	const legacy = `[{"TitleStr": "Old task", "DescStr": "", "Done": false}]`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if n, err := s.ImportFile(path); n != 1 || err != nil {
+		t.Fatalf("ImportFile() = %d, %v; want 1, nil", n, err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != legacy {
+		t.Fatalf("tasks file = %q, %v; want it unchanged", b, err)
+	}
+	if others, _ := filepath.Glob(path + ".*"); len(others) != 0 {
+		t.Fatalf("files written next to the tasks file: %v", others)
+	}
+}
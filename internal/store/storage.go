@@ -1,9 +1,29 @@
 package store
 
-import "github.com/jacobdanielrose/terminaltask/internal/task"
+import (
+	"os"
+
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
 
 type TaskStore interface {
 	Load() ([]task.Task, error)
 	Save([]task.Task) error
 	Name() string
 }
+
+// FileBacked is implemented by stores that keep their data in files,
+// so that changes other programs make to them can be watched.
+type FileBacked interface {
+	// Paths returns the files holding the store's data.
+	Paths() []string
+}
+
+// FilePermSetter is implemented by stores that write files, so that the
+// permissions of files they create can be chosen, such as by an
+// EncryptedTaskStore keeping them private.
+type FilePermSetter interface {
+	// SetFilePerm makes the store write its files, and any copies of
+	// them it keeps, with perm. Call it before using the store.
+	SetFilePerm(perm os.FileMode)
+}
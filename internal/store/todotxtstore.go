@@ -0,0 +1,540 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+const (
+	DefaultTodoTxtName = "todo.txt Store"
+
+	// todoTxtDate is the layout of every date in a todo.txt file.
+	todoTxtDate = "2006-01-02"
+)
+
+// Extension keys understood by other todo.txt tools.
+const (
+	todoTxtDue       = "due"
+	todoTxtThreshold = "t"
+	todoTxtRecur     = "rec"
+	todoTxtPriority  = "pri"
+)
+
+// Extension keys only terminaltask uses, for the parts of a task that
+// todo.txt has no syntax for. They start with todoTxtPrefix, and are
+// hidden from the user.
+const (
+	todoTxtPrefix    = "tt-"
+	todoTxtID        = todoTxtPrefix + "id"
+	todoTxtDesc      = todoTxtPrefix + "desc"
+	todoTxtDueAt     = todoTxtPrefix + "at"
+	todoTxtRule      = todoTxtPrefix + "rec"
+	todoTxtList      = todoTxtPrefix + "list"
+	todoTxtBlockedBy = todoTxtPrefix + "after"
+	todoTxtOpenItem  = todoTxtPrefix + "todo"
+	todoTxtDoneItem  = todoTxtPrefix + "done"
+	todoTxtUpdated   = todoTxtPrefix + "updated"
+)
+
+// todoTxtNamespace derives IDs for lines that do not carry one yet, such
+// as those written by other tools, so they keep the same ID from one
+// load to the next.
+var todoTxtNamespace = uuid.MustParse("8ff0fba9-7ded-4c91-a01e-16b09fa37cd3")
+
+var (
+	todoTxtPriorityRe  = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtExtensionRe = regexp.MustCompile(`^([A-Za-z][\w-]*):(\S+)$`)
+	todoTxtRecurRe     = regexp.MustCompile(`^(\+?)([1-9][0-9]*)([dw])$`)
+)
+
+// todoTxtLetters maps priorities to todo.txt priority letters. Letters
+// after D are read as PriorityLow.
+var todoTxtLetters = map[task.Priority]string{
+	task.PriorityUrgent: "A",
+	task.PriorityHigh:   "B",
+	task.PriorityMedium: "C",
+	task.PriorityLow:    "D",
+}
+
+// todoTxtEscaper escapes the values terminaltask stores in extensions,
+// which must not contain whitespace.
+var todoTxtEscaper = strings.NewReplacer(
+	"%", "%25",
+	" ", "%20",
+	"\t", "%09",
+	"\n", "%0A",
+	"\r", "%0D",
+)
+
+// TodoTxtTaskStore keeps tasks in a todo.txt file, one task per line,
+// so they can be shared with other todo.txt tools:
+//
+//	x 2030-01-03 2030-01-01 Call Mom +Family @phone due:2030-01-02 tt-id:<uuid>
+//
+// Completion, priority, creation and completion dates, the list (as a
+// +project), tags (as @contexts), due and start dates (due: and t:), and
+// simple recurrences (rec:) use the usual syntax. The rest of a task,
+// including its ID, is kept in extensions starting with "tt-". Projects
+// after the first and extensions terminaltask does not know are kept as
+// they are.
+type TodoTxtTaskStore struct {
+	path string
+	name string
+
+	// mu guards revision, the hash of the file as last loaded or saved
+	// by this store, and extras, the words of each task this store does
+	// not understand. seen is false until the file is loaded or saved.
+	mu       sync.Mutex
+	revision [sha256.Size]byte
+	seen     bool
+	extras   map[uuid.UUID][]string
+
+	// perm, when set, is the permissions the file is written with;
+	// otherwise it keeps its own.
+	perm os.FileMode
+}
+
+// NewTodoTxtTaskStore returns a store that keeps tasks in the todo.txt
+// file at path.
+func NewTodoTxtTaskStore(path string) *TodoTxtTaskStore {
+	return &TodoTxtTaskStore{
+		path:   path,
+		name:   DefaultTodoTxtName,
+		extras: map[uuid.UUID][]string{},
+	}
+}
+
+func (s *TodoTxtTaskStore) Name() string {
+	return s.name
+}
+
+func (s *TodoTxtTaskStore) Paths() []string {
+	return []string{s.path}
+}
+
+func (s *TodoTxtTaskStore) SetFilePerm(perm os.FileMode) {
+	s.perm = perm
+}
+
+func (s *TodoTxtTaskStore) Load() ([]task.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path + lockSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	b, err := readIfExists(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []task.Task{}
+	extras := map[uuid.UUID][]string{}
+	seenIDs := map[uuid.UUID]bool{}
+	seenLines := map[string]int{}
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		t, extra := parseTodoTxtLine(line)
+
+		// Lines without an ID are named after their text, and copies of
+		// a line get IDs of their own.
+		if t.ID == uuid.Nil {
+			t.ID = uuid.NewSHA1(todoTxtNamespace, []byte(line+"\x00"+strconv.Itoa(seenLines[line])))
+			seenLines[line]++
+		}
+		for n := 1; seenIDs[t.ID]; n++ {
+			t.ID = uuid.NewSHA1(t.ID, []byte(strconv.Itoa(n)))
+		}
+		seenIDs[t.ID] = true
+		for i, sub := range t.Subtasks {
+			t.Subtasks[i].ID = uuid.NewSHA1(t.ID, []byte(strconv.Itoa(i)+" "+sub.TitleStr))
+		}
+
+		tasks = append(tasks, t)
+		if len(extra) > 0 {
+			extras[t.ID] = extra
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.path, err)
+	}
+
+	s.revision, s.seen = sha256.Sum256(b), true
+	s.extras = extras
+	return tasks, nil
+}
+
+// Save writes tasks to the file, unless another program changed it
+// since it was last loaded.
+func (s *TodoTxtTaskStore) Save(tasks []task.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path + lockSuffix)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := readIfExists(s.path)
+	if err != nil {
+		return err
+	}
+	if s.seen && sha256.Sum256(current) != s.revision {
+		return fmt.Errorf("%s: %w", s.path, ErrStaleSave)
+	}
+
+	var b bytes.Buffer
+	for _, t := range tasks {
+		b.WriteString(formatTodoTxtLine(t, s.extras[t.ID]))
+		b.WriteByte('\n')
+	}
+	if err := writeFileAtomic(s.path, b.Bytes(), writePerm(s.path, s.perm)); err != nil {
+		return err
+	}
+	s.revision, s.seen = sha256.Sum256(b.Bytes()), true
+	return nil
+}
+
+// todoTxtLine collects a task while reading a line of a todo.txt file.
+type todoTxtLine struct {
+	task.Task
+
+	// extra holds the words that were not understood, in order.
+	extra []string
+
+	title   []string
+	letter  string
+	dueDay  time.Time
+	dueAt   string
+	hasList bool
+}
+
+// parseTodoTxtLine reads a task from a line of a todo.txt file. It also
+// returns the words it does not understand. Every line makes a task;
+// values that cannot be read are kept as they are.
+func parseTodoTxtLine(line string) (task.Task, []string) {
+	var l todoTxtLine
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		l.Done = true
+		words = words[1:]
+		if d, ok := parseTodoTxtDate(words); ok {
+			l.CompletedAt = d
+			words = words[1:]
+		}
+	} else if len(words) > 0 {
+		if m := todoTxtPriorityRe.FindStringSubmatch(words[0]); m != nil {
+			l.letter = m[1]
+			words = words[1:]
+		}
+	}
+	if d, ok := parseTodoTxtDate(words); ok {
+		l.CreatedAt = d
+		words = words[1:]
+	}
+
+	for _, w := range words {
+		l.word(w)
+	}
+
+	l.TitleStr = strings.Join(l.title, " ")
+	for p, letter := range todoTxtLetters {
+		if letter == l.letter {
+			l.Priority = p
+		}
+	}
+	if l.letter != "" && l.Priority == task.PriorityNone {
+		l.Priority = task.PriorityLow
+	}
+	if !l.dueDay.IsZero() {
+		if err := l.SetDue(l.dueDay, l.dueAt); err != nil {
+			_ = l.SetDue(l.dueDay, "")
+		}
+	}
+	return l.Task, l.extra
+}
+
+// word reads a word of the line after its dates and priority.
+func (l *todoTxtLine) word(w string) {
+	switch {
+	case len(w) > 1 && w[0] == '\\':
+		l.title = append(l.title, w[1:])
+
+	case len(w) > 1 && w[0] == '+':
+		if l.hasList {
+			l.extra = append(l.extra, w)
+			return
+		}
+		l.hasList = true
+		if l.List == "" {
+			l.List = task.NormalizeList(w[1:])
+		}
+
+	case len(w) > 1 && w[0] == '@':
+		if tag := task.NormalizeTag(w[1:]); tag != "" && !slices.Contains(l.Tags, tag) {
+			l.Tags = append(l.Tags, tag)
+		}
+
+	case todoTxtExtensionRe.MatchString(w) && !strings.Contains(w, "://"):
+		key, value, _ := strings.Cut(w, ":")
+		if !l.extension(key, value) {
+			l.extra = append(l.extra, w)
+		}
+
+	default:
+		l.title = append(l.title, w)
+	}
+}
+
+// extension sets the part of the task named by key to value. It reports
+// false when the key is unknown or the value cannot be read.
+func (l *todoTxtLine) extension(key, value string) bool {
+	switch key {
+	case todoTxtDue:
+		d, err := time.ParseInLocation(todoTxtDate, value, time.Local)
+		l.dueDay = d
+		return err == nil
+
+	case todoTxtThreshold:
+		d, err := time.ParseInLocation(todoTxtDate, value, time.Local)
+		l.StartDate = d
+		return err == nil
+
+	case todoTxtRecur:
+		m := todoTxtRecurRe.FindStringSubmatch(value)
+		if m == nil {
+			return false
+		}
+		interval, _ := strconv.Atoi(m[2])
+		r := &task.Recurrence{Kind: task.RecurDays, Interval: interval, AfterCompletion: m[1] == ""}
+		if m[3] == "w" {
+			r.Kind = task.RecurWeeks
+		}
+		l.Recurrence = r
+		return true
+
+	case todoTxtPriority:
+		if !l.Done || len(value) != 1 || value[0] < 'A' || value[0] > 'Z' {
+			return false
+		}
+		l.letter = value
+		return true
+	}
+
+	if !strings.HasPrefix(key, todoTxtPrefix) {
+		return false
+	}
+	value, err := url.PathUnescape(value)
+	if err != nil {
+		return false
+	}
+
+	switch key {
+	case todoTxtID:
+		id, err := uuid.Parse(value)
+		l.ID = id
+		return err == nil
+
+	case todoTxtDesc:
+		l.DescStr = value
+
+	case todoTxtDueAt:
+		l.dueAt = value
+
+	case todoTxtRule:
+		r, err := task.ParseRecurrence(value)
+		if err != nil {
+			return false
+		}
+		l.Recurrence = r
+
+	case todoTxtList:
+		l.List = task.NormalizeList(value)
+
+	case todoTxtBlockedBy:
+		for _, s := range strings.Split(value, ",") {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return false
+			}
+			l.BlockedBy = append(l.BlockedBy, id)
+		}
+
+	case todoTxtOpenItem, todoTxtDoneItem:
+		l.Subtasks = append(l.Subtasks, task.Subtask{TitleStr: value, Done: key == todoTxtDoneItem})
+
+	case todoTxtUpdated:
+		updated, err := time.Parse(time.RFC3339, value)
+		l.UpdatedAt = updated
+		return err == nil
+
+	default:
+		return false
+	}
+	return true
+}
+
+// parseTodoTxtDate reads a date from the first of words.
+func parseTodoTxtDate(words []string) (time.Time, bool) {
+	if len(words) == 0 {
+		return time.Time{}, false
+	}
+	d, err := time.ParseInLocation(todoTxtDate, words[0], time.Local)
+	return d, err == nil
+}
+
+// escapeTodoTxtWord puts a backslash before a word of a title that
+// would otherwise be read back as something else: a list, a tag or an
+// extension, or, as the first word, a completion mark, a priority or a
+// date. Words that already start with one are escaped too.
+func escapeTodoTxtWord(w string, first bool) string {
+	switch {
+	case w[0] == '\\',
+		len(w) > 1 && (w[0] == '+' || w[0] == '@'),
+		todoTxtExtensionRe.MatchString(w) && !strings.Contains(w, "://"),
+		first && (w == "x" || todoTxtPriorityRe.MatchString(w)):
+		return "\\" + w
+	}
+	if _, ok := parseTodoTxtDate([]string{w}); first && ok {
+		return "\\" + w
+	}
+	return w
+}
+
+// formatTodoTxtLine writes t as a line of a todo.txt file, followed by
+// the words of extra that do not name an extension the task already
+// has.
+func formatTodoTxtLine(t task.Task, extra []string) string {
+	var words []string
+	keys := map[string]bool{}
+	ext := func(key, value string) {
+		words = append(words, key+":"+value)
+		keys[key] = true
+	}
+	hidden := func(key, value string) {
+		ext(key, todoTxtEscaper.Replace(value))
+	}
+
+	// Done tasks keep their priority in an extension, as is the custom.
+	// A creation date can only follow a completion date.
+	letter := todoTxtLetters[t.Priority]
+	if t.Done {
+		words = append(words, "x")
+		if !t.CompletedAt.IsZero() {
+			words = append(words, t.CompletedAt.Format(todoTxtDate))
+		}
+	} else if letter != "" {
+		words = append(words, "("+letter+")")
+	}
+	if !t.CreatedAt.IsZero() && (!t.Done || !t.CompletedAt.IsZero()) {
+		words = append(words, t.CreatedAt.Format(todoTxtDate))
+	}
+
+	for i, w := range strings.Fields(t.TitleStr) {
+		words = append(words, escapeTodoTxtWord(w, i == 0))
+	}
+
+	switch {
+	case t.List == "":
+	case strings.ContainsFunc(t.List, unicode.IsSpace):
+		hidden(todoTxtList, t.List)
+	default:
+		words = append(words, "+"+t.List)
+	}
+	for _, tag := range t.Tags {
+		words = append(words, "@"+tag)
+	}
+
+	if t.Done && letter != "" {
+		ext(todoTxtPriority, letter)
+	}
+	if t.HasDueDate() {
+		day := t.DueDate
+		if t.DueHasTime {
+			day = t.DueDate.In(t.DueLocation())
+		}
+		ext(todoTxtDue, day.Format(todoTxtDate))
+		if t.DueHasTime {
+			hidden(todoTxtDueAt, t.DueClock())
+		}
+	}
+	if t.HasStartDate() {
+		ext(todoTxtThreshold, t.StartDate.Format(todoTxtDate))
+	}
+	if r := t.Recurrence; r != nil {
+		switch {
+		case r.Kind == task.RecurDays || r.Kind == task.RecurWeeks:
+			rec := strconv.Itoa(r.Interval) + "d"
+			if r.Kind == task.RecurWeeks {
+				rec = strconv.Itoa(r.Interval) + "w"
+			}
+			if !r.AfterCompletion {
+				rec = "+" + rec
+			}
+			ext(todoTxtRecur, rec)
+		default:
+			hidden(todoTxtRule, r.String())
+		}
+	}
+
+	if t.DescStr != "" {
+		hidden(todoTxtDesc, t.DescStr)
+	}
+	if len(t.BlockedBy) > 0 {
+		ids := make([]string, len(t.BlockedBy))
+		for i, id := range t.BlockedBy {
+			ids[i] = id.String()
+		}
+		ext(todoTxtBlockedBy, strings.Join(ids, ","))
+	}
+	for _, sub := range t.Subtasks {
+		if sub.Done {
+			hidden(todoTxtDoneItem, sub.TitleStr)
+		} else {
+			hidden(todoTxtOpenItem, sub.TitleStr)
+		}
+	}
+	if !t.UpdatedAt.IsZero() {
+		ext(todoTxtUpdated, t.UpdatedAt.UTC().Format(time.RFC3339))
+	}
+
+	if t.ID != uuid.Nil {
+		ext(todoTxtID, t.ID.String())
+	}
+
+	// Unknown words stay, unless the task now has a value of its own for
+	// the same extension.
+	for _, w := range extra {
+		if key, _, ok := strings.Cut(w, ":"); ok && w[0] != '+' && keys[key] {
+			continue
+		}
+		words = append(words, w)
+	}
+
+	return strings.Join(words, " ")
+}
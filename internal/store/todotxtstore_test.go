@@ -0,0 +1,254 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
+)
+
+// newTempTodoTxtStore returns a TodoTxtTaskStore in a temporary
+// directory, along with the path of its file.
+func newTempTodoTxtStore(t *testing.T) (*TodoTxtTaskStore, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	return NewTodoTxtTaskStore(path), path
+}
+
+func writeTodoTxt(t *testing.T, path string, lines ...string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func readTodoTxt(t *testing.T, path string) []string {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+// -----------------------------------------------------------------------------
+// Round trips
+// -----------------------------------------------------------------------------
+
+func TestTodoTxtTaskStore_SaveLoadRoundTrip(t *testing.T) {
+	s, path := newTempTodoTxtStore(t)
+
+	if got, want := s.Name(), DefaultTodoTxtName; got != want {
+		t.Fatalf("Name() = %q, want %q", got, want)
+	}
+
+	day := func(d int) time.Time { return time.Date(2030, 1, d, 0, 0, 0, 0, time.Local) }
+	weekly, _ := task.ParseRecurrence("every mon, fri")
+	first := uuid.New()
+	standup := task.Task{
+		ID:         uuid.New(),
+		TitleStr:   "standup",
+		DescStr:    "daily sync\nbring 100% of notes",
+		Priority:   task.PriorityMedium,
+		StartDate:  day(1),
+		Recurrence: weekly,
+		List:       "Sprint 42",
+		BlockedBy:  []uuid.UUID{first},
+		Subtasks: []task.Subtask{
+			{TitleStr: "read board", Done: true},
+			{TitleStr: "share blockers"},
+		},
+		CreatedAt: day(1),
+		UpdatedAt: time.Date(2030, 1, 2, 9, 30, 0, 0, time.UTC),
+	}
+	if err := standup.SetDue(day(3), "9:00 Europe/Berlin"); err != nil {
+		t.Fatalf("SetDue() error = %v", err)
+	}
+	original := []task.Task{
+		{
+			ID:          first,
+			TitleStr:    "write report",
+			Done:        true,
+			Priority:    task.PriorityUrgent,
+			Tags:        []string{"work", "finance"},
+			List:        "Work",
+			DueDate:     day(10),
+			Recurrence:  &task.Recurrence{Kind: task.RecurWeeks, Interval: 2},
+			CreatedAt:   day(1),
+			CompletedAt: day(4),
+		},
+		standup,
+	}
+	for i, sub := range original[1].Subtasks {
+		original[1].Subtasks[i].ID = uuid.NewSHA1(standup.ID, []byte(strconv.Itoa(i)+" "+sub.TitleStr))
+	}
+
+	if err := s.Save(original); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	lines := readTodoTxt(t, path)
+	wantFirst := "x 2030-01-04 2030-01-01 write report +Work @work @finance pri:A due:2030-01-10 rec:+2w tt-id:" + first.String()
+	if lines[0] != wantFirst {
+		t.Fatalf("line 1 = %q\nwant %q", lines[0], wantFirst)
+	}
+
+	loaded, err := NewTodoTxtTaskStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	assertSameTasks(t, loaded, original)
+}
+
+func TestTodoTxtTaskStore_ReadsOtherTools(t *testing.T) {
+	s, path := newTempTodoTxtStore(t)
+	writeTodoTxt(t, path,
+		"(A) 2030-01-01 Call Mom +Family +Phone @Home due:2030-01-02 custom:keep-me",
+		"",
+		"x 2030-01-05 Pay rent https://example.com t:2030-01-01 rec:1m",
+		"(F) Someday at 10:30",
+	)
+
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("Load() returned %d tasks, want 3", len(tasks))
+	}
+
+	call := tasks[0]
+	if call.Title() != "Call Mom" || call.Priority != task.PriorityUrgent || call.List != "Family" {
+		t.Fatalf("first task = %+v", call)
+	}
+	if !slices.Equal(call.Tags, []string{"home"}) {
+		t.Fatalf("Tags = %v, want [home]", call.Tags)
+	}
+	if got, want := call.DueDate, time.Date(2030, 1, 2, 0, 0, 0, 0, time.Local); !got.Equal(want) || call.DueHasTime {
+		t.Fatalf("DueDate = %v (time %v), want %v", got, call.DueHasTime, want)
+	}
+	if got, want := call.CreatedAt, time.Date(2030, 1, 1, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Fatalf("CreatedAt = %v, want %v", got, want)
+	}
+
+	rent := tasks[1]
+	if !rent.Done || rent.Title() != "Pay rent https://example.com" || rent.Recurrence != nil {
+		t.Fatalf("second task = %+v", rent)
+	}
+	if rent.CompletedAt.IsZero() || !rent.CreatedAt.IsZero() || rent.StartDate.IsZero() {
+		t.Fatalf("second task dates = %+v", rent)
+	}
+
+	if someday := tasks[2]; someday.Title() != "Someday at 10:30" || someday.Priority != task.PriorityLow {
+		t.Fatalf("third task = %+v", someday)
+	}
+
+	// Saving keeps what terminaltask does not understand.
+	tasks[0].TitleStr = "Call Dad"
+	if err := s.Save(tasks); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	lines := readTodoTxt(t, path)
+	for i, want := range []string{
+		"(A) 2030-01-01 Call Dad +Family @home due:2030-01-02 tt-id:" + tasks[0].ID.String() + " +Phone custom:keep-me",
+		"x 2030-01-05 Pay rent https://example.com t:2030-01-01 tt-id:" + tasks[1].ID.String() + " rec:1m",
+	} {
+		if lines[i] != want {
+			t.Fatalf("line %d = %q\nwant %q", i+1, lines[i], want)
+		}
+	}
+}
+
+func TestTodoTxtTaskStore_EscapesTitles(t *testing.T) {
+	titles := []string{
+		"x marks the spot",
+		"(A) is for apple",
+		"2030-01-05 retro",
+		"add +1 to the @team vote",
+		"due:friday or later",
+		`\ is a backslash`,
+		`\x stays`,
+		"see https://example.com",
+	}
+	for _, title := range titles {
+		t.Run(title, func(t *testing.T) {
+			s, _ := newTempTodoTxtStore(t)
+			original := []task.Task{{ID: uuid.New(), TitleStr: title}}
+			if err := s.Save(original); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			loaded, err := NewTodoTxtTaskStore(s.path).Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			assertSameTasks(t, loaded, original)
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------
+// IDs
+// -----------------------------------------------------------------------------
+
+func TestTodoTxtTaskStore_StableIDs(t *testing.T) {
+	_, path := newTempTodoTxtStore(t)
+	writeTodoTxt(t, path, "buy milk", "buy milk", "walk dog")
+
+	load := func() []task.Task {
+		t.Helper()
+		tasks, err := NewTodoTxtTaskStore(path).Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		return tasks
+	}
+
+	first, second := load(), load()
+	assertSameTasks(t, second, first)
+	if first[0].ID == first[1].ID {
+		t.Fatal("copies of a line share an ID")
+	}
+
+	// Once saved, the IDs are in the file and survive edits by other
+	// tools.
+	s := NewTodoTxtTaskStore(path)
+	if _, err := s.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := s.Save(first); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	lines := readTodoTxt(t, path)
+	lines[2] = "x " + lines[2]
+	writeTodoTxt(t, path, lines...)
+
+	edited := load()
+	if edited[2].ID != first[2].ID || !edited[2].Done {
+		t.Fatalf("edited task = %+v, want ID %v and done", edited[2], first[2].ID)
+	}
+}
+
+func TestTodoTxtTaskStore_RefusesStaleData(t *testing.T) {
+	s, path := newTempTodoTxtStore(t)
+	writeTodoTxt(t, path, "first")
+
+	tasks, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	writeTodoTxt(t, path, "first", "added elsewhere")
+
+	if err := s.Save(tasks); !errors.Is(err, ErrStaleSave) {
+		t.Fatalf("Save() error = %v, want ErrStaleSave", err)
+	}
+}
@@ -0,0 +1,212 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Labels used when describing a task's dates.
+const (
+	noDueDateLabel = "no due date"
+	startsLabel    = "starts"
+	overdueLabel   = "overdue"
+)
+
+// Layouts of due times shown to and accepted from the user.
+const (
+	dueTimeFormat = "2006-01-02 15:04"
+	clockFormat   = "15:04"
+)
+
+// unnamedLocalZone is what time.Local reports as its name when the
+// zone was loaded from /etc/localtime rather than $TZ.
+const unnamedLocalZone = "Local"
+
+// clockLayouts are the accepted spellings of a time of day, tried in
+// order against the lowercased input.
+var clockLayouts = []string{"15:04", "3:04pm", "3pm", "1504"}
+
+// HasDueDate reports whether the task has a due date.
+func (t Task) HasDueDate() bool {
+	return !t.DueDate.IsZero()
+}
+
+// HasStartDate reports whether the task has a start (scheduled) date.
+func (t Task) HasStartDate() bool {
+	return !t.StartDate.IsZero()
+}
+
+// CalendarDay returns midnight in the local time zone on the calendar
+// day of t as it was recorded. A date picked as 2030-01-02 stays on
+// that day no matter which zone it is later read in.
+func CalendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// today returns the local calendar day of now.
+func today(now time.Time) time.Time {
+	return CalendarDay(now.In(time.Local))
+}
+
+// LocalZoneName returns the IANA name of the local time zone, such as
+// "Europe/Berlin", or an empty string when it cannot be determined.
+func LocalZoneName() string {
+	if name := time.Local.String(); name != unnamedLocalZone {
+		return name
+	}
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		return tz
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// DueLocation returns the time zone the deadline was set in, or the
+// local zone when none was recorded or it is unknown on this system.
+func (t Task) DueLocation() *time.Location {
+	if t.DueZone != "" {
+		if loc, err := time.LoadLocation(t.DueZone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// DueDay returns the local calendar day the task is due on. A due time
+// is converted to local time first, so a deadline set at 01:00 in
+// another zone may fall on a different local day.
+func (t Task) DueDay() time.Time {
+	if t.DueHasTime {
+		return CalendarDay(t.DueDate.In(time.Local))
+	}
+	return CalendarDay(t.DueDate)
+}
+
+// IsOverdue reports whether an open task's deadline has passed at now.
+// Tasks without a due time are overdue once their day has passed.
+func (t Task) IsOverdue(now time.Time) bool {
+	switch {
+	case t.Done || !t.HasDueDate():
+		return false
+	case t.DueHasTime:
+		return now.After(t.DueDate)
+	default:
+		return t.DueDay().Before(today(now))
+	}
+}
+
+// NotStarted reports whether the task is scheduled to start on a later
+// local day than now, so it is not yet actionable.
+func (t Task) NotStarted(now time.Time) bool {
+	return t.HasStartDate() && CalendarDay(t.StartDate).After(today(now))
+}
+
+// SetDue sets the due date to the calendar day of day, at the time of
+// day given by clock, such as "17:00", "5pm", or "9:30am Europe/Berlin".
+// An empty clock leaves the task due at any time that day, and a zero
+// day removes the due date.
+func (t *Task) SetDue(day time.Time, clock string) error {
+	t.DueDate, t.DueHasTime, t.DueZone = time.Time{}, false, ""
+	if day.IsZero() {
+		return nil
+	}
+
+	y, m, d := day.Date()
+	fields := strings.Fields(clock)
+	if len(fields) == 0 {
+		t.DueDate = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+		return nil
+	}
+	if len(fields) > 2 { //nolint:mnd
+		return fmt.Errorf("expected a time and an optional zone, got %q", clock)
+	}
+
+	hour, minute, err := parseClock(fields[0])
+	if err != nil {
+		return err
+	}
+	loc, zone := time.Local, LocalZoneName()
+	if len(fields) == 2 { //nolint:mnd
+		if loc, err = time.LoadLocation(fields[1]); err != nil {
+			return fmt.Errorf("unknown time zone %q", fields[1])
+		}
+		zone = fields[1]
+	}
+
+	t.DueDate = time.Date(y, m, d, hour, minute, 0, 0, loc)
+	t.DueHasTime = true
+	t.DueZone = zone
+	return nil
+}
+
+// DueClock returns the task's due time of day in the form accepted by
+// SetDue, naming the zone when it is not the local one. It is empty
+// when the task has no due time.
+func (t Task) DueClock() string {
+	if !t.DueHasTime {
+		return ""
+	}
+	clock := t.DueDate.In(t.DueLocation()).Format(clockFormat)
+	if t.DueZone != "" && t.DueZone != LocalZoneName() {
+		clock += " " + t.DueZone
+	}
+	return clock
+}
+
+// parseClock parses a time of day.
+func parseClock(value string) (hour, minute int, err error) {
+	value = strings.ToLower(value)
+	for _, layout := range clockLayouts {
+		if c, err := time.Parse(layout, value); err == nil {
+			return c.Hour(), c.Minute(), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unknown time of day %q", value)
+}
+
+// CompareDue orders tasks by deadline, earliest first, with undated
+// tasks last. On the same day, tasks due at any time come after those
+// due at a given time.
+func CompareDue(a, b Task) int {
+	switch {
+	case !a.HasDueDate() && !b.HasDueDate():
+		return 0
+	case !a.HasDueDate():
+		return 1
+	case !b.HasDueDate():
+		return -1
+	}
+	return a.deadline().Compare(b.deadline())
+}
+
+// deadline returns the instant a task is due: its due time, or the end
+// of its local due day.
+func (t Task) deadline() time.Time {
+	if t.DueHasTime {
+		return t.DueDate
+	}
+	return t.DueDay().AddDate(0, 0, 1)
+}
+
+// dueDescription renders the due date shown on the task's date line.
+// Due times are shown in the zone they were set in when that is not
+// the local one.
+func (t Task) dueDescription() string {
+	switch {
+	case !t.HasDueDate():
+		return noDueDateLabel
+	case !t.DueHasTime:
+		return t.DueDate.Format(dateFormat)
+	case t.DueZone != "" && t.DueZone != LocalZoneName():
+		return t.DueDate.In(t.DueLocation()).Format(dueTimeFormat) + " " + t.DueZone
+	default:
+		return t.DueDate.Local().Format(dueTimeFormat)
+	}
+}
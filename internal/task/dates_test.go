@@ -0,0 +1,127 @@
+package task
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func TestTaskNotStarted(t *testing.T) {
+	now := time.Date(2030, 3, 10, 15, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		want  bool
+	}{
+		{"no start date", time.Time{}, false},
+		{"started earlier", now.AddDate(0, 0, -1), false},
+		{"starts today", time.Date(2030, 3, 10, 23, 0, 0, 0, time.Local), false},
+		{"starts tomorrow", now.AddDate(0, 0, 1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Task{StartDate: tt.start}).NotStarted(now); got != tt.want {
+				t.Errorf("NotStarted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareDue(t *testing.T) {
+	early := Task{TitleStr: "early", DueDate: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	late := Task{TitleStr: "late", DueDate: time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)}
+	undated := Task{TitleStr: "undated"}
+
+	tasks := []Task{undated, late, early}
+	SortBy(tasks, CompareDue)
+
+	for i, want := range []string{"early", "late", "undated"} {
+		if tasks[i].TitleStr != want {
+			t.Errorf("tasks[%d] = %q, want %q", i, tasks[i].TitleStr, want)
+		}
+	}
+}
+
+func TestTaskDelegateRender_OptionalDates(t *testing.T) {
+	d := NewTaskDelegate()
+	start := time.Now().AddDate(0, 0, 3)
+	items := []list.Item{
+		Task{TitleStr: "undated"},
+		Task{TitleStr: "later", StartDate: start},
+	}
+	m := newTestList(items, d)
+	m.SetWidth(60)
+
+	var buf bytes.Buffer
+	d.Render(&buf, m, 0, items[0])
+	if bytes.Contains(buf.Bytes(), []byte("0001-01-01")) || !bytes.Contains(buf.Bytes(), []byte(noDueDateLabel)) {
+		t.Errorf("undated task rendered as %q, want %q", buf.String(), noDueDateLabel)
+	}
+
+	buf.Reset()
+	d.Render(&buf, m, 1, items[1])
+	if want := startsLabel + " " + start.Format(dateFormat); !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("scheduled task rendered as %q, want it to contain %q", buf.String(), want)
+	}
+}
+
+func TestTaskSetDue(t *testing.T) {
+	day := time.Date(2030, 3, 10, 0, 0, 0, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	var tk Task
+	if err := tk.SetDue(day, "9:30am Europe/Berlin"); err != nil {
+		t.Fatalf("SetDue() error = %v", err)
+	}
+	if want := time.Date(2030, 3, 10, 9, 30, 0, 0, berlin); !tk.DueDate.Equal(want) || !tk.DueHasTime || tk.DueZone != "Europe/Berlin" {
+		t.Errorf("SetDue() = %v, %v, %q; want %v in Europe/Berlin", tk.DueDate, tk.DueHasTime, tk.DueZone, want)
+	}
+	if got := tk.DueClock(); got != "09:30 Europe/Berlin" && got != "09:30" {
+		t.Errorf("DueClock() = %q, want the time with its zone", got)
+	}
+
+	if err := tk.SetDue(day, ""); err != nil || tk.DueHasTime || tk.DueZone != "" {
+		t.Errorf("SetDue(day, \"\") = %v, %v, %q; want a date without time", err, tk.DueHasTime, tk.DueZone)
+	}
+	if !tk.DueDay().Equal(time.Date(2030, 3, 10, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("DueDay() = %v, want 2030-03-10 local", tk.DueDay())
+	}
+
+	for _, bad := range []string{"noon-ish", "25:00", "9:00 Mars/Olympus", "9:00 UTC extra"} {
+		if err := tk.SetDue(day, bad); err == nil {
+			t.Errorf("SetDue(%q) error = nil, want an error", bad)
+		}
+	}
+	if err := tk.SetDue(time.Time{}, "9:00"); err != nil || tk.HasDueDate() {
+		t.Errorf("SetDue(zero) = %v, %v; want no due date", err, tk.DueDate)
+	}
+}
+
+func TestTaskIsOverdue_LocalDay(t *testing.T) {
+	// A date-only deadline recorded in a zone far from local time stays
+	// on its calendar day.
+	east := time.FixedZone("UTC+14", 14*60*60)
+	now := time.Date(2030, 3, 10, 12, 0, 0, 0, time.Local)
+	tk := Task{DueDate: time.Date(2030, 3, 10, 0, 0, 0, 0, east)}
+
+	if tk.IsOverdue(now) {
+		t.Errorf("IsOverdue() = true on the due day")
+	}
+	if !tk.IsOverdue(now.AddDate(0, 0, 1)) {
+		t.Errorf("IsOverdue() = false the day after")
+	}
+
+	timed := Task{DueDate: now.Add(time.Hour), DueHasTime: true}
+	if timed.IsOverdue(now) || !timed.IsOverdue(now.Add(2*time.Hour)) {
+		t.Errorf("IsOverdue() does not follow the due time")
+	}
+	if (Task{DueDate: now.AddDate(0, 0, -1), Done: true}).IsOverdue(now) {
+		t.Errorf("IsOverdue() = true for a done task")
+	}
+}
@@ -0,0 +1,103 @@
+package task
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// IsBlockedBy reports whether the task lists id among its blockers.
+func (t Task) IsBlockedBy(id uuid.UUID) bool {
+	return slices.Contains(t.BlockedBy, id)
+}
+
+// AddBlocker records that the task cannot start until the task with
+// the given ID is done. It reports whether the link was added.
+func (t *Task) AddBlocker(id uuid.UUID) bool {
+	if id == uuid.Nil || t.IsBlockedBy(id) {
+		return false
+	}
+	t.BlockedBy = append(slices.Clone(t.BlockedBy), id)
+	return true
+}
+
+// RemoveBlocker drops the link to the task with the given ID and
+// reports whether there was one.
+func (t *Task) RemoveBlocker(id uuid.UUID) bool {
+	if !t.IsBlockedBy(id) {
+		return false
+	}
+	t.BlockedBy = slices.DeleteFunc(slices.Clone(t.BlockedBy), func(b uuid.UUID) bool {
+		return b == id
+	})
+	if len(t.BlockedBy) == 0 {
+		t.BlockedBy = nil
+	}
+	return true
+}
+
+// Blockers returns the tasks in all that still block t, in the order
+// they were linked. Blockers that are done, or no longer exist, do not
+// count, so completing a blocker unblocks its dependents.
+func (t Task) Blockers(all []Task) []Task {
+	if len(t.BlockedBy) == 0 {
+		return nil
+	}
+	var blockers []Task
+	for _, id := range t.BlockedBy {
+		for _, other := range all {
+			if other.ID == id && !other.Done {
+				blockers = append(blockers, other)
+				break
+			}
+		}
+	}
+	return blockers
+}
+
+// OpenBlockers returns the blockers of every task in all that is
+// blocked, keyed by its ID, as Task.Blockers would return them.
+func OpenBlockers(all []Task) map[uuid.UUID][]Task {
+	byID := make(map[uuid.UUID]int, len(all))
+	for i, t := range all {
+		byID[t.ID] = i
+	}
+	blockers := map[uuid.UUID][]Task{}
+	for _, t := range all {
+		for _, id := range t.BlockedBy {
+			if i, ok := byID[id]; ok && !all[i].Done {
+				blockers[t.ID] = append(blockers[t.ID], all[i])
+			}
+		}
+	}
+	return blockers
+}
+
+// DependencyCycle returns the IDs along a chain of blocked-by links
+// that leads from the task with the given ID back to itself, starting
+// and ending with that ID, or nil when there is no such cycle.
+func DependencyCycle(tasks []Task, id uuid.UUID) []uuid.UUID {
+	links := make(map[uuid.UUID][]uuid.UUID, len(tasks))
+	for _, t := range tasks {
+		links[t.ID] = t.BlockedBy
+	}
+
+	visited := map[uuid.UUID]bool{}
+	var walk func(path []uuid.UUID) []uuid.UUID
+	walk = func(path []uuid.UUID) []uuid.UUID {
+		for _, next := range links[path[len(path)-1]] {
+			if next == id {
+				return append(slices.Clone(path), next)
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			if cycle := walk(append(path, next)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return walk([]uuid.UUID{id})
+}
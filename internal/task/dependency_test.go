@@ -0,0 +1,68 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAddAndRemoveBlocker(t *testing.T) {
+	blocker := uuid.New()
+	tk := Task{ID: uuid.New()}
+
+	if !tk.AddBlocker(blocker) {
+		t.Fatalf("AddBlocker() = false, want true")
+	}
+	if tk.AddBlocker(blocker) {
+		t.Fatalf("AddBlocker() added a duplicate link")
+	}
+	if !tk.IsBlockedBy(blocker) {
+		t.Fatalf("IsBlockedBy() = false after AddBlocker")
+	}
+
+	if !tk.RemoveBlocker(blocker) || tk.BlockedBy != nil {
+		t.Fatalf("RemoveBlocker() left BlockedBy = %v, want nil", tk.BlockedBy)
+	}
+	if tk.RemoveBlocker(blocker) {
+		t.Fatalf("RemoveBlocker() = true for a missing link")
+	}
+}
+
+func TestBlockers_IgnoresDoneAndMissing(t *testing.T) {
+	open := Task{ID: uuid.New(), TitleStr: "open"}
+	done := Task{ID: uuid.New(), TitleStr: "done", Done: true}
+	tk := Task{ID: uuid.New(), BlockedBy: []uuid.UUID{done.ID, uuid.New(), open.ID}}
+
+	blockers := tk.Blockers([]Task{open, done, tk})
+	if len(blockers) != 1 || blockers[0].ID != open.ID {
+		t.Fatalf("Blockers() = %v, want only the open blocker", blockers)
+	}
+}
+
+func TestDependencyCycle(t *testing.T) {
+	a := Task{ID: uuid.New()}
+	b := Task{ID: uuid.New(), BlockedBy: []uuid.UUID{a.ID}}
+	c := Task{ID: uuid.New(), BlockedBy: []uuid.UUID{b.ID}}
+
+	if cycle := DependencyCycle([]Task{a, b, c}, a.ID); cycle != nil {
+		t.Fatalf("DependencyCycle() = %v for an acyclic graph, want nil", cycle)
+	}
+
+	a.BlockedBy = []uuid.UUID{c.ID}
+	cycle := DependencyCycle([]Task{a, b, c}, a.ID)
+	want := []uuid.UUID{a.ID, c.ID, b.ID, a.ID}
+	if len(cycle) != len(want) {
+		t.Fatalf("DependencyCycle() = %v, want %v", cycle, want)
+	}
+	for i := range want {
+		if cycle[i] != want[i] {
+			t.Fatalf("DependencyCycle()[%d] = %v, want %v", i, cycle[i], want[i])
+		}
+	}
+
+	self := Task{ID: uuid.New()}
+	self.BlockedBy = []uuid.UUID{self.ID}
+	if cycle := DependencyCycle([]Task{self}, self.ID); len(cycle) != 2 {
+		t.Fatalf("DependencyCycle() = %v for a self-link, want [self self]", cycle)
+	}
+}
@@ -3,6 +3,9 @@
 package editmenu
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/help"
@@ -26,11 +29,46 @@ const (
 	defaultDescPrompt      = "Description: "
 	defaultDescPlaceholder = "Description"
 
+	defaultListPrompt = "List: "
+
+	defaultTagsPrompt      = "Tags: "
+	defaultTagsPlaceholder = "#tag #another"
+	defaultTagsHintPrefix  = "tab to complete: "
+
+	defaultPriorityPrompt = "Priority: "
+
+	defaultRepeatPrompt      = "Repeat: "
+	defaultRepeatPlaceholder = "e.g. every 2 weeks, every mon, fri, monthly last fri, 3 days after done"
+
+	defaultStartPrompt = "Start: "
+	defaultDuePrompt   = "Due: "
+	noDateLabel        = "none"
+	datePickHint       = "  ←/→ to pick a date"
+	dateClearHint      = "  del to clear"
+	dateFormat         = "2006-01-02"
+
+	defaultDueTimePrompt      = "Due time: "
+	defaultDueTimePlaceholder = "optional, e.g. 17:00, 9:30am, or 9:00 Europe/Berlin"
+
+	defaultSubtaskPrompt      = "Checklist: "
+	defaultSubtaskPlaceholder = "New item"
+	subtaskCheckedBox         = "[x] "
+	subtaskUncheckedBox       = "[ ] "
+
 	defaultWindowTitle = "Editing..."
 
+	timestampCreatedLabel   = "Created"
+	timestampUpdatedLabel   = "Updated"
+	timestampCompletedLabel = "Completed"
+	timestampSeparator      = " · "
+
 	statusMsgDatePastError   = "Error: Date cannot be in the past"
+	statusMsgStartAfterDue   = "Error: Start date cannot be after the due date"
+	statusMsgDueTimeError    = "Error: Invalid due time: %s"
+	statusMsgTimeWithoutDate = "Error: Pick a due date for the due time"
 	statusMsgTitleEmptyError = "Error: Title cannot be empty"
 	statusMsgDescEmptyError  = "Error: Description cannot be empty"
+	statusMsgRepeatError     = "Error: Invalid repeat rule: %s"
 )
 
 //
@@ -44,12 +82,21 @@ type EscapeEditMsg struct{}
 // SaveTaskMsg carries the data needed to save a task from the edit
 // menu back to the main application.
 type SaveTaskMsg struct {
-	TaskID uuid.UUID
-	Title  string
-	Desc   string
-	Date   time.Time
-	Done   bool
-	IsNew  bool
+	TaskID     uuid.UUID
+	Title      string
+	Desc       string
+	Date       time.Time
+	DueHasTime bool
+	DueZone    string
+	Start      time.Time
+	Done       bool
+	Priority   task.Priority
+	Tags       []string
+	Subtasks   []task.Subtask
+	Recurrence *task.Recurrence
+	List       string
+	BlockedBy  []uuid.UUID
+	IsNew      bool
 }
 
 // ErrorMsg is a generic error message produced by the edit menu.
@@ -98,6 +145,9 @@ func DefaultStyles() (s Styles) {
 	s.Normal = lipgloss.NewStyle().
 		Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"}).
 		Padding(0, 0, 0, 2)
+	s.Blurred = lipgloss.NewStyle().
+		Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"}).
+		Padding(0, 0, 0, 2)
 
 	s.StatusMessage = lipgloss.NewStyle().
 		Foreground(lipgloss.AdaptiveColor{Light: "#FF0000", Dark: "#FF5555"})
@@ -118,12 +168,23 @@ type EditTaskKeyMap struct {
 	Help           key.Binding
 	Quit           key.Binding
 
+	CompleteTag key.Binding
+
+	PriorityNext key.Binding
+	PriorityPrev key.Binding
+
+	SubtaskUp     key.Binding
+	SubtaskDown   key.Binding
+	SubtaskToggle key.Binding
+	SubtaskRemove key.Binding
+
 	DateUp        key.Binding
 	DateDown      key.Binding
 	DateNext      key.Binding
 	DatePrevious  key.Binding
 	DateFocusNext key.Binding
 	DateFocusPrev key.Binding
+	DateClear     key.Binding
 }
 
 // newEditTaskKeyMap constructs the default key bindings for the
@@ -147,12 +208,44 @@ func newEditTaskKeyMap() *EditTaskKeyMap {
 			key.WithKeys("ctrl+o"),
 			key.WithHelp("ctrl+o", "help"),
 		),
+		CompleteTag: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "complete tag"),
+		),
+		PriorityNext: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "raise priority"),
+		),
+		PriorityPrev: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "lower priority"),
+		),
+		SubtaskUp: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "previous item"),
+		),
+		SubtaskDown: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next item"),
+		),
+		SubtaskToggle: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "check item"),
+		),
+		SubtaskRemove: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "remove item"),
+		),
 		DateUp:        dpk.Up,
 		DateDown:      dpk.Down,
 		DateNext:      dpk.Right,
 		DatePrevious:  dpk.Left,
 		DateFocusNext: dpk.FocusNext,
 		DateFocusPrev: dpk.FocusPrev,
+		DateClear: key.NewBinding(
+			key.WithKeys("delete", "backspace"),
+			key.WithHelp("del", "clear date"),
+		),
 	}
 }
 
@@ -176,11 +269,23 @@ func (e EditTaskKeyMap) FullHelp() [][]key.Binding {
 			e.Help,
 			e.Quit,
 		},
+		{
+			e.CompleteTag,
+			e.PriorityNext,
+			e.PriorityPrev,
+		},
+		{
+			e.SubtaskUp,
+			e.SubtaskDown,
+			e.SubtaskToggle,
+			e.SubtaskRemove,
+		},
 		{
 			e.DateUp,
 			e.DateDown,
 			e.DateNext,
 			e.DatePrevious,
+			e.DateClear,
 		},
 	}
 }
@@ -198,6 +303,14 @@ type Model struct {
 	TaskID uuid.UUID
 	IsNew  bool
 
+	// blockedBy carries the task's blocked-by links through the edit
+	// unchanged; they are managed from the list view.
+	blockedBy []uuid.UUID
+
+	// timestamps summarizes when the task was created, updated, and
+	// completed; it is empty for new tasks.
+	timestamps string
+
 	form Form
 
 	// Layout / dimensions
@@ -253,6 +366,7 @@ func NewWithSizeAndStyles(
 		description = task.Description()
 		duedate     = task.DueDate
 		done        = task.Done
+		priority    = task.Priority
 		keymap      = newEditTaskKeyMap()
 		isNew       = false
 	)
@@ -262,21 +376,29 @@ func NewWithSizeAndStyles(
 		windowTitle = title
 	}
 
-	if duedate.IsZero() {
-		duedate = time.Now()
-	}
-
 	if task.IsEmpty() {
 		isNew = true
 	}
 
+	form := NewForm(title, description, duedate, done, keymap, formStyles)
+	form.Priority = priority
+	form = form.SetDue(task)
+	form = form.SetStartDate(task.StartDate)
+	form = form.SetList(task.List)
+	form = form.SetTags(task.Tags)
+	form.Subtasks = slices.Clone(task.Subtasks)
+	form = form.SetRecurrence(task.Recurrence)
+
 	return Model{
 		// Identity / basic metadata
-		Title: windowTitle,
-		IsNew: isNew,
+		Title:      windowTitle,
+		TaskID:     task.GetID(),
+		IsNew:      isNew,
+		blockedBy:  slices.Clone(task.BlockedBy),
+		timestamps: timestampsSummary(task),
 
 		// User-editable fields
-		form: NewForm(title, description, duedate, done, keymap, formStyles),
+		form: form,
 
 		// Layout / dimensions
 		width:  width,
@@ -324,8 +446,16 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keymap.SaveTask):
-			if m.form.Date.Time.Before(time.Now().Truncate(24 * time.Hour)) {
+			due, err := m.form.DueValue()
+			switch {
+			case err != nil:
+				return m, m.showStatus(fmt.Sprintf(statusMsgDueTimeError, err))
+			case !m.form.HasDate && strings.TrimSpace(m.form.DueTime.Value()) != "":
+				return m, m.showStatus(statusMsgTimeWithoutDate)
+			case due.IsOverdue(time.Now()):
 				return m, m.showStatus(statusMsgDatePastError)
+			case m.form.HasDate && m.form.HasStart && task.CalendarDay(m.form.Start.Time).After(due.DueDay()):
+				return m, m.showStatus(statusMsgStartAfterDue)
 			}
 			if m.form.Title.Value() == "" {
 				return m, m.showStatus(statusMsgTitleEmptyError)
@@ -333,16 +463,29 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			if m.form.Desc.Value() == "" {
 				return m, m.showStatus(statusMsgDescEmptyError)
 			}
+			recurrence, err := m.form.RecurrenceValue()
+			if err != nil {
+				return m, m.showStatus(fmt.Sprintf(statusMsgRepeatError, err))
+			}
 
 			m.form = m.form.setFocus()
 			return m, func() tea.Msg {
 				return SaveTaskMsg{
-					TaskID: m.TaskID,
-					Title:  m.form.Title.Value(),
-					Desc:   m.form.Desc.Value(),
-					Date:   m.form.Date.Time,
-					Done:   m.form.Done,
-					IsNew:  m.IsNew,
+					TaskID:     m.TaskID,
+					Title:      m.form.Title.Value(),
+					Desc:       m.form.Desc.Value(),
+					Date:       due.DueDate,
+					DueHasTime: due.DueHasTime,
+					DueZone:    due.DueZone,
+					Start:      m.form.StartDateValue(),
+					Done:       m.form.Done,
+					Priority:   m.form.Priority,
+					Tags:       m.form.TagValues(),
+					Subtasks:   m.form.Subtasks,
+					Recurrence: recurrence,
+					List:       m.form.ListValue(),
+					BlockedBy:  m.blockedBy,
+					IsNew:      m.IsNew,
 				}
 			}
 
@@ -362,6 +505,13 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	return m, cmd
 }
 
+// SetKnownTags sets the tags offered as completions while editing the
+// task's tags, typically every tag already used in the task list.
+func (m Model) SetKnownTags(tags []string) Model {
+	m.form = m.form.SetKnownTags(tags)
+	return m
+}
+
 // SetSize updates the edit menu dimensions and internal help width.
 func (m Model) SetSize(width int, height int) Model {
 	m.width = width
@@ -399,6 +549,12 @@ func (m Model) View() string {
 		availHeight -= lipgloss.Height(v)
 	}
 
+	if m.timestamps != "" {
+		v := m.timestampsView()
+		sections = append(sections, v)
+		availHeight -= lipgloss.Height(v)
+	}
+
 	var helpView string
 	if m.showHelp {
 		helpView = m.helpView()
@@ -440,6 +596,31 @@ func (m Model) titleView() string {
 	return view
 }
 
+// timestampsView renders the task's creation, update, and completion
+// times beneath the title bar.
+func (m Model) timestampsView() string {
+	return m.styles.Blurred.PaddingBottom(1).Render(m.timestamps)
+}
+
+// timestampsSummary formats the known timestamps of t on one line,
+// e.g. "Created 2025-01-02 09:30 · Updated 2025-01-03 17:00".
+func timestampsSummary(t task.Task) string {
+	var parts []string
+	for _, ts := range []struct {
+		label string
+		at    time.Time
+	}{
+		{timestampCreatedLabel, t.CreatedAt},
+		{timestampUpdatedLabel, t.UpdatedAt},
+		{timestampCompletedLabel, t.CompletedAt},
+	} {
+		if !ts.at.IsZero() {
+			parts = append(parts, ts.label+" "+task.FormatTimestamp(ts.at))
+		}
+	}
+	return strings.Join(parts, timestampSeparator)
+}
+
 // ShowTitle reports whether the title bar is currently enabled.
 func (m Model) ShowTitle() bool {
 	return m.showTitle
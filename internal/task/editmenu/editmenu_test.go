@@ -61,6 +61,9 @@ func TestNewWithSizeAndStyles_ExistingTask(t *testing.T) {
 	if m.IsNew {
 		t.Errorf("IsNew = %v, want false for non-empty task", m.IsNew)
 	}
+	if m.TaskID != tk.ID {
+		t.Errorf("TaskID = %v, want %v", m.TaskID, tk.ID)
+	}
 
 	// Form fields should reflect the task values.
 	if m.form.Title.Value() != "my task" {
@@ -77,6 +80,29 @@ func TestNewWithSizeAndStyles_ExistingTask(t *testing.T) {
 	}
 }
 
+func TestNewWithSizeAndStyles_ShowsTimestamps(t *testing.T) {
+	created := time.Date(2030, 1, 2, 9, 30, 0, 0, time.Local)
+	tk := task.Task{
+		ID:        uuid.New(),
+		TitleStr:  "my task",
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+
+	m := NewWithSize(80, 24, tk)
+	want := "Created 2030-01-02 09:30 · Updated 2030-01-02 09:30"
+	if m.timestamps != want {
+		t.Fatalf("timestamps = %q, want %q", m.timestamps, want)
+	}
+	if !strings.Contains(m.View(), "Created 2030-01-02 09:30") {
+		t.Errorf("View() does not show the creation time")
+	}
+
+	if m := NewWithSize(80, 24, task.New()); m.timestamps != "" {
+		t.Errorf("timestamps = %q for a new task, want empty", m.timestamps)
+	}
+}
+
 //
 // Update behavior tests
 //
@@ -90,6 +116,7 @@ func TestModelUpdate_SaveTask_ValidationErrors(t *testing.T) {
 	saveMsg := tea.KeyMsg{Type: tea.KeyCtrlS}
 
 	// Case 1: date in the past
+	m.form.HasDate = true
 	m.form.Date.Time = time.Now().Add(-24 * time.Hour)
 	m2, cmd := m.Update(saveMsg)
 	if cmd == nil {
@@ -115,6 +142,14 @@ func TestModelUpdate_SaveTask_ValidationErrors(t *testing.T) {
 	if !strings.Contains(m2.statusMsg, statusMsgDescEmptyError) {
 		t.Errorf("statusMsg = %q, want to contain %q", m2.statusMsg, statusMsgDescEmptyError)
 	}
+
+	// Case 4: unparsable repeat rule
+	m.form.Desc.SetValue("desc")
+	m.form.Repeat.SetValue("every blue moon")
+	m2, _ = m.Update(saveMsg)
+	if !strings.Contains(m2.statusMsg, "Invalid repeat rule") {
+		t.Errorf("statusMsg = %q, want to mention the invalid repeat rule", m2.statusMsg)
+	}
 }
 
 func TestModelUpdate_SaveTask_Success(t *testing.T) {
@@ -122,6 +157,7 @@ func TestModelUpdate_SaveTask_Success(t *testing.T) {
 	m := New(baseTask)
 
 	// Satisfy all validation conditions.
+	m.form.HasDate = true
 	m.form.Date.Time = time.Now().Add(24 * time.Hour).Truncate(24 * time.Hour)
 	m.form.Title.SetValue("title")
 	m.form.Desc.SetValue("desc")
@@ -147,7 +183,7 @@ func TestModelUpdate_SaveTask_Success(t *testing.T) {
 	if save.Desc != "desc" {
 		t.Errorf("Desc = %q, want %q", save.Desc, "desc")
 	}
-	if !save.Date.Equal(m2.form.Date.Time) {
+	if !save.Date.Equal(task.CalendarDay(m2.form.Date.Time)) {
 		t.Errorf("Date in message = %v, want %v", save.Date, m2.form.Date.Time)
 	}
 	if !save.Done {
@@ -158,6 +194,75 @@ func TestModelUpdate_SaveTask_Success(t *testing.T) {
 	}
 }
 
+func TestModelUpdate_SaveTask_OptionalDates(t *testing.T) {
+	saveMsg := tea.KeyMsg{Type: tea.KeyCtrlS}
+
+	// A task without dates saves with zero dates.
+	m := New(task.Task{ID: uuid.New(), TitleStr: "title", DescStr: "desc"})
+	_, cmd := m.Update(saveMsg)
+	if cmd == nil {
+		t.Fatalf("expected non-nil cmd on successful save")
+	}
+	save, ok := cmd().(SaveTaskMsg)
+	if !ok {
+		t.Fatalf("expected SaveTaskMsg")
+	}
+	if !save.Date.IsZero() || !save.Start.IsZero() {
+		t.Errorf("Date, Start = %v, %v; want zero", save.Date, save.Start)
+	}
+
+	// A start date after the due date is rejected.
+	due := time.Now().AddDate(0, 0, 2)
+	m = New(task.Task{
+		ID:        uuid.New(),
+		TitleStr:  "title",
+		DescStr:   "desc",
+		DueDate:   due,
+		StartDate: due.AddDate(0, 0, 1),
+	})
+	m2, _ := m.Update(saveMsg)
+	if m2.statusMsg != statusMsgStartAfterDue {
+		t.Errorf("statusMsg = %q, want %q", m2.statusMsg, statusMsgStartAfterDue)
+	}
+}
+
+func TestModelUpdate_SaveTask_DueTime(t *testing.T) {
+	saveMsg := tea.KeyMsg{Type: tea.KeyCtrlS}
+	day := time.Now().AddDate(0, 0, 3)
+
+	m := New(task.Task{ID: uuid.New(), TitleStr: "title", DescStr: "desc", DueDate: day})
+	m.form.DueTime.SetValue("9:30pm Asia/Tokyo")
+	_, cmd := m.Update(saveMsg)
+	if cmd == nil {
+		t.Fatalf("expected non-nil cmd on successful save")
+	}
+	save, ok := cmd().(SaveTaskMsg)
+	if !ok {
+		t.Fatalf("expected SaveTaskMsg")
+	}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	y, mo, d := day.Date()
+	if want := time.Date(y, mo, d, 21, 30, 0, 0, tokyo); !save.Date.Equal(want) {
+		t.Errorf("Date = %v, want %v", save.Date, want)
+	}
+	if !save.DueHasTime || save.DueZone != "Asia/Tokyo" {
+		t.Errorf("DueHasTime, DueZone = %v, %q; want true, Asia/Tokyo", save.DueHasTime, save.DueZone)
+	}
+
+	// An unparsable time, or a time without a date, is rejected.
+	m.form.DueTime.SetValue("teatime")
+	m2, _ := m.Update(saveMsg)
+	if !strings.Contains(m2.statusMsg, "Invalid due time") {
+		t.Errorf("statusMsg = %q, want to mention the invalid due time", m2.statusMsg)
+	}
+	m.form.DueTime.SetValue("17:00")
+	m.form.HasDate = false
+	m2, _ = m.Update(saveMsg)
+	if m2.statusMsg != statusMsgTimeWithoutDate {
+		t.Errorf("statusMsg = %q, want %q", m2.statusMsg, statusMsgTimeWithoutDate)
+	}
+}
+
 func TestModelUpdate_EscapeEditMode(t *testing.T) {
 	m := New(task.Task{})
 
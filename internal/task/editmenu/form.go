@@ -1,6 +1,8 @@
 package editmenu
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -8,12 +10,20 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	datepicker "github.com/ethanefung/bubble-datepicker"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
 )
 
 const (
 	focusIdxTitle = iota
 	focusIdxDesc
+	focusIdxList
+	focusIdxTags
+	focusIdxPriority
+	focusIdxSubtasks
+	focusIdxRepeat
+	focusIdxStart
 	focusIdxDate
+	focusIdxTime
 	focusIdxMax
 
 	// Padding to indent the datepicker calendar within the form.
@@ -26,16 +36,39 @@ const (
 type Form struct {
 	Title    textinput.Model
 	Desc     textinput.Model
-	Date     datepicker.Model
+	List     textinput.Model
+	Tags     textinput.Model
+	Repeat   textinput.Model
 	Done     bool
+	Priority task.Priority
 	focusIdx int
-	keymap   *EditTaskKeyMap
+
+	// Subtasks holds the checklist items being edited, and SubtaskInput
+	// the title of the next item to add.
+	Subtasks      []task.Subtask
+	SubtaskInput  textinput.Model
+	subtaskCursor int
+
+	// Date and Start pick the due and start dates. Both are optional:
+	// while HasDate or HasStart is false the picker only holds the day
+	// offered when the user starts picking one. DueTime holds the
+	// optional time of day and zone of the deadline.
+	Date     datepicker.Model
+	HasDate  bool
+	DueTime  textinput.Model
+	Start    datepicker.Model
+	HasStart bool
+
+	keymap *EditTaskKeyMap
+
+	// knownTags holds tags used elsewhere, offered as completions.
+	knownTags []string
 
 	styles Styles
 }
 
-// NewForm constructs a Form with initial values for the task fields,
-// defaulting the due date to now if it is zero.
+// NewForm constructs a Form with initial values for the task fields. A
+// zero due date leaves the task without one.
 func NewForm(
 	title, desc string,
 	dueDate time.Time,
@@ -43,21 +76,21 @@ func NewForm(
 	keymap *EditTaskKeyMap,
 	styles Styles,
 ) Form {
-	// Default due date to "now" if zero so the datepicker has a sensible value.
-	if dueDate.IsZero() {
-		dueDate = time.Now()
-	}
-
-	dp := datepicker.NewWithRange(dueDate, dueDate, time.Time{})
-
 	return Form{
-		Title:    newTitleInput(title),
-		Desc:     newDescInput(desc),
-		Date:     dp,
-		Done:     done,
-		focusIdx: focusIdxTitle,
-		keymap:   keymap,
-		styles:   styles,
+		Title:        newTitleInput(title),
+		Desc:         newDescInput(desc),
+		List:         newListInput(""),
+		Tags:         newTagsInput(""),
+		Repeat:       newRepeatInput(""),
+		SubtaskInput: newSubtaskInput(),
+		Date:         newDatePicker(dueDate),
+		HasDate:      !dueDate.IsZero(),
+		DueTime:      newDueTimeInput(),
+		Start:        newDatePicker(time.Time{}),
+		Done:         done,
+		focusIdx:     focusIdxTitle,
+		keymap:       keymap,
+		styles:       styles,
 	}
 }
 
@@ -86,6 +119,197 @@ func newDescInput(initial string) textinput.Model {
 	return ti
 }
 
+// newListInput configures a text input for the name of the task list.
+func newListInput(initial string) textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = defaultListPrompt
+	ti.PromptStyle.Underline(true)
+	ti.Placeholder = task.DefaultList
+	ti.SetValue(initial)
+	ti.SetCursor(len(initial))
+	ti.Width = defaultTextInputWidth
+	return ti
+}
+
+// newTagsInput configures a text input for the tags field.
+func newTagsInput(initial string) textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = defaultTagsPrompt
+	ti.PromptStyle.Underline(true)
+	ti.Placeholder = defaultTagsPlaceholder
+	ti.SetValue(initial)
+	ti.SetCursor(len(initial))
+	ti.Width = defaultTextInputWidth
+	return ti
+}
+
+// newSubtaskInput configures a text input for adding checklist items.
+func newSubtaskInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = defaultSubtaskPrompt
+	ti.PromptStyle.Underline(true)
+	ti.Placeholder = defaultSubtaskPlaceholder
+	ti.Width = defaultTextInputWidth
+	return ti
+}
+
+// newRepeatInput configures a text input for the recurrence rule.
+func newRepeatInput(initial string) textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = defaultRepeatPrompt
+	ti.PromptStyle.Underline(true)
+	ti.Placeholder = defaultRepeatPlaceholder
+	ti.SetValue(initial)
+	ti.SetCursor(len(initial))
+	ti.Width = defaultTextInputWidth
+	return ti
+}
+
+// newDueTimeInput configures a text input for the time of day a task
+// is due.
+func newDueTimeInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = defaultDueTimePrompt
+	ti.PromptStyle.Underline(true)
+	ti.Placeholder = defaultDueTimePlaceholder
+	ti.Width = defaultTextInputWidth
+	return ti
+}
+
+// newDatePicker configures a date picker starting at initial, or at
+// today when initial is zero, that does not go back before that day.
+func newDatePicker(initial time.Time) datepicker.Model {
+	if initial.IsZero() {
+		initial = time.Now()
+	}
+	return datepicker.NewWithRange(initial, initial, time.Time{})
+}
+
+// SetStartDate replaces the start date, or clears it when start is
+// zero.
+func (f Form) SetStartDate(start time.Time) Form {
+	f.Start = newDatePicker(start)
+	f.HasStart = !start.IsZero()
+	return f
+}
+
+// SetDue replaces the due date and time with those of t.
+func (f Form) SetDue(t task.Task) Form {
+	f.HasDate = t.HasDueDate()
+	day := t.DueDate
+	if t.DueHasTime {
+		day = day.In(t.DueLocation())
+	}
+	f.Date = newDatePicker(day)
+
+	clock := t.DueClock()
+	f.DueTime.SetValue(clock)
+	f.DueTime.SetCursor(len(clock))
+	return f
+}
+
+// DueDateValue returns the picked due date, or the zero time when the
+// task has none.
+func (f Form) DueDateValue() time.Time {
+	if !f.HasDate {
+		return time.Time{}
+	}
+	return f.Date.Time
+}
+
+// DueValue returns a task carrying only the picked due date and time,
+// or an error when the time of day cannot be parsed.
+func (f Form) DueValue() (task.Task, error) {
+	var t task.Task
+	err := t.SetDue(f.DueDateValue(), f.DueTime.Value())
+	return t, err
+}
+
+// StartDateValue returns the picked start date, or the zero time when
+// the task has none.
+func (f Form) StartDateValue() time.Time {
+	if !f.HasStart {
+		return time.Time{}
+	}
+	return f.Start.Time
+}
+
+// SetRecurrence replaces the value of the repeat input with the given
+// rule, or clears it when r is nil.
+func (f Form) SetRecurrence(r *task.Recurrence) Form {
+	var value string
+	if r != nil {
+		value = r.String()
+	}
+	f.Repeat.SetValue(value)
+	f.Repeat.SetCursor(len(value))
+	return f
+}
+
+// RecurrenceValue parses the repeat input into a recurrence rule.
+func (f Form) RecurrenceValue() (*task.Recurrence, error) {
+	return task.ParseRecurrence(f.Repeat.Value())
+}
+
+// SetList replaces the value of the list input with the given list
+// name. The default list is shown as an empty input.
+func (f Form) SetList(name string) Form {
+	f.List.SetValue(name)
+	f.List.SetCursor(len(name))
+	return f
+}
+
+// ListValue returns the normalized list name from the list input.
+func (f Form) ListValue() string {
+	return task.NormalizeList(f.List.Value())
+}
+
+// SetTags replaces the value of the tags input with the given tags.
+func (f Form) SetTags(tags []string) Form {
+	value := task.FormatTags(tags)
+	f.Tags.SetValue(value)
+	f.Tags.SetCursor(len(value))
+	return f
+}
+
+// TagValues parses the tags input into normalized tags.
+func (f Form) TagValues() []string {
+	return task.ParseTags(f.Tags.Value())
+}
+
+// SetKnownTags sets the tags offered as completions in the tags input.
+func (f Form) SetKnownTags(tags []string) Form {
+	f.knownTags = tags
+	return f
+}
+
+// tagCompletions returns the known tags matching the word currently
+// being typed in the tags input.
+func (f Form) tagCompletions() []string {
+	value := f.Tags.Value()
+	if value == "" || strings.HasSuffix(value, " ") || strings.HasSuffix(value, ",") {
+		return nil
+	}
+	words := strings.Fields(value)
+	return task.CompleteTag(words[len(words)-1], f.knownTags)
+}
+
+// completeTag replaces the word being typed in the tags input with the
+// first matching known tag.
+func (f Form) completeTag() Form {
+	completions := f.tagCompletions()
+	if len(completions) == 0 {
+		return f
+	}
+
+	value := f.Tags.Value()
+	cut := strings.LastIndexAny(value, " ,") + 1
+	value = value[:cut] + "#" + completions[0] + " "
+	f.Tags.SetValue(value)
+	f.Tags.SetCursor(len(value))
+	return f
+}
+
 // Update processes incoming messages for the form, cycling focus on
 // SaveField and delegating updates to each sub-input.
 func (f Form) Update(msg tea.Msg) (Form, tea.Cmd) {
@@ -95,6 +319,11 @@ func (f Form) Update(msg tea.Msg) (Form, tea.Cmd) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, f.keymap.SaveField):
+			// Enter on a non-empty checklist input adds the item
+			// instead of moving on to the next field.
+			if f.focusIdx == focusIdxSubtasks && strings.TrimSpace(f.SubtaskInput.Value()) != "" {
+				return f.addSubtask(), nil
+			}
 			f.focusIdx = (f.focusIdx + 1) % focusIdxMax
 			f = f.setFocus()
 		}
@@ -111,29 +340,162 @@ func (f Form) Update(msg tea.Msg) (Form, tea.Cmd) {
 		f.Desc, cmd = f.Desc.Update(msg)
 		cmds = append(cmds, cmd)
 
+	case focusIdxList:
+		f.List, cmd = f.List.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case focusIdxTags:
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && key.Matches(keyMsg, f.keymap.CompleteTag) {
+			f = f.completeTag()
+			break
+		}
+		f.Tags, cmd = f.Tags.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case focusIdxPriority:
+		f = f.updatePriority(msg)
+
+	case focusIdxSubtasks:
+		f, cmd = f.updateSubtasks(msg)
+		cmds = append(cmds, cmd)
+
+	case focusIdxRepeat:
+		f.Repeat, cmd = f.Repeat.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case focusIdxStart:
+		f.Start, f.HasStart, cmd = f.updateDate(f.Start, f.HasStart, msg)
+		cmds = append(cmds, cmd)
+
 	case focusIdxDate:
-		f.Date, cmd = f.Date.Update(msg)
+		f.Date, f.HasDate, cmd = f.updateDate(f.Date, f.HasDate, msg)
+		cmds = append(cmds, cmd)
+
+	case focusIdxTime:
+		f.DueTime, cmd = f.DueTime.Update(msg)
 		cmds = append(cmds, cmd)
 	}
 
 	return f, tea.Batch(cmds...)
 }
 
+// updatePriority cycles the selected priority when the priority field
+// is focused and one of the priority keys is pressed.
+func (f Form) updatePriority(msg tea.Msg) Form {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return f
+	}
+
+	switch {
+	case key.Matches(keyMsg, f.keymap.PriorityNext):
+		f.Priority = f.Priority.Next()
+	case key.Matches(keyMsg, f.keymap.PriorityPrev):
+		f.Priority = f.Priority.Prev()
+	}
+	return f
+}
+
+// updateDate forwards input to one of the optional date pickers. The
+// clear key unsets the date, and the first calendar key pressed on an
+// unset date sets it to the day the picker offers.
+func (f Form) updateDate(dp datepicker.Model, set bool, msg tea.Msg) (datepicker.Model, bool, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(keyMsg, f.keymap.DateClear):
+			return dp, false, nil
+		case !set:
+			picking := key.Matches(keyMsg, f.keymap.DateUp, f.keymap.DateDown, f.keymap.DateNext, f.keymap.DatePrevious)
+			return dp, picking, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	dp, cmd = dp.Update(msg)
+	return dp, set, cmd
+}
+
+// updateSubtasks moves the checklist cursor, toggles or removes the
+// item under it, and otherwise forwards input to the new item field.
+func (f Form) updateSubtasks(msg tea.Msg) (Form, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(keyMsg, f.keymap.SubtaskUp):
+			if f.subtaskCursor > 0 {
+				f.subtaskCursor--
+			}
+			return f, nil
+
+		case key.Matches(keyMsg, f.keymap.SubtaskDown):
+			if f.subtaskCursor < len(f.Subtasks)-1 {
+				f.subtaskCursor++
+			}
+			return f, nil
+
+		case key.Matches(keyMsg, f.keymap.SubtaskToggle):
+			if f.subtaskCursor < len(f.Subtasks) {
+				f.Subtasks[f.subtaskCursor].Done = !f.Subtasks[f.subtaskCursor].Done
+			}
+			return f, nil
+
+		case key.Matches(keyMsg, f.keymap.SubtaskRemove):
+			if f.subtaskCursor < len(f.Subtasks) {
+				f.Subtasks = slices.Delete(f.Subtasks, f.subtaskCursor, f.subtaskCursor+1)
+				f.subtaskCursor = max(0, min(f.subtaskCursor, len(f.Subtasks)-1))
+			}
+			return f, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	f.SubtaskInput, cmd = f.SubtaskInput.Update(msg)
+	return f, cmd
+}
+
+// addSubtask appends a new unchecked item from the checklist input and
+// moves the cursor onto it.
+func (f Form) addSubtask() Form {
+	title := strings.TrimSpace(f.SubtaskInput.Value())
+	f.Subtasks = append(f.Subtasks, task.NewSubtask(title))
+	f.subtaskCursor = len(f.Subtasks) - 1
+	f.SubtaskInput.Reset()
+	return f
+}
+
 // setFocus applies focus to the active field based on the current
 // focus index and blurs all others.
 func (f Form) setFocus() Form {
 	f.Title.Blur()
 	f.Desc.Blur()
+	f.List.Blur()
+	f.Tags.Blur()
+	f.SubtaskInput.Blur()
+	f.Repeat.Blur()
+	f.Start.Blur()
 	f.Date.Blur()
+	f.DueTime.Blur()
 
 	switch f.focusIdx {
 	case focusIdxTitle:
 		f.Title.Focus()
 	case focusIdxDesc:
 		f.Desc.Focus()
+	case focusIdxList:
+		f.List.Focus()
+	case focusIdxTags:
+		f.Tags.Focus()
+	case focusIdxSubtasks:
+		f.SubtaskInput.Focus()
+	case focusIdxRepeat:
+		f.Repeat.Focus()
+	case focusIdxStart:
+		f.Start.SelectDate()
+		f.Start.SetFocus(datepicker.FocusCalendar)
 	case focusIdxDate:
 		f.Date.SelectDate()
 		f.Date.SetFocus(datepicker.FocusCalendar)
+	case focusIdxTime:
+		f.DueTime.Focus()
 	}
 
 	return f
@@ -145,30 +507,125 @@ func (f Form) View() string {
 	f.Title.PromptStyle = f.styles.Normal
 	f.Desc.TextStyle = f.styles.Normal
 	f.Desc.PromptStyle = f.styles.Normal
+	f.List.TextStyle = f.styles.Normal
+	f.List.PromptStyle = f.styles.Normal
+	f.Tags.TextStyle = f.styles.Normal
+	f.Tags.PromptStyle = f.styles.Normal
+	f.SubtaskInput.TextStyle = f.styles.Normal
+	f.SubtaskInput.PromptStyle = f.styles.Normal
+	f.Repeat.TextStyle = f.styles.Normal
+	f.Repeat.PromptStyle = f.styles.Normal
+	f.DueTime.TextStyle = f.styles.Normal
+	f.DueTime.PromptStyle = f.styles.Normal
 
-	// Base calendar style: keep a fixed left padding so the calendar
-	// is horizontally aligned regardless of focus state.
-	calendarStyle := lipgloss.NewStyle().
-		AlignHorizontal(lipgloss.Center).
-		PaddingLeft(formCalendarPadding)
+	priorityStyle := f.styles.Normal
 
 	switch f.focusIdx {
 	case focusIdxTitle:
 		f.Title.PromptStyle = f.styles.Focused
 	case focusIdxDesc:
 		f.Desc.PromptStyle = f.styles.Focused
-	case focusIdxDate:
-		calendarStyle = calendarStyle.
-			Border(lipgloss.NormalBorder(), false, false, false, true).
-			BorderForeground(f.styles.Focused.GetForeground())
+	case focusIdxList:
+		f.List.PromptStyle = f.styles.Focused
+	case focusIdxTags:
+		f.Tags.PromptStyle = f.styles.Focused
+	case focusIdxPriority:
+		priorityStyle = f.styles.Focused
+	case focusIdxSubtasks:
+		f.SubtaskInput.PromptStyle = f.styles.Focused
+	case focusIdxRepeat:
+		f.Repeat.PromptStyle = f.styles.Focused
+	case focusIdxTime:
+		f.DueTime.PromptStyle = f.styles.Focused
 	}
 
-	calendar := calendarStyle.Render(f.Date.View())
-
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		f.Title.View(),
 		f.Desc.View(),
-		calendar,
+		f.List.View(),
+		f.tagsView(),
+		priorityStyle.Render(f.priorityView()),
+		f.subtasksView(),
+		f.Repeat.View(),
+		f.dateView(defaultStartPrompt, f.Start, f.HasStart, focusIdxStart),
+		f.dateView(defaultDuePrompt, f.Date, f.HasDate, focusIdxDate),
+		f.DueTime.View(),
 	)
 }
+
+// dateView renders one of the optional date fields: a summary line,
+// followed by the calendar while the field is focused and set.
+func (f Form) dateView(prompt string, dp datepicker.Model, set bool, idx int) string {
+	focused := f.focusIdx == idx
+
+	value := noDateLabel
+	if set {
+		value = dp.Time.Format(dateFormat)
+	}
+	lineStyle := f.styles.Normal
+	if focused {
+		lineStyle = f.styles.Focused
+		if set {
+			value += f.styles.Blurred.UnsetPadding().Render(dateClearHint)
+		} else {
+			value += f.styles.Blurred.UnsetPadding().Render(datePickHint)
+		}
+	}
+	line := lineStyle.Render(prompt + value)
+	if !focused || !set {
+		return line
+	}
+
+	// Keep a fixed left padding so the calendar is horizontally aligned
+	// with the other fields.
+	calendar := lipgloss.NewStyle().
+		AlignHorizontal(lipgloss.Center).
+		PaddingLeft(formCalendarPadding).
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(f.styles.Focused.GetForeground()).
+		Render(dp.View())
+	return lipgloss.JoinVertical(lipgloss.Left, line, calendar)
+}
+
+// priorityView renders the priority selector line.
+func (f Form) priorityView() string {
+	return defaultPriorityPrompt + "‹ " + f.Priority.String() + " ›"
+}
+
+// tagsView renders the tags input followed by completions for the word
+// being typed while the field is focused.
+func (f Form) tagsView() string {
+	view := f.Tags.View()
+	if f.focusIdx != focusIdxTags {
+		return view
+	}
+
+	completions := f.tagCompletions()
+	if len(completions) == 0 {
+		return view
+	}
+	hint := f.styles.Blurred.Render(defaultTagsHintPrefix + task.FormatTags(completions))
+	return lipgloss.JoinVertical(lipgloss.Left, view, hint)
+}
+
+// subtasksView renders the checklist input followed by the existing
+// items, marking the item under the cursor while the field is focused.
+func (f Form) subtasksView() string {
+	lines := []string{f.SubtaskInput.View()}
+	for i, s := range f.Subtasks {
+		box := subtaskUncheckedBox
+		if s.Done {
+			box = subtaskCheckedBox
+		}
+
+		style := f.styles.Blurred
+		cursor := "  "
+		if f.focusIdx == focusIdxSubtasks && i == f.subtaskCursor {
+			style = f.styles.Focused
+			cursor = "› "
+		}
+		lines = append(lines, style.Render(cursor+box+s.Title()))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
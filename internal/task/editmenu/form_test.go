@@ -1,11 +1,13 @@
 package editmenu
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	datepicker "github.com/ethanefung/bubble-datepicker"
+	"github.com/jacobdanielrose/terminaltask/internal/task"
 )
 
 //
@@ -123,11 +125,46 @@ func TestNewForm_Update_FocusCycle(t *testing.T) {
 		t.Errorf("expected focusIdx to be focusIdxDesc, got %v", f.focusIdx)
 	}
 
+	f, _ = f.Update(msg)
+	if f.focusIdx != focusIdxList {
+		t.Errorf("expected focusIdx to be focusIdxList, got %v", f.focusIdx)
+	}
+
+	f, _ = f.Update(msg)
+	if f.focusIdx != focusIdxTags {
+		t.Errorf("expected focusIdx to be focusIdxTags, got %v", f.focusIdx)
+	}
+
+	f, _ = f.Update(msg)
+	if f.focusIdx != focusIdxPriority {
+		t.Errorf("expected focusIdx to be focusIdxPriority, got %v", f.focusIdx)
+	}
+
+	f, _ = f.Update(msg)
+	if f.focusIdx != focusIdxSubtasks {
+		t.Errorf("expected focusIdx to be focusIdxSubtasks, got %v", f.focusIdx)
+	}
+
+	f, _ = f.Update(msg)
+	if f.focusIdx != focusIdxRepeat {
+		t.Errorf("expected focusIdx to be focusIdxRepeat, got %v", f.focusIdx)
+	}
+
+	f, _ = f.Update(msg)
+	if f.focusIdx != focusIdxStart {
+		t.Errorf("expected focusIdx to be focusIdxStart, got %v", f.focusIdx)
+	}
+
 	f, _ = f.Update(msg)
 	if f.focusIdx != focusIdxDate {
 		t.Errorf("expected focusIdx to be focusIdxDate, got %v", f.focusIdx)
 	}
 
+	f, _ = f.Update(msg)
+	if f.focusIdx != focusIdxTime {
+		t.Errorf("expected focusIdx to be focusIdxTime, got %v", f.focusIdx)
+	}
+
 	// make sure it cycles back to the title
 	f, _ = f.Update(msg)
 	if f.focusIdx != focusIdxTitle {
@@ -135,6 +172,187 @@ func TestNewForm_Update_FocusCycle(t *testing.T) {
 	}
 }
 
+func TestForm_ListValue(t *testing.T) {
+	f := NewForm("title", "desc", time.Time{}, false, newEditTaskKeyMap(), Styles{})
+
+	if got := f.SetList("  Work ").ListValue(); got != "Work" {
+		t.Errorf("ListValue() = %q, want %q", got, "Work")
+	}
+	// Naming the default list is the same as leaving the field empty.
+	if got := f.SetList(task.DefaultList).ListValue(); got != "" {
+		t.Errorf("ListValue() = %q, want empty for the default list", got)
+	}
+}
+
+func TestNewForm_Update_PriorityCycle(t *testing.T) {
+	f := NewForm("title", "desc", time.Time{}, false, newEditTaskKeyMap(), Styles{})
+	f.focusIdx = focusIdxPriority
+
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+
+	f, _ = f.Update(right)
+	if f.Priority != task.PriorityLow {
+		t.Errorf("Priority after right = %v, want %v", f.Priority, task.PriorityLow)
+	}
+
+	f, _ = f.Update(left)
+	f, _ = f.Update(left)
+	if f.Priority != task.PriorityUrgent {
+		t.Errorf("Priority after wrapping left = %v, want %v", f.Priority, task.PriorityUrgent)
+	}
+
+	// Priority keys must not change the priority while another field is focused.
+	f.focusIdx = focusIdxTitle
+	f, _ = f.Update(right)
+	if f.Priority != task.PriorityUrgent {
+		t.Errorf("Priority changed while title focused: got %v", f.Priority)
+	}
+}
+
+func TestNewForm_Tags_CompleteAndParse(t *testing.T) {
+	f := NewForm("title", "desc", time.Time{}, false, newEditTaskKeyMap(), Styles{})
+	f = f.SetKnownTags([]string{"backend", "build", "oncall"})
+	f.focusIdx = focusIdxTags
+	f = f.setFocus()
+
+	f.Tags.SetValue("#oncall ba")
+	if got := f.tagCompletions(); len(got) != 1 || got[0] != "backend" {
+		t.Fatalf("tagCompletions() = %v, want [backend]", got)
+	}
+
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if got, want := f.Tags.Value(), "#oncall #backend "; got != want {
+		t.Fatalf("Tags.Value() after completion = %q, want %q", got, want)
+	}
+
+	tags := f.TagValues()
+	if len(tags) != 2 || tags[0] != "oncall" || tags[1] != "backend" {
+		t.Fatalf("TagValues() = %v, want [oncall backend]", tags)
+	}
+}
+
+func TestForm_RecurrenceRoundTrip(t *testing.T) {
+	f := NewForm("title", "desc", time.Time{}, false, newEditTaskKeyMap(), Styles{})
+
+	rule, err := task.ParseRecurrence("every 2 weeks")
+	if err != nil {
+		t.Fatalf("ParseRecurrence error = %v", err)
+	}
+	f = f.SetRecurrence(rule)
+	if got, want := f.Repeat.Value(), "every 2 weeks"; got != want {
+		t.Fatalf("Repeat.Value() = %q, want %q", got, want)
+	}
+
+	got, err := f.RecurrenceValue()
+	if err != nil || got == nil || got.Interval != 2 {
+		t.Fatalf("RecurrenceValue() = %v, %v; want every 2 weeks", got, err)
+	}
+
+	f = f.SetRecurrence(nil)
+	if got, err := f.RecurrenceValue(); got != nil || err != nil {
+		t.Fatalf("RecurrenceValue() after clearing = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestForm_SetTags(t *testing.T) {
+	f := NewForm("title", "desc", time.Time{}, false, newEditTaskKeyMap(), Styles{})
+	f = f.SetTags([]string{"a", "b"})
+
+	if got, want := f.Tags.Value(), "#a #b"; got != want {
+		t.Fatalf("Tags.Value() = %q, want %q", got, want)
+	}
+}
+
+func TestNewForm_Subtasks_AddToggleRemove(t *testing.T) {
+	f := NewForm("title", "desc", time.Time{}, false, newEditTaskKeyMap(), Styles{})
+	f.focusIdx = focusIdxSubtasks
+	f = f.setFocus()
+
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	for _, title := range []string{"first", "second"} {
+		f.SubtaskInput.SetValue(title)
+		f, _ = f.Update(enter)
+	}
+
+	if f.focusIdx != focusIdxSubtasks {
+		t.Fatalf("enter with a pending item moved focus to %v", f.focusIdx)
+	}
+	if len(f.Subtasks) != 2 {
+		t.Fatalf("len(Subtasks) = %d, want 2", len(f.Subtasks))
+	}
+	if f.SubtaskInput.Value() != "" {
+		t.Errorf("SubtaskInput.Value() = %q, want empty after adding", f.SubtaskInput.Value())
+	}
+
+	// Cursor sits on the last added item; move up and check it off.
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyUp})
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if !f.Subtasks[0].Done || f.Subtasks[1].Done {
+		t.Fatalf("Subtasks done = [%v %v], want [true false]", f.Subtasks[0].Done, f.Subtasks[1].Done)
+	}
+
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	if len(f.Subtasks) != 1 || f.Subtasks[0].Title() != "second" {
+		t.Fatalf("Subtasks after remove = %v, want only %q", f.Subtasks, "second")
+	}
+
+	// Enter on an empty input moves on to the next field.
+	f, _ = f.Update(enter)
+	if f.focusIdx != focusIdxRepeat {
+		t.Errorf("focusIdx = %v, want focusIdxRepeat", f.focusIdx)
+	}
+}
+
+func TestForm_Update_OptionalDates(t *testing.T) {
+	f := NewForm("title", "desc", time.Time{}, false, newEditTaskKeyMap(), Styles{})
+	if f.HasDate || !f.DueDateValue().IsZero() {
+		t.Fatalf("new form has a due date %v, want none", f.DueDateValue())
+	}
+
+	f.focusIdx = focusIdxDate
+	f = f.setFocus()
+
+	// The first calendar key sets the date to the offered day.
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if !f.HasDate || !f.DueDateValue().Equal(f.Date.Time) {
+		t.Fatalf("HasDate = %v after picking, want true", f.HasDate)
+	}
+	offered := f.Date.Time
+
+	// Later keys move it.
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if got := f.Date.Time; !got.Equal(offered.AddDate(0, 0, 1)) {
+		t.Errorf("Date = %v, want the next day after %v", got, offered)
+	}
+
+	// Delete clears it again.
+	f, _ = f.Update(tea.KeyMsg{Type: tea.KeyDelete})
+	if f.HasDate || !f.DueDateValue().IsZero() {
+		t.Errorf("DueDateValue() = %v after clearing, want zero", f.DueDateValue())
+	}
+}
+
+func TestForm_SetStartDate(t *testing.T) {
+	start := time.Date(2030, 1, 5, 0, 0, 0, 0, time.UTC)
+	f := NewForm("title", "desc", time.Time{}, false, newEditTaskKeyMap(), Styles{}).SetStartDate(start)
+
+	if !f.StartDateValue().Equal(start) {
+		t.Errorf("StartDateValue() = %v, want %v", f.StartDateValue(), start)
+	}
+	if !strings.Contains(f.View(), defaultStartPrompt+"2030-01-05") {
+		t.Errorf("View() does not show the start date")
+	}
+	if !strings.Contains(f.View(), defaultDuePrompt+noDateLabel) {
+		t.Errorf("View() does not show the missing due date")
+	}
+
+	if f := f.SetStartDate(time.Time{}); f.HasStart || !f.StartDateValue().IsZero() {
+		t.Errorf("StartDateValue() = %v, want zero after clearing", f.StartDateValue())
+	}
+}
+
 //
 // Text input configuration
 //
@@ -0,0 +1,115 @@
+package task
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// Filter is a list.FilterFunc that understands tags. Words in the
+// filter term starting with "#" must all be present as tags on a task
+// for it to match; the remaining words are fuzzy matched with the
+// list's default filter.
+func Filter(term string, targets []string) []list.Rank {
+	return filterAt(term, targets, nil, time.Now())
+}
+
+// FilterTasks returns a list.FilterFunc for a list whose items are
+// tasks, in order. Besides tags as in Filter, it understands words such
+// as "done:week", "created:today", or "updated:7d", which restrict
+// tasks to those completed, created, or updated within that range.
+func FilterTasks(tasks []Task) list.FilterFunc {
+	return func(term string, targets []string) []list.Rank {
+		return filterAt(term, targets, tasks, time.Now())
+	}
+}
+
+// filterAt implements FilterTasks, resolving relative date ranges
+// against now. Timestamp words are only understood when tasks are
+// given.
+func filterAt(term string, targets []string, tasks []Task, now time.Time) []list.Rank {
+	var (
+		tags   []string
+		ranges = map[string]dateRange{}
+		words  []string
+	)
+	for _, f := range strings.Fields(term) {
+		if strings.HasPrefix(f, tagPrefix) {
+			if tag := NormalizeTag(f); tag != "" {
+				tags = append(tags, tag)
+			}
+			continue
+		}
+		if key, value, ok := cutTimestampTerm(f); ok && tasks != nil {
+			if r, err := parseDateRange(value, now); err == nil {
+				ranges[key] = r
+				continue
+			}
+		}
+		words = append(words, f)
+	}
+
+	if len(tags) == 0 && len(ranges) == 0 {
+		return list.DefaultFilter(term, targets)
+	}
+
+	var (
+		candidates []string
+		indexes    []int
+	)
+	for i, target := range targets {
+		if !targetHasTags(target, tags) {
+			continue
+		}
+		if len(ranges) > 0 && (i >= len(tasks) || !tasks[i].stampsInRanges(ranges)) {
+			continue
+		}
+		candidates = append(candidates, target)
+		indexes = append(indexes, i)
+	}
+
+	if len(words) == 0 {
+		ranks := make([]list.Rank, len(indexes))
+		for i, idx := range indexes {
+			ranks[i] = list.Rank{Index: idx}
+		}
+		return ranks
+	}
+
+	ranks := list.DefaultFilter(strings.Join(words, " "), candidates)
+	for i := range ranks {
+		ranks[i].Index = indexes[ranks[i].Index]
+	}
+	return ranks
+}
+
+// targetHasTags reports whether a filter target produced by
+// Task.FilterValue contains every one of the given tags.
+func targetHasTags(target string, tags []string) bool {
+	var present []string
+	for _, f := range strings.Fields(target) {
+		if strings.HasPrefix(f, tagPrefix) {
+			present = append(present, NormalizeTag(f))
+		}
+	}
+	for _, tag := range tags {
+		if !slices.Contains(present, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// stampsInRanges reports whether every filtered timestamp of t is set
+// and falls within its range.
+func (t Task) stampsInRanges(ranges map[string]dateRange) bool {
+	for key, r := range ranges {
+		at := t.timestamp(key)
+		if at.IsZero() || !r.contains(at) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package task
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultList is the name shown for tasks that do not belong to any
+// named list.
+const DefaultList = "Tasks"
+
+// NormalizeList trims a list name entered by the user. Naming the
+// default list explicitly is the same as naming no list at all.
+func NormalizeList(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.EqualFold(name, DefaultList) {
+		return ""
+	}
+	return name
+}
+
+// ListName returns the name of the list the task belongs to.
+func (t Task) ListName() string {
+	if t.List == "" {
+		return DefaultList
+	}
+	return t.List
+}
+
+// InList reports whether the task belongs to the named list.
+func (t Task) InList(name string) bool {
+	return t.List == NormalizeList(name)
+}
+
+// CollectLists returns the names of the lists used by the given tasks:
+// the default list first, followed by named lists in the order they
+// first appear.
+func CollectLists(tasks []Task) []string {
+	lists := []string{DefaultList}
+	for _, t := range tasks {
+		if name := t.ListName(); !slices.Contains(lists, name) {
+			lists = append(lists, name)
+		}
+	}
+	return lists
+}
+
+// TasksInList returns the tasks that belong to the named list, in
+// their original order.
+func TasksInList(tasks []Task, name string) []Task {
+	var out []Task
+	for _, t := range tasks {
+		if t.InList(name) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
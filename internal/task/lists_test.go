@@ -0,0 +1,46 @@
+package task
+
+import "testing"
+
+func TestNormalizeList(t *testing.T) {
+	tests := map[string]string{
+		"":           "",
+		"  Work  ":   "Work",
+		DefaultList:  "",
+		"tasks":      "",
+		"Sprint 42 ": "Sprint 42",
+	}
+	for in, want := range tests {
+		if got := NormalizeList(in); got != want {
+			t.Errorf("NormalizeList(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCollectListsAndTasksInList(t *testing.T) {
+	tasks := []Task{
+		{TitleStr: "a", List: "Work"},
+		{TitleStr: "b"},
+		{TitleStr: "c", List: "Personal"},
+		{TitleStr: "d", List: "Work"},
+	}
+
+	lists := CollectLists(tasks)
+	want := []string{DefaultList, "Work", "Personal"}
+	if len(lists) != len(want) {
+		t.Fatalf("CollectLists() = %v, want %v", lists, want)
+	}
+	for i := range want {
+		if lists[i] != want[i] {
+			t.Fatalf("CollectLists() = %v, want %v", lists, want)
+		}
+	}
+
+	work := TasksInList(tasks, "Work")
+	if len(work) != 2 || work[0].TitleStr != "a" || work[1].TitleStr != "d" {
+		t.Fatalf("TasksInList(Work) = %v, want a and d", work)
+	}
+	if inbox := TasksInList(tasks, DefaultList); len(inbox) != 1 || inbox[0].TitleStr != "b" {
+		t.Fatalf("TasksInList(%s) = %v, want b", DefaultList, inbox)
+	}
+}
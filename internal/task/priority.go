@@ -0,0 +1,121 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Priority ranks how urgently a task needs attention. The zero value
+// is PriorityNone so tasks persisted before priorities existed load
+// without one.
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+// priorityNames maps each priority to its persisted and displayed name.
+var priorityNames = [...]string{
+	PriorityNone:   "none",
+	PriorityLow:    "low",
+	PriorityMedium: "medium",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+// Priorities returns every priority in ascending order.
+func Priorities() []Priority {
+	return []Priority{
+		PriorityNone,
+		PriorityLow,
+		PriorityMedium,
+		PriorityHigh,
+		PriorityUrgent,
+	}
+}
+
+// String returns the lowercase name of the priority.
+func (p Priority) String() string {
+	if p < PriorityNone || p > PriorityUrgent {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// Next returns the next higher priority, wrapping around to none.
+func (p Priority) Next() Priority {
+	return (p + 1) % (PriorityUrgent + 1)
+}
+
+// Prev returns the next lower priority, wrapping around to urgent.
+func (p Priority) Prev() Priority {
+	return (p + PriorityUrgent) % (PriorityUrgent + 1)
+}
+
+// ParsePriority converts a priority name back into a Priority.
+func ParsePriority(s string) (Priority, error) {
+	for p, name := range priorityNames {
+		if strings.EqualFold(s, name) {
+			return Priority(p), nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("unknown priority %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler so priorities are
+// stored by name rather than by their numeric value.
+func (p Priority) MarshalText() ([]byte, error) {
+	if p < PriorityNone || p > PriorityUrgent {
+		return nil, fmt.Errorf("invalid priority %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Priority) UnmarshalText(b []byte) error {
+	parsed, err := ParsePriority(string(b))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// priorityMarker is the glyph drawn in front of a prioritized task title.
+const priorityMarker = "●"
+
+// newPriorityStyles constructs the default marker colors for each
+// priority. PriorityNone has no marker and therefore no style.
+func newPriorityStyles() map[Priority]lipgloss.Style {
+	return map[Priority]lipgloss.Style{
+		PriorityLow: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#2E86C1", Dark: "#5DADE2"}),
+		PriorityMedium: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#B7950B", Dark: "#F4D03F"}),
+		PriorityHigh: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#CA6F1E", Dark: "#F39C12"}),
+		PriorityUrgent: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#FF0000", Dark: "#FF5555"}).
+			Bold(true),
+	}
+}
+
+// ComparePriority orders tasks from the highest priority to the
+// lowest. It is suitable for use with slices.SortStableFunc.
+func ComparePriority(a, b Task) int {
+	return cmp.Compare(b.Priority, a.Priority)
+}
+
+// SortByPriority sorts tasks in place from the highest priority to the
+// lowest, keeping the existing order of tasks with equal priority.
+func SortByPriority(tasks []Task) {
+	slices.SortStableFunc(tasks, ComparePriority)
+}
@@ -0,0 +1,93 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPriorityStringAndParse(t *testing.T) {
+	for _, p := range Priorities() {
+		got, err := ParsePriority(p.String())
+		if err != nil {
+			t.Fatalf("ParsePriority(%q) error = %v, want nil", p.String(), err)
+		}
+		if got != p {
+			t.Errorf("ParsePriority(%q) = %v, want %v", p.String(), got, p)
+		}
+	}
+
+	if _, err := ParsePriority("critical"); err == nil {
+		t.Errorf("ParsePriority(%q) error = nil, want non-nil", "critical")
+	}
+}
+
+func TestPriorityNextPrevWrap(t *testing.T) {
+	if got := PriorityUrgent.Next(); got != PriorityNone {
+		t.Errorf("PriorityUrgent.Next() = %v, want %v", got, PriorityNone)
+	}
+	if got := PriorityNone.Prev(); got != PriorityUrgent {
+		t.Errorf("PriorityNone.Prev() = %v, want %v", got, PriorityUrgent)
+	}
+	if got := PriorityLow.Next(); got != PriorityMedium {
+		t.Errorf("PriorityLow.Next() = %v, want %v", got, PriorityMedium)
+	}
+}
+
+func TestPriorityJSONRoundTrip(t *testing.T) {
+	in := Task{TitleStr: "x", Priority: PriorityHigh}
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+	if !contains(string(b), `"Priority":"high"`) {
+		t.Errorf("marshaled task %s, want priority stored by name", b)
+	}
+
+	var out Task
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if out.Priority != PriorityHigh {
+		t.Errorf("Priority = %v, want %v", out.Priority, PriorityHigh)
+	}
+}
+
+func TestPriorityJSONMissingFieldDefaultsToNone(t *testing.T) {
+	var out Task
+	if err := json.Unmarshal([]byte(`{"TitleStr":"legacy"}`), &out); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if out.Priority != PriorityNone {
+		t.Errorf("Priority = %v, want %v", out.Priority, PriorityNone)
+	}
+}
+
+func TestSortByPriority(t *testing.T) {
+	tasks := []Task{
+		{TitleStr: "a", Priority: PriorityLow},
+		{TitleStr: "b", Priority: PriorityUrgent},
+		{TitleStr: "c", Priority: PriorityNone},
+		{TitleStr: "d", Priority: PriorityUrgent},
+	}
+
+	SortByPriority(tasks)
+
+	want := []string{"b", "d", "a", "c"}
+	for i, w := range want {
+		if tasks[i].TitleStr != w {
+			t.Errorf("tasks[%d] = %q, want %q", i, tasks[i].TitleStr, w)
+		}
+	}
+}
+
+// contains is a small helper to avoid importing strings just for tests.
+func contains(s, substr string) bool {
+	n := len(substr)
+	for i := 0; i+n <= len(s); i++ {
+		if s[i:i+n] == substr {
+			return true
+		}
+	}
+	return false
+}
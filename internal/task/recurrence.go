@@ -0,0 +1,390 @@
+package task
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecurrenceKind selects how the next occurrence of a recurring task
+// is scheduled.
+type RecurrenceKind int
+
+const (
+	// RecurDays repeats every Interval days.
+	RecurDays RecurrenceKind = iota + 1
+	// RecurWeeks repeats every Interval weeks.
+	RecurWeeks
+	// RecurWeekdays repeats on each of the given Weekdays.
+	RecurWeekdays
+	// RecurMonthlyWeekday repeats on the Nth Weekday of every month.
+	RecurMonthlyWeekday
+)
+
+// lastWeek is the Week value meaning "the last one in the month".
+const lastWeek = -1
+
+// maxOccurrenceSkips bounds how many missed occurrences Next will skip
+// over when catching a schedule up to the present.
+const maxOccurrenceSkips = 1000
+
+var recurrenceKindNames = map[RecurrenceKind]string{
+	RecurDays:           "days",
+	RecurWeeks:          "weeks",
+	RecurWeekdays:       "weekdays",
+	RecurMonthlyWeekday: "monthly-weekday",
+}
+
+// MarshalText implements encoding.TextMarshaler so kinds are stored by
+// name rather than by their numeric value.
+func (k RecurrenceKind) MarshalText() ([]byte, error) {
+	name, ok := recurrenceKindNames[k]
+	if !ok {
+		return nil, fmt.Errorf("invalid recurrence kind %d", int(k))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *RecurrenceKind) UnmarshalText(b []byte) error {
+	for kind, name := range recurrenceKindNames {
+		if name == string(b) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown recurrence kind %q", b)
+}
+
+// Recurrence describes when a recurring task comes due again after it
+// is completed.
+type Recurrence struct {
+	Kind RecurrenceKind `json:"Kind"`
+
+	// Interval is the number of days or weeks between occurrences for
+	// RecurDays and RecurWeeks.
+	Interval int `json:"Interval,omitempty"`
+
+	// Weekdays lists the days a RecurWeekdays task repeats on.
+	Weekdays []time.Weekday `json:"Weekdays,omitempty"`
+
+	// Week and Weekday pick the day of the month for
+	// RecurMonthlyWeekday: Week is 1-4, or -1 for the last one.
+	Week    int          `json:"Week,omitempty"`
+	Weekday time.Weekday `json:"Weekday,omitempty"`
+
+	// AfterCompletion counts the interval from the day the task was
+	// completed instead of from its due date.
+	AfterCompletion bool `json:"AfterCompletion,omitempty"`
+}
+
+// Next returns the due date of the occurrence that follows due, given
+// that the task was completed at completed. Occurrences that would
+// already be overdue at completion are skipped. A zero due date, or an
+// AfterCompletion rule, schedules from the completion time instead.
+func (r Recurrence) Next(due, completed time.Time) time.Time {
+	base := due
+	if base.IsZero() || r.AfterCompletion {
+		return r.step(completed)
+	}
+
+	next := r.step(base)
+	for i := 0; i < maxOccurrenceSkips && !next.After(completed); i++ {
+		next = r.step(next)
+	}
+	return next
+}
+
+// step returns the first occurrence strictly after from.
+func (r Recurrence) step(from time.Time) time.Time {
+	interval := max(r.Interval, 1)
+
+	switch r.Kind {
+	case RecurWeeks:
+		return from.AddDate(0, 0, 7*interval)
+
+	case RecurWeekdays:
+		if len(r.Weekdays) == 0 {
+			return from.AddDate(0, 0, 7)
+		}
+		for d := 1; d <= 7; d++ {
+			next := from.AddDate(0, 0, d)
+			if slices.Contains(r.Weekdays, next.Weekday()) {
+				return next
+			}
+		}
+		return from.AddDate(0, 0, 7)
+
+	case RecurMonthlyWeekday:
+		next := nthWeekdayOfMonth(from, r.Week, r.Weekday)
+		if !next.After(from) {
+			next = nthWeekdayOfMonth(from.AddDate(0, 0, 1-from.Day()).AddDate(0, 1, 0), r.Week, r.Weekday)
+		}
+		return next
+
+	default:
+		return from.AddDate(0, 0, interval)
+	}
+}
+
+// nthWeekdayOfMonth returns the given weekday in week n of the month
+// containing t, keeping t's time of day. A week of -1 selects the last
+// matching weekday of the month.
+func nthWeekdayOfMonth(t time.Time, n int, wd time.Weekday) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	if n == lastWeek {
+		last := first.AddDate(0, 1, -1)
+		offset := (int(last.Weekday()) - int(wd) + 7) % 7
+		return last.AddDate(0, 0, -offset)
+	}
+
+	offset := (int(wd) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// String renders the rule in the same form ParseRecurrence accepts,
+// e.g. "every 2 weeks", "every mon, fri", or "monthly last fri".
+func (r Recurrence) String() string {
+	var s string
+
+	switch r.Kind {
+	case RecurDays, RecurWeeks:
+		unit := "day"
+		if r.Kind == RecurWeeks {
+			unit = "week"
+		}
+		if r.Interval > 1 {
+			s = fmt.Sprintf("every %d %ss", r.Interval, unit)
+		} else {
+			s = "every " + unit
+		}
+
+	case RecurWeekdays:
+		names := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			names[i] = shortWeekday(wd)
+		}
+		s = "every " + strings.Join(names, ", ")
+
+	case RecurMonthlyWeekday:
+		s = fmt.Sprintf("monthly %s %s", ordinal(r.Week), shortWeekday(r.Weekday))
+
+	default:
+		return ""
+	}
+
+	if r.AfterCompletion {
+		s += " after done"
+	}
+	return s
+}
+
+// ParseRecurrence parses a recurrence rule written by a user. An empty
+// string or "none" means the task does not repeat and yields nil.
+//
+// Accepted forms:
+//
+//	daily, weekly, every day, every 3 days, every 2 weeks
+//	every mon, wed, fri
+//	monthly 2nd tue, monthly last fri
+//
+// Day and week intervals may end in "after done" to count from the
+// completion date instead of the due date.
+func ParseRecurrence(s string) (*Recurrence, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == "none" {
+		return nil, nil
+	}
+
+	var r Recurrence
+	if rest, ok := strings.CutSuffix(s, "after done"); ok {
+		r.AfterCompletion = true
+		s = strings.TrimSpace(rest)
+	}
+
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty recurrence %q", s)
+	}
+
+	switch fields[0] {
+	case "daily":
+		r.Kind, r.Interval = RecurDays, 1
+		fields = fields[1:]
+
+	case "weekly":
+		r.Kind, r.Interval = RecurWeeks, 1
+		fields = fields[1:]
+
+	case "monthly":
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("monthly recurrence must look like %q", "monthly 2nd tue")
+		}
+		week, err := parseOrdinal(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		wd, err := parseWeekday(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		r.Kind, r.Week, r.Weekday = RecurMonthlyWeekday, week, wd
+		fields = nil
+
+	case "every":
+		var err error
+		fields, err = r.parseEvery(fields[1:])
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		// Allow the "3 days after done" shorthand.
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			return nil, fmt.Errorf("unknown recurrence %q", s)
+		}
+		var err error
+		fields, err = r.parseEvery(fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fields) != 0 {
+		return nil, fmt.Errorf("unexpected %q in recurrence", strings.Join(fields, " "))
+	}
+	if r.AfterCompletion && r.Kind != RecurDays && r.Kind != RecurWeeks {
+		return nil, fmt.Errorf("%q only applies to day and week intervals", "after done")
+	}
+	return &r, nil
+}
+
+// parseEvery parses the words following "every" into r and returns any
+// words it did not consume.
+func (r *Recurrence) parseEvery(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing interval after %q", "every")
+	}
+
+	interval := 1
+	if n, err := strconv.Atoi(fields[0]); err == nil {
+		if n < 1 {
+			return nil, fmt.Errorf("interval must be at least 1, got %d", n)
+		}
+		interval = n
+		fields = fields[1:]
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("missing unit after interval %d", n)
+		}
+	}
+
+	switch strings.TrimSuffix(fields[0], "s") {
+	case "day":
+		r.Kind, r.Interval = RecurDays, interval
+		return fields[1:], nil
+	case "week":
+		r.Kind, r.Interval = RecurWeeks, interval
+		return fields[1:], nil
+	}
+
+	if interval != 1 {
+		return nil, fmt.Errorf("unknown unit %q", fields[0])
+	}
+
+	r.Kind = RecurWeekdays
+	for _, f := range fields {
+		wd, err := parseWeekday(f)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(r.Weekdays, wd) {
+			r.Weekdays = append(r.Weekdays, wd)
+		}
+	}
+	slices.Sort(r.Weekdays)
+	return nil, nil
+}
+
+// parseWeekday accepts full or abbreviated English weekday names.
+func parseWeekday(s string) (time.Weekday, error) {
+	if len(s) >= 3 {
+		for wd := time.Sunday; wd <= time.Saturday; wd++ {
+			if strings.HasPrefix(strings.ToLower(wd.String()), s) {
+				return wd, nil
+			}
+		}
+	}
+	return time.Sunday, fmt.Errorf("unknown weekday %q", s)
+}
+
+// shortWeekday returns the three-letter lowercase name of a weekday.
+func shortWeekday(wd time.Weekday) string {
+	return strings.ToLower(wd.String()[:3])
+}
+
+var ordinals = []string{"1st", "2nd", "3rd", "4th"}
+
+// parseOrdinal parses "1st" through "4th" or "last" into a week number.
+func parseOrdinal(s string) (int, error) {
+	if s == "last" {
+		return lastWeek, nil
+	}
+	if i := slices.Index(ordinals, s); i >= 0 {
+		return i + 1, nil
+	}
+	return 0, fmt.Errorf("unknown week %q, want 1st-4th or last", s)
+}
+
+// ordinal formats a week number as parsed by parseOrdinal.
+func ordinal(week int) string {
+	if week >= 1 && week <= len(ordinals) {
+		return ordinals[week-1]
+	}
+	return "last"
+}
+
+// IsRecurring reports whether the task repeats after it is completed.
+func (t Task) IsRecurring() bool {
+	return t.Recurrence != nil
+}
+
+// NextOccurrence returns a fresh copy of a recurring task for its next
+// occurrence, given that it was completed at completed. The copy gets a
+// new ID, an unchecked checklist, cleared timestamps, and a due date
+// shifted according to the recurrence rule. A start date moves along
+// with the due date.
+func (t Task) NextOccurrence(completed time.Time) Task {
+	next := t
+	next.ID = uuid.New()
+	next.Tags = slices.Clone(t.Tags)
+	next.Subtasks = make([]Subtask, len(t.Subtasks))
+	for i, s := range t.Subtasks {
+		next.Subtasks[i] = NewSubtask(s.Title())
+	}
+	next.Done = false
+	next.CreatedAt, next.UpdatedAt, next.CompletedAt = time.Time{}, time.Time{}, time.Time{}
+	// Step due times in the zone they were set in, so a 09:00 deadline
+	// stays at 09:00 across daylight saving changes.
+	due := t.DueDate
+	if t.DueHasTime {
+		due = due.In(t.DueLocation())
+	}
+	next.DueDate = t.Recurrence.Next(due, completed)
+	next.StartDate = time.Time{}
+	if t.HasStartDate() && t.HasDueDate() {
+		// Keep the same lead time between start and due date.
+		next.StartDate = next.DueDate.Add(-t.DueDate.Sub(t.StartDate))
+	}
+
+	rule := *t.Recurrence
+	rule.Weekdays = slices.Clone(rule.Weekdays)
+	next.Recurrence = &rule
+	return next
+}
@@ -0,0 +1,158 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence_RoundTrip(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "daily", want: "every day"},
+		{in: "weekly", want: "every week"},
+		{in: "every 3 days", want: "every 3 days"},
+		{in: "Every 2 Weeks", want: "every 2 weeks"},
+		{in: "every fri, mon,wednesday", want: "every mon, wed, fri"},
+		{in: "monthly 2nd tue", want: "monthly 2nd tue"},
+		{in: "monthly last friday", want: "monthly last fri"},
+		{in: "3 days after done", want: "every 3 days after done"},
+		{in: "every week after done", want: "every week after done"},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.in)
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("ParseRecurrence(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRecurrence_EmptyAndInvalid(t *testing.T) {
+	for _, in := range []string{"", "  ", "none"} {
+		r, err := ParseRecurrence(in)
+		if r != nil || err != nil {
+			t.Errorf("ParseRecurrence(%q) = %v, %v; want nil, nil", in, r, err)
+		}
+	}
+
+	for _, in := range []string{
+		"sometimes",
+		"every",
+		"every 0 days",
+		"every 2 fortnights",
+		"monthly 5th mon",
+		"monthly 1st",
+		"every mon after done",
+		"every 2 days please",
+	} {
+		if _, err := ParseRecurrence(in); err == nil {
+			t.Errorf("ParseRecurrence(%q) error = nil, want non-nil", in)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	// Monday 2026-03-02.
+	due := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	onTime := due.Add(-time.Hour)
+
+	tests := []struct {
+		rule      string
+		completed time.Time
+		want      time.Time
+	}{
+		{rule: "every 3 days", completed: onTime, want: time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{rule: "every 2 weeks", completed: onTime, want: time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{rule: "every wed, fri", completed: onTime, want: time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)},
+		{rule: "every mon", completed: onTime, want: time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)},
+		{rule: "monthly 1st mon", completed: onTime, want: time.Date(2026, 4, 6, 9, 0, 0, 0, time.UTC)},
+		{rule: "monthly last fri", completed: onTime, want: time.Date(2026, 3, 27, 9, 0, 0, 0, time.UTC)},
+		// Completed late: missed occurrences are skipped.
+		{rule: "every day", completed: due.AddDate(0, 0, 3), want: time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC)},
+		// After-completion rules count from the completion time.
+		{rule: "2 days after done", completed: due.AddDate(0, 0, 5), want: due.AddDate(0, 0, 7)},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) error = %v", tt.rule, err)
+		}
+		if got := r.Next(due, tt.completed); !got.Equal(tt.want) {
+			t.Errorf("%q Next() = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestTaskNextOccurrence(t *testing.T) {
+	rule, _ := ParseRecurrence("weekly")
+	due := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	tk := NewWithOptions("rotate keys", "desc", due, true)
+	tk.Tags = []string{"ops"}
+	tk.Subtasks = []Subtask{{TitleStr: "prod", Done: true}}
+	tk.Recurrence = rule
+	tk.StartDate = due.AddDate(0, 0, -2)
+
+	next := tk.NextOccurrence(due)
+
+	if next.GetID() == tk.GetID() {
+		t.Errorf("next occurrence reused the original ID")
+	}
+	if next.Done {
+		t.Errorf("next occurrence is already done")
+	}
+	if !next.DueDate.Equal(due.AddDate(0, 0, 7)) {
+		t.Errorf("next DueDate = %v, want %v", next.DueDate, due.AddDate(0, 0, 7))
+	}
+	if !next.StartDate.Equal(due.AddDate(0, 0, 5)) {
+		t.Errorf("next StartDate = %v, want %v", next.StartDate, due.AddDate(0, 0, 5))
+	}
+	if len(next.Subtasks) != 1 || next.Subtasks[0].Done || next.Subtasks[0].ID == tk.Subtasks[0].ID {
+		t.Errorf("next Subtasks = %+v, want one fresh unchecked item", next.Subtasks)
+	}
+	if next.Recurrence == tk.Recurrence {
+		t.Errorf("next occurrence shares the recurrence rule pointer")
+	}
+}
+
+func TestRecurrenceJSONRoundTrip(t *testing.T) {
+	rule, _ := ParseRecurrence("monthly last fri")
+	in := Task{TitleStr: "review", Recurrence: rule}
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+
+	var out Task
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if out.Recurrence == nil || out.Recurrence.String() != "monthly last fri" {
+		t.Fatalf("Recurrence = %v, want monthly last fri", out.Recurrence)
+	}
+}
+
+func TestTaskNextOccurrence_KeepsDueTimeAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	rule, _ := ParseRecurrence("weekly")
+	// Stored with a fixed offset, as it would be after loading from JSON.
+	due := time.Date(2030, 3, 25, 9, 0, 0, 0, berlin).In(time.FixedZone("", 60*60))
+	tk := Task{TitleStr: "standup", DueDate: due, DueHasTime: true, DueZone: "Europe/Berlin", Recurrence: rule}
+
+	next := tk.NextOccurrence(due)
+
+	if got := next.DueDate.In(berlin); got.Hour() != 9 || got.Day() != 1 {
+		t.Errorf("next DueDate = %v, want 09:00 on 2030-04-01 in Berlin", got)
+	}
+}
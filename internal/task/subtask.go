@@ -0,0 +1,86 @@
+package task
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Subtask is a single checklist item owned by a Task. Subtasks are
+// ordered and can be checked off independently of their parent.
+type Subtask struct {
+	ID       uuid.UUID `json:"ID"`
+	TitleStr string    `json:"TitleStr"`
+	Done     bool      `json:"Done"`
+}
+
+// NewSubtask constructs an unchecked Subtask with a generated ID.
+func NewSubtask(title string) Subtask {
+	return Subtask{
+		ID:       uuid.New(),
+		TitleStr: title,
+	}
+}
+
+// Title returns the subtask title.
+func (s Subtask) Title() string { return s.TitleStr }
+
+// HasSubtasks reports whether the task owns any checklist items.
+func (t Task) HasSubtasks() bool {
+	return len(t.Subtasks) > 0
+}
+
+// Progress returns how many of the task's subtasks are done and how
+// many there are in total.
+func (t Task) Progress() (done, total int) {
+	for _, s := range t.Subtasks {
+		if s.Done {
+			done++
+		}
+	}
+	return done, len(t.Subtasks)
+}
+
+// ProgressString formats the subtask progress as "done/total", or
+// returns an empty string when the task has no subtasks.
+func (t Task) ProgressString() string {
+	done, total := t.Progress()
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", done, total)
+}
+
+// SetDone marks the task and every one of its subtasks as done or not
+// done, so completing a checklist parent checks off all of its items.
+func (t *Task) SetDone(done bool) {
+	t.Done = done
+	for i := range t.Subtasks {
+		t.Subtasks[i].Done = done
+	}
+}
+
+// ToggleSubtask flips the completion state of the subtask with the
+// given ID and rolls the result up into the parent. It reports whether
+// a matching subtask was found.
+func (t *Task) ToggleSubtask(id uuid.UUID) bool {
+	for i := range t.Subtasks {
+		if t.Subtasks[i].ID == id {
+			t.Subtasks[i].Done = !t.Subtasks[i].Done
+			t.RollUpSubtasks()
+			return true
+		}
+	}
+	return false
+}
+
+// RollUpSubtasks derives the task's completion state from its
+// subtasks: a task with subtasks is done exactly when all of them are.
+// Tasks without subtasks are left unchanged.
+func (t *Task) RollUpSubtasks() {
+	if !t.HasSubtasks() {
+		return
+	}
+	done, total := t.Progress()
+	t.Done = done == total
+}
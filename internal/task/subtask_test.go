@@ -0,0 +1,77 @@
+package task
+
+import (
+	"testing"
+)
+
+func TestTaskProgress(t *testing.T) {
+	tk := Task{Subtasks: []Subtask{
+		{TitleStr: "a", Done: true},
+		{TitleStr: "b"},
+		{TitleStr: "c", Done: true},
+	}}
+
+	done, total := tk.Progress()
+	if done != 2 || total != 3 {
+		t.Fatalf("Progress() = %d/%d, want 2/3", done, total)
+	}
+	if got := tk.ProgressString(); got != "2/3" {
+		t.Fatalf("ProgressString() = %q, want %q", got, "2/3")
+	}
+	if got := (Task{}).ProgressString(); got != "" {
+		t.Fatalf("ProgressString() without subtasks = %q, want empty", got)
+	}
+}
+
+func TestTaskToggleSubtaskRollsUp(t *testing.T) {
+	a := NewSubtask("a")
+	b := NewSubtask("b")
+	tk := Task{Subtasks: []Subtask{a, b}}
+
+	if !tk.ToggleSubtask(a.ID) {
+		t.Fatalf("ToggleSubtask(a) = false, want true")
+	}
+	if tk.Done {
+		t.Fatalf("parent done after checking 1/2 subtasks")
+	}
+
+	tk.ToggleSubtask(b.ID)
+	if !tk.Done {
+		t.Fatalf("parent not done after checking every subtask")
+	}
+
+	tk.ToggleSubtask(a.ID)
+	if tk.Done {
+		t.Fatalf("parent still done after unchecking a subtask")
+	}
+
+	if tk.ToggleSubtask(NewSubtask("missing").ID) {
+		t.Fatalf("ToggleSubtask(unknown) = true, want false")
+	}
+}
+
+func TestTaskSetDoneAppliesToSubtasks(t *testing.T) {
+	tk := Task{Subtasks: []Subtask{NewSubtask("a"), NewSubtask("b")}}
+
+	tk.SetDone(true)
+	for i, s := range tk.Subtasks {
+		if !s.Done {
+			t.Errorf("subtask %d not done after SetDone(true)", i)
+		}
+	}
+
+	tk.SetDone(false)
+	for i, s := range tk.Subtasks {
+		if s.Done {
+			t.Errorf("subtask %d still done after SetDone(false)", i)
+		}
+	}
+}
+
+func TestTaskRollUpSubtasksWithoutSubtasks(t *testing.T) {
+	tk := Task{Done: true}
+	tk.RollUpSubtasks()
+	if !tk.Done {
+		t.Fatalf("RollUpSubtasks changed a task without subtasks")
+	}
+}
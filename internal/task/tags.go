@@ -0,0 +1,83 @@
+package task
+
+import (
+	"slices"
+	"strings"
+)
+
+// tagPrefix marks a word as a tag, both when displaying tags and when
+// filtering the list by them.
+const tagPrefix = "#"
+
+// NormalizeTag trims whitespace and any leading tag prefix from s and
+// lowercases the result, so "#Backend" and "backend" name the same tag.
+func NormalizeTag(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimLeft(s, tagPrefix)
+	return strings.ToLower(s)
+}
+
+// ParseTags splits free-form user input on whitespace and commas into
+// a list of normalized, de-duplicated tags in input order.
+func ParseTags(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+
+	var tags []string
+	for _, f := range fields {
+		tag := NormalizeTag(f)
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// FormatTags renders tags as space separated words with the tag prefix,
+// e.g. "#backend #oncall".
+func FormatTags(tags []string) string {
+	words := make([]string, len(tags))
+	for i, tag := range tags {
+		words[i] = tagPrefix + tag
+	}
+	return strings.Join(words, " ")
+}
+
+// HasTag reports whether the task carries the given tag.
+func (t Task) HasTag(tag string) bool {
+	return slices.Contains(t.Tags, NormalizeTag(tag))
+}
+
+// CollectTags returns every distinct tag used by the given tasks in
+// sorted order. It is used to offer tag completions while editing.
+func CollectTags(tasks []Task) []string {
+	var tags []string
+	for _, t := range tasks {
+		for _, tag := range t.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// CompleteTag returns the known tags that start with the given prefix,
+// in the order they appear in known. An empty prefix matches nothing.
+func CompleteTag(prefix string, known []string) []string {
+	prefix = NormalizeTag(prefix)
+	if prefix == "" {
+		return nil
+	}
+
+	var matches []string
+	for _, tag := range known {
+		if strings.HasPrefix(tag, prefix) && tag != prefix {
+			matches = append(matches, tag)
+		}
+	}
+	return matches
+}
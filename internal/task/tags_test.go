@@ -0,0 +1,107 @@
+package task
+
+import (
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	got := ParseTags("#Backend, oncall  #backend ,, #")
+	want := []string{"backend", "oncall"}
+
+	if len(got) != len(want) {
+		t.Fatalf("ParseTags() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ParseTags()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFormatTags(t *testing.T) {
+	if got, want := FormatTags([]string{"backend", "oncall"}), "#backend #oncall"; got != want {
+		t.Fatalf("FormatTags() = %q, want %q", got, want)
+	}
+	if got := FormatTags(nil); got != "" {
+		t.Fatalf("FormatTags(nil) = %q, want empty", got)
+	}
+}
+
+func TestCollectTags(t *testing.T) {
+	tasks := []Task{
+		{Tags: []string{"oncall", "backend"}},
+		{Tags: []string{"backend", "docs"}},
+		{},
+	}
+
+	got := CollectTags(tasks)
+	want := []string{"backend", "docs", "oncall"}
+	if len(got) != len(want) {
+		t.Fatalf("CollectTags() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("CollectTags()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCompleteTag(t *testing.T) {
+	known := []string{"backend", "build", "oncall"}
+
+	if got := CompleteTag("#b", known); len(got) != 2 {
+		t.Errorf("CompleteTag(#b) = %v, want 2 matches", got)
+	}
+	if got := CompleteTag("backend", known); len(got) != 0 {
+		t.Errorf("CompleteTag(backend) = %v, want no matches for a complete tag", got)
+	}
+	if got := CompleteTag("", known); got != nil {
+		t.Errorf("CompleteTag(\"\") = %v, want nil", got)
+	}
+}
+
+func TestTaskFilterValueIncludesTags(t *testing.T) {
+	tk := Task{TitleStr: "deploy", Tags: []string{"backend"}}
+	if got, want := tk.FilterValue(), "deploy #backend"; got != want {
+		t.Fatalf("FilterValue() = %q, want %q", got, want)
+	}
+	if !tk.HasTag("#Backend") {
+		t.Fatalf("HasTag(#Backend) = false, want true")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	targets := []string{
+		Task{TitleStr: "deploy api", Tags: []string{"backend", "oncall"}}.FilterValue(),
+		Task{TitleStr: "write docs", Tags: []string{"docs"}}.FilterValue(),
+		Task{TitleStr: "deploy web", Tags: []string{"frontend", "oncall"}}.FilterValue(),
+	}
+
+	tests := []struct {
+		term string
+		want []int
+	}{
+		{term: "#oncall", want: []int{0, 2}},
+		{term: "#oncall #backend", want: []int{0}},
+		{term: "#oncall web", want: []int{2}},
+		{term: "#missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		ranks := Filter(tt.term, targets)
+		if len(ranks) != len(tt.want) {
+			t.Errorf("Filter(%q) returned %d ranks, want %d", tt.term, len(ranks), len(tt.want))
+			continue
+		}
+		for i, r := range ranks {
+			if r.Index != tt.want[i] {
+				t.Errorf("Filter(%q)[%d].Index = %d, want %d", tt.term, i, r.Index, tt.want[i])
+			}
+		}
+	}
+
+	// Terms without tags fall back to fuzzy matching.
+	if ranks := Filter("docs", targets); len(ranks) == 0 {
+		t.Errorf("Filter(%q) returned no ranks, want a fuzzy match", "docs")
+	}
+}
@@ -34,6 +34,18 @@ type ToggleDoneMsg struct{}
 // currently selected task.
 type DeleteMsg struct{}
 
+// PickBlockerMsg signals that the user wants to choose a task that
+// blocks the currently selected one.
+type PickBlockerMsg struct{}
+
+// ClearBlockersMsg signals that the user wants to remove every
+// blocked-by link from the currently selected task.
+type ClearBlockersMsg struct{}
+
+// ToggleSubtasksMsg signals that the user wants to expand or collapse
+// the subtasks beneath each task.
+type ToggleSubtasksMsg struct{}
+
 //
 // Styles
 //
@@ -63,6 +75,16 @@ type Styles struct {
 
 	// StatusMessage styles status text shown for task operations.
 	StatusMessage lipgloss.Style
+
+	// Priority colors the marker drawn in front of prioritized tasks.
+	Priority map[Priority]lipgloss.Style
+
+	// Tag styles the chips rendered for each of a task's tags.
+	Tag lipgloss.Style
+
+	// Blocked is used for tasks waiting on another task to be done, or
+	// scheduled to start on a later day.
+	Blocked subStyle
 }
 
 // newTaskStyles constructs the default Styles used for rendering
@@ -109,6 +131,22 @@ func newTaskStyles() Styles {
 		FilterMatch: lipgloss.NewStyle().Underline(true),
 		StatusMessage: lipgloss.NewStyle().
 			Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}),
+		Priority: newPriorityStyles(),
+		Tag: lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"}).
+			Background(lipgloss.AdaptiveColor{Light: "#E5E1E6", Dark: "#3C3C3C"}).
+			Padding(0, 1),
+		Blocked: subStyle{
+			Title: lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"}).
+				Padding(0, 0, 0, 2), //nolint:mnd
+			Desc: lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#C2B8C2", Dark: "#4D4D4D"}).
+				Padding(0, 0, 0, 2),
+			Date: lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#C2B8C2", Dark: "#4D4D4D"}).
+				Padding(0, 0, 0, 2),
+		},
 	}
 }
 
@@ -119,9 +157,12 @@ func newTaskStyles() Styles {
 // TaskKeyMap defines key bindings for actions on a single task in
 // the list, such as editing, toggling completion, and removing.
 type TaskKeyMap struct {
-	EditItem   key.Binding
-	ToggleDone key.Binding
-	RemoveItem key.Binding
+	EditItem       key.Binding
+	ToggleDone     key.Binding
+	RemoveItem     key.Binding
+	ToggleSubtasks key.Binding
+	PickBlocker    key.Binding
+	ClearBlockers  key.Binding
 }
 
 // newTaskKeyMap constructs the default key bindings used for tasks
@@ -140,6 +181,18 @@ func newTaskKeyMap() *TaskKeyMap {
 			key.WithKeys("r"),
 			key.WithHelp("r", "remove item"),
 		),
+		ToggleSubtasks: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "expand/collapse subtasks"),
+		),
+		PickBlocker: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "add blocker"),
+		),
+		ClearBlockers: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "clear blockers"),
+		),
 	}
 }
 
@@ -161,6 +214,11 @@ func (t TaskKeyMap) FullHelp() [][]key.Binding {
 			t.EditItem,
 			t.RemoveItem,
 		},
+		{
+			t.ToggleSubtasks,
+			t.PickBlocker,
+			t.ClearBlockers,
+		},
 	}
 }
 
@@ -169,17 +227,56 @@ func (t TaskKeyMap) FullHelp() [][]key.Binding {
 //
 
 // Task represents a single task, including ID, title, description,
-// due date, and completion status.
+// optional due date and time and start date, completion status,
+// priority, tags, checklist items, an optional recurrence rule, the
+// list it belongs to, the tasks blocking it, and when it was created,
+// updated, and completed.
 type Task struct {
 	ID       uuid.UUID `json:"ID"`
 	TitleStr string    `json:"TitleStr"`
 	DescStr  string    `json:"DescStr"`
 	DueDate  time.Time `json:"DueDate"`
 	Done     bool      `json:"Done"`
+	Priority Priority  `json:"Priority"`
+	Tags     []string  `json:"Tags,omitempty"`
+	Subtasks []Subtask `json:"Subtasks,omitempty"`
+
+	// DueHasTime reports whether DueDate includes a time of day; without
+	// one the task is due any time on that calendar day. DueZone names
+	// the time zone the due time was set in.
+	DueHasTime bool   `json:"DueHasTime,omitempty"`
+	DueZone    string `json:"DueZone,omitempty"`
+
+	Recurrence *Recurrence `json:"Recurrence,omitempty"`
+
+	// StartDate is the day the task becomes actionable; until then it
+	// is shown dimmed. The zero time means the task can start any time,
+	// just as a zero DueDate means the task has no deadline.
+	StartDate time.Time `json:"StartDate,omitzero"`
+
+	// List names the task list the task belongs to; empty means the
+	// DefaultList.
+	List string `json:"List,omitempty"`
+
+	// BlockedBy lists the IDs of tasks that must be done before this
+	// one can start.
+	BlockedBy []uuid.UUID `json:"BlockedBy,omitempty"`
+
+	// CreatedAt, UpdatedAt, and CompletedAt are maintained by the
+	// service layer when tasks are saved or toggled.
+	CreatedAt   time.Time `json:"CreatedAt,omitzero"`
+	UpdatedAt   time.Time `json:"UpdatedAt,omitzero"`
+	CompletedAt time.Time `json:"CompletedAt,omitzero"`
 }
 
-// FilterValue implements list.Item and is used by the list filter.
-func (t Task) FilterValue() string { return t.TitleStr }
+// FilterValue implements list.Item and is used by the list filter. It
+// includes the task's tags so they can be searched alongside the title.
+func (t Task) FilterValue() string {
+	if len(t.Tags) == 0 {
+		return t.TitleStr
+	}
+	return t.TitleStr + " " + FormatTags(t.Tags)
+}
 
 // Title returns the task title.
 func (t Task) Title() string { return t.TitleStr }
@@ -197,13 +294,20 @@ func (t *Task) SetID(id uuid.UUID) {
 	t.ID = id
 }
 
-// IsEmpty reports whether the task has no title, description, due
-// date, and is not marked as done.
+// IsEmpty reports whether the task has no title, description, due or
+// start date, priority, tags, subtasks, recurrence, or blockers, and
+// is not marked as done.
 func (t Task) IsEmpty() bool {
 	return t.TitleStr == "" &&
 		t.DescStr == "" &&
 		t.DueDate.IsZero() &&
-		!t.Done
+		t.StartDate.IsZero() &&
+		!t.Done &&
+		t.Priority == PriorityNone &&
+		len(t.Tags) == 0 &&
+		len(t.Subtasks) == 0 &&
+		t.Recurrence == nil &&
+		len(t.BlockedBy) == 0
 }
 
 // New constructs a new, empty Task with a generated ID.
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -11,12 +12,24 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/x/ansi"
+	"github.com/google/uuid"
 )
 
 const (
 	defaultDelegateHeight  = 3
 	defaultDelegateSpacing = 1
 	dateFormat             = "2006-01-02"
+
+	// maxSubtaskLines is the number of extra lines reserved beneath each
+	// task for its subtasks while they are expanded.
+	maxSubtaskLines = 4
+
+	subtaskCheckedBox   = "[x] "
+	subtaskUncheckedBox = "[ ] "
+
+	recurrenceIndicator = "↻"
+	completedIndicator  = "✓"
+	blockedLabel        = "blocked by"
 )
 
 //
@@ -30,6 +43,13 @@ type TaskDelegate struct {
 	height  int
 	spacing int
 	keymap  *TaskKeyMap
+
+	// showSubtasks expands each task's checklist beneath it.
+	showSubtasks bool
+
+	// blockers holds the open blockers of each blocked task, among all
+	// tasks rather than only those in the list.
+	blockers map[uuid.UUID][]Task
 }
 
 // NewTaskDelegate constructs a TaskDelegate with default styles and keymap.
@@ -42,8 +62,31 @@ func NewTaskDelegate() TaskDelegate {
 	}
 }
 
-// Height returns the number of lines used to render each task.
-func (t TaskDelegate) Height() int { return t.height }
+// Height returns the number of lines used to render each task,
+// including the lines reserved for subtasks while they are expanded.
+func (t TaskDelegate) Height() int {
+	if t.showSubtasks {
+		return t.height + maxSubtaskLines
+	}
+	return t.height
+}
+
+// ShowSubtasks reports whether subtasks are expanded beneath each task.
+func (t TaskDelegate) ShowSubtasks() bool { return t.showSubtasks }
+
+// SetShowSubtasks expands or collapses the subtasks beneath each task.
+func (t TaskDelegate) SetShowSubtasks(v bool) TaskDelegate {
+	t.showSubtasks = v
+	return t
+}
+
+// SetTasks tells the delegate about every task, so that a task blocked
+// by one that is not in the list, such as one in another list, is shown
+// as blocked.
+func (t TaskDelegate) SetTasks(all []Task) TaskDelegate {
+	t.blockers = OpenBlockers(all)
+	return t
+}
 
 // Spacing returns the number of blank lines between rendered tasks.
 func (t TaskDelegate) Spacing() int { return t.spacing }
@@ -63,6 +106,21 @@ func (t TaskDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
 				return EnterEditMsg{}
 			}
 
+		case key.Matches(msg, t.keymap.ToggleSubtasks):
+			return func() tea.Msg {
+				return ToggleSubtasksMsg{}
+			}
+
+		case key.Matches(msg, t.keymap.PickBlocker):
+			return func() tea.Msg {
+				return PickBlockerMsg{}
+			}
+
+		case key.Matches(msg, t.keymap.ClearBlockers):
+			return func() tea.Msg {
+				return ClearBlockersMsg{}
+			}
+
 		case key.Matches(msg, t.keymap.RemoveItem):
 			if len(m.Items()) == 0 {
 				t.keymap.RemoveItem.SetEnabled(false)
@@ -85,6 +143,12 @@ func (t TaskDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		descStyle         lipgloss.Style
 		dateStyle         lipgloss.Style
 		done              bool
+		priority          Priority
+		tags              []string
+		subtasks          []Subtask
+		progress          string
+		blocked           bool
+		notStarted        bool
 		matchedRunes      []int
 		s                 = &t.Styles
 	)
@@ -100,7 +164,33 @@ func (t TaskDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		title = i.Title()
 		desc = i.Description()
 		done = i.Done
-		date = i.DueDate.Format(dateFormat)
+		priority = i.Priority
+		tags = i.Tags
+		subtasks = i.Subtasks
+		progress = i.ProgressString()
+		now := time.Now()
+		parts := []string{i.dueDescription()}
+		if i.IsOverdue(now) {
+			parts = append(parts, overdueLabel)
+		}
+		if i.NotStarted(now) {
+			notStarted = true
+			parts = append(parts, startsLabel+" "+i.StartDate.Format(dateFormat))
+		}
+		if progress != "" {
+			parts = append(parts, progress)
+		}
+		if i.IsRecurring() {
+			parts = append(parts, recurrenceIndicator+" "+i.Recurrence.String())
+		}
+		if i.Done && !i.CompletedAt.IsZero() {
+			parts = append(parts, completedIndicator+" "+i.CompletedAt.Local().Format(dateFormat))
+		}
+		if blockers := t.blockers[i.ID]; len(blockers) > 0 {
+			blocked = true
+			parts = append(parts, blockedDescription(blockers))
+		}
+		date = strings.Join(parts, "  ")
 	}
 
 	if m.Width() <= 0 {
@@ -111,7 +201,8 @@ func (t TaskDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 
 	// Prevent text from exceeding list width
 	textwidth := m.Width() - s.Normal.Title.GetPaddingLeft() - s.Normal.Title.GetPaddingRight()
-	title = ansi.Truncate(title, textwidth, ellipsis)
+	marker := t.priorityMarker(priority)
+	title = ansi.Truncate(title, textwidth-ansi.StringWidth(marker), ellipsis)
 
 	var lines []string
 	for i, line := range strings.Split(desc, "\n") {
@@ -149,15 +240,19 @@ func (t TaskDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		descStyle = s.Selected.Desc
 		dateStyle = s.Selected.Date
 	} else {
+		normal := s.Normal
+		if blocked || notStarted {
+			normal = s.Blocked
+		}
 		if isFiltered {
 			// Highlight matches
-			unmatched := s.Normal.Title.Inline(true)
+			unmatched := normal.Title.Inline(true)
 			matched := unmatched.Inherit(s.FilterMatch)
 			title = lipgloss.StyleRunes(title, matchedRunes, matched, unmatched)
 		}
-		titleStyle = s.Normal.Title
-		descStyle = s.Normal.Desc
-		dateStyle = s.Normal.Date
+		titleStyle = normal.Title
+		descStyle = normal.Desc
+		dateStyle = normal.Date
 	}
 
 	// Mark Done
@@ -174,15 +269,72 @@ func (t TaskDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 	n, err := fmt.Fprintf(
 		w,
 		"%s\n%s\n%s",
-		titleStyle.Render(title),
+		titleStyle.Render(marker+titleStyle.Inline(true).Render(title)),
 		descStyle.Render(desc),
-		dateStyle.Render(date),
+		dateStyle.Render(date)+t.tagChips(tags, textwidth-ansi.StringWidth(date)),
 	)
+	if err == nil && t.showSubtasks {
+		_, err = fmt.Fprint(w, t.subtaskLines(subtasks, descStyle, textwidth))
+	}
 	if err != nil {
 		log.Error("Failed to render delegate", "err", err, "bytes", n)
 	}
 }
 
+// blockedDescription names the first task blocking another, e.g.
+// "blocked by Write spec", noting how many other blockers remain.
+func blockedDescription(blockers []Task) string {
+	desc := blockedLabel + " " + blockers[0].Title()
+	if len(blockers) > 1 {
+		desc += fmt.Sprintf(" +%d", len(blockers)-1)
+	}
+	return desc
+}
+
+// priorityMarker returns the colored marker, including a trailing
+// space, drawn in front of a task title. Tasks without a priority get
+// no marker.
+func (t TaskDelegate) priorityMarker(p Priority) string {
+	style, ok := t.Styles.Priority[p]
+	if !ok {
+		return ""
+	}
+	return style.Render(priorityMarker) + " "
+}
+
+// subtaskLines renders up to maxSubtaskLines checklist items, each on
+// its own line, padding with blank lines so every task keeps the same
+// height. When there are more subtasks than fit, the last line reports
+// how many were left out.
+func (t TaskDelegate) subtaskLines(subtasks []Subtask, style lipgloss.Style, width int) string {
+	lines := make([]string, maxSubtaskLines)
+	for i, s := range subtasks {
+		if i == maxSubtaskLines-1 && len(subtasks) > maxSubtaskLines {
+			lines[i] = style.Render(fmt.Sprintf("%s %d more", ellipsis, len(subtasks)-i))
+			break
+		}
+		box := subtaskUncheckedBox
+		if s.Done {
+			box = subtaskCheckedBox
+		}
+		lines[i] = style.Render(ansi.Truncate(box+s.Title(), width, ellipsis))
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
+
+// tagChips renders a task's tags as chips, separated from the date by
+// a single space and truncated to fit within width.
+func (t TaskDelegate) tagChips(tags []string, width int) string {
+	if len(tags) == 0 || width <= 1 {
+		return ""
+	}
+	chips := make([]string, len(tags))
+	for i, tag := range tags {
+		chips[i] = t.Styles.Tag.Render(tagPrefix + tag)
+	}
+	return " " + ansi.Truncate(strings.Join(chips, " "), width-1, ellipsis)
+}
+
 // ShortHelp implements the help.KeyMap interface for condensed help
 // for task-related key bindings.
 func (t TaskDelegate) ShortHelp() []key.Binding {
@@ -202,5 +354,10 @@ func (t TaskDelegate) FullHelp() [][]key.Binding {
 			t.keymap.EditItem,
 			t.keymap.RemoveItem,
 		},
+		{
+			t.keymap.ToggleSubtasks,
+			t.keymap.PickBlocker,
+			t.keymap.ClearBlockers,
+		},
 	}
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
 )
 
 // Helpers
@@ -132,7 +133,7 @@ func TestTaskDelegateUpdate_IgnoresOtherKeys(t *testing.T) {
 	m := newTestList(items, d)
 
 	// Some unrelated key we don't bind.
-	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}}
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}}
 
 	cmd := d.Update(msg, &m)
 	if cmd != nil {
@@ -212,8 +213,8 @@ func TestTaskDelegateFullHelpBindings(t *testing.T) {
 	d := NewTaskDelegate()
 
 	full := d.FullHelp()
-	if len(full) != 1 {
-		t.Fatalf("FullHelp length = %d, want 1 row", len(full))
+	if len(full) != 2 {
+		t.Fatalf("FullHelp length = %d, want 2 rows", len(full))
 	}
 	if len(full[0]) != 3 {
 		t.Fatalf("FullHelp[0] length = %d, want 3", len(full[0]))
@@ -231,3 +232,115 @@ func TestTaskDelegateFullHelpBindings(t *testing.T) {
 		t.Errorf("FullHelp[0][2].Keys() = %v, want first key %q", keys, "r")
 	}
 }
+
+func TestTaskDelegateRender_PriorityMarker(t *testing.T) {
+	d := NewTaskDelegate()
+	items := []list.Item{
+		Task{TitleStr: "urgent", Priority: PriorityUrgent},
+		Task{TitleStr: "plain"},
+	}
+	m := newTestList(items, d)
+	m.SetWidth(40)
+
+	var buf bytes.Buffer
+	d.Render(&buf, m, 0, items[0])
+	if !bytes.Contains(buf.Bytes(), []byte(priorityMarker)) {
+		t.Errorf("expected priority marker in output, got %q", buf.String())
+	}
+
+	buf.Reset()
+	d.Render(&buf, m, 1, items[1])
+	if bytes.Contains(buf.Bytes(), []byte(priorityMarker)) {
+		t.Errorf("expected no priority marker for task without priority, got %q", buf.String())
+	}
+}
+
+func TestTaskDelegateRender_TagChips(t *testing.T) {
+	d := NewTaskDelegate()
+	items := []list.Item{Task{TitleStr: "deploy", Tags: []string{"backend", "oncall"}}}
+	m := newTestList(items, d)
+	m.SetWidth(60)
+
+	var buf bytes.Buffer
+	d.Render(&buf, m, 0, items[0])
+
+	for _, chip := range []string{"#backend", "#oncall"} {
+		if !bytes.Contains(buf.Bytes(), []byte(chip)) {
+			t.Errorf("expected tag chip %q in output, got %q", chip, buf.String())
+		}
+	}
+}
+
+func TestTaskDelegateUpdate_ToggleSubtasksSendsMsg(t *testing.T) {
+	d := NewTaskDelegate()
+	items := []list.Item{Task{TitleStr: "title"}}
+	m := newTestList(items, d)
+
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}}
+	cmd := d.Update(msg, &m)
+	if cmd == nil {
+		t.Fatalf("expected non-nil cmd for ToggleSubtasks key")
+	}
+	if _, ok := cmd().(ToggleSubtasksMsg); !ok {
+		t.Fatalf("expected ToggleSubtasksMsg")
+	}
+
+	expanded := d.SetShowSubtasks(true)
+	if got, want := expanded.Height(), defaultDelegateHeight+maxSubtaskLines; got != want {
+		t.Fatalf("expanded Height() = %d, want %d", got, want)
+	}
+}
+
+func TestTaskDelegateRender_SubtaskProgressAndLines(t *testing.T) {
+	d := NewTaskDelegate().SetShowSubtasks(true)
+	tk := Task{
+		TitleStr: "release",
+		Subtasks: []Subtask{
+			{TitleStr: "tag", Done: true},
+			{TitleStr: "changelog"},
+			{TitleStr: "announce"},
+		},
+	}
+	items := []list.Item{tk}
+	m := newTestList(items, d)
+	m.SetWidth(60)
+
+	var buf bytes.Buffer
+	d.Render(&buf, m, 0, items[0])
+	out := buf.String()
+
+	for _, want := range []string{"1/3", "[x] tag", "[ ] changelog"} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("expected %q in output, got %q", want, out)
+		}
+	}
+	if got, want := bytes.Count(buf.Bytes(), []byte("\n"))+1, d.Height(); got != want {
+		t.Errorf("rendered %d lines, want %d", got, want)
+	}
+}
+
+func TestTaskDelegateRender_BlockedShowsBlocker(t *testing.T) {
+	spec := Task{ID: uuid.New(), TitleStr: "write spec", List: "Planning"}
+	build := Task{ID: uuid.New(), TitleStr: "build", BlockedBy: []uuid.UUID{spec.ID}}
+	// Only build is in the list; its blocker is in another one.
+	d := NewTaskDelegate().SetTasks([]Task{spec, build})
+	items := []list.Item{build}
+	m := newTestList(items, d)
+	m.SetWidth(60)
+
+	var buf bytes.Buffer
+	d.Render(&buf, m, 0, items[0])
+	if !bytes.Contains(buf.Bytes(), []byte("blocked by write spec")) {
+		t.Errorf("expected blocker name in output, got %q", buf.String())
+	}
+
+	// Completing the blocker unblocks the dependent.
+	spec.Done = true
+	d = d.SetTasks([]Task{spec, build})
+
+	buf.Reset()
+	d.Render(&buf, m, 0, items[0])
+	if bytes.Contains(buf.Bytes(), []byte(blockedLabel)) {
+		t.Errorf("expected no blocker once it is done, got %q", buf.String())
+	}
+}
@@ -0,0 +1,158 @@
+package task
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampFormat is how creation, update, and completion times are
+// shown to the user.
+const timestampFormat = "2006-01-02 15:04"
+
+// Keys of the "key:range" filter terms that match tasks by when they
+// were created, last updated, or completed.
+const (
+	filterKeyCreated = "created"
+	filterKeyUpdated = "updated"
+	filterKeyDone    = "done"
+)
+
+// Stamp records that t is being saved at now. prev is the stored
+// version of the task, or nil when t is new. The creation time is
+// carried over from prev, and the completion time is set when the task
+// becomes done and cleared when it is reopened.
+func (t *Task) Stamp(prev *Task, now time.Time) {
+	switch {
+	case prev != nil && !prev.CreatedAt.IsZero():
+		t.CreatedAt = prev.CreatedAt
+	case prev == nil && t.CreatedAt.IsZero():
+		t.CreatedAt = now
+	}
+	t.UpdatedAt = now
+
+	switch {
+	case !t.Done:
+		t.CompletedAt = time.Time{}
+	case prev != nil && prev.Done && !prev.CompletedAt.IsZero():
+		t.CompletedAt = prev.CompletedAt
+	default:
+		t.CompletedAt = now
+	}
+}
+
+// FormatTimestamp renders a timestamp in local time for display, or
+// returns an empty string for the zero time.
+func FormatTimestamp(ts time.Time) string {
+	if ts.IsZero() {
+		return ""
+	}
+	return ts.Local().Format(timestampFormat)
+}
+
+// CompareCreated orders the most recently created tasks first, and
+// tasks with no recorded creation time last.
+func CompareCreated(a, b Task) int { return compareNewest(a.CreatedAt, b.CreatedAt) }
+
+// CompareUpdated orders the most recently updated tasks first.
+func CompareUpdated(a, b Task) int { return compareNewest(a.UpdatedAt, b.UpdatedAt) }
+
+// CompareCompleted orders the most recently completed tasks first, and
+// open tasks last.
+func CompareCompleted(a, b Task) int { return compareNewest(a.CompletedAt, b.CompletedAt) }
+
+// compareNewest orders later times first and zero times last.
+func compareNewest(a, b time.Time) int {
+	switch {
+	case a.IsZero() && b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	}
+	return b.Compare(a)
+}
+
+// SortBy sorts tasks in place using cmp. The sort is stable, so tasks
+// that compare equal keep their existing order.
+func SortBy(tasks []Task, cmp func(a, b Task) int) {
+	slices.SortStableFunc(tasks, cmp)
+}
+
+// timestamp returns the time t was created, updated, or completed,
+// as named by a timestamp filter key.
+func (t Task) timestamp(key string) time.Time {
+	switch key {
+	case filterKeyCreated:
+		return t.CreatedAt
+	case filterKeyUpdated:
+		return t.UpdatedAt
+	case filterKeyDone:
+		return t.CompletedAt
+	}
+	return time.Time{}
+}
+
+// cutTimestampTerm splits a "key:value" word whose key is one of the
+// timestamp filter keys.
+func cutTimestampTerm(word string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(word, ":")
+	if !ok || value == "" {
+		return "", "", false
+	}
+	switch key {
+	case filterKeyCreated, filterKeyUpdated, filterKeyDone:
+		return key, value, true
+	}
+	return "", "", false
+}
+
+// dateRange is the half-open range [from, to) of local calendar days.
+type dateRange struct {
+	from, to time.Time
+}
+
+// contains reports whether at falls within the range.
+func (r dateRange) contains(at time.Time) bool {
+	return !at.Before(r.from) && at.Before(r.to)
+}
+
+// parseDateRange interprets the value of a timestamp filter relative to
+// now: "today", "week" (since Monday), "month", "<N>d" for the last N
+// days including today, or a single "YYYY-MM-DD" day.
+func parseDateRange(value string, now time.Time) (dateRange, error) {
+	today := startOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	switch value {
+	case "today":
+		return dateRange{today, tomorrow}, nil
+	case "week":
+		sinceMonday := (int(today.Weekday()) + 6) % 7 //nolint:mnd
+		return dateRange{today.AddDate(0, 0, -sinceMonday), tomorrow}, nil
+	case "month":
+		return dateRange{today.AddDate(0, 0, 1-today.Day()), tomorrow}, nil
+	}
+
+	if n, ok := strings.CutSuffix(value, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil && days > 0 {
+			return dateRange{today.AddDate(0, 0, 1-days), tomorrow}, nil
+		}
+	}
+
+	day, err := time.ParseInLocation(dateFormat, value, now.Location())
+	if err != nil {
+		return dateRange{}, fmt.Errorf("unknown date range %q", value)
+	}
+	return dateRange{day, day.AddDate(0, 0, 1)}, nil
+}
+
+// startOfDay returns midnight at the start of t's calendar day in t's
+// location.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,150 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStamp_NewTask(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	tk := Task{TitleStr: "new"}
+	tk.Stamp(nil, now)
+
+	if !tk.CreatedAt.Equal(now) || !tk.UpdatedAt.Equal(now) {
+		t.Fatalf("CreatedAt = %v, UpdatedAt = %v, want both %v", tk.CreatedAt, tk.UpdatedAt, now)
+	}
+	if !tk.CompletedAt.IsZero() {
+		t.Fatalf("CompletedAt = %v, want zero for an open task", tk.CompletedAt)
+	}
+}
+
+func TestStamp_ExistingTask(t *testing.T) {
+	created := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	completed := time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	prev := Task{CreatedAt: created, UpdatedAt: created}
+
+	// Completing sets the completion time and keeps the creation time.
+	tk := Task{Done: true}
+	tk.Stamp(&prev, completed)
+	if !tk.CreatedAt.Equal(created) || !tk.CompletedAt.Equal(completed) {
+		t.Fatalf("CreatedAt = %v, CompletedAt = %v, want %v and %v", tk.CreatedAt, tk.CompletedAt, created, completed)
+	}
+
+	// Editing a done task keeps the original completion time.
+	edited := Task{Done: true}
+	edited.Stamp(&tk, now)
+	if !edited.CompletedAt.Equal(completed) || !edited.UpdatedAt.Equal(now) {
+		t.Fatalf("CompletedAt = %v, UpdatedAt = %v, want %v and %v", edited.CompletedAt, edited.UpdatedAt, completed, now)
+	}
+
+	// Reopening clears it.
+	reopened := Task{}
+	reopened.Stamp(&edited, now)
+	if !reopened.CompletedAt.IsZero() {
+		t.Fatalf("CompletedAt = %v, want zero after reopening", reopened.CompletedAt)
+	}
+}
+
+func TestTimestampsJSONOmitZero(t *testing.T) {
+	b, err := json.Marshal(Task{TitleStr: "legacy"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if contains(string(b), "CreatedAt") {
+		t.Fatalf("zero timestamps were written: %s", b)
+	}
+
+	created := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	b, err = json.Marshal(Task{CreatedAt: created})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got Task
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !got.CreatedAt.Equal(created) {
+		t.Fatalf("CreatedAt = %v, want %v", got.CreatedAt, created)
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	// Wednesday.
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		from, to string
+	}{
+		{"today", "2025-03-12", "2025-03-13"},
+		{"week", "2025-03-10", "2025-03-13"},
+		{"month", "2025-03-01", "2025-03-13"},
+		{"7d", "2025-03-06", "2025-03-13"},
+		{"2025-02-28", "2025-02-28", "2025-03-01"},
+	}
+
+	for _, tt := range tests {
+		r, err := parseDateRange(tt.value, now)
+		if err != nil {
+			t.Errorf("parseDateRange(%q) error = %v", tt.value, err)
+			continue
+		}
+		if got := r.from.Format(dateFormat); got != tt.from {
+			t.Errorf("parseDateRange(%q).from = %s, want %s", tt.value, got, tt.from)
+		}
+		if got := r.to.Format(dateFormat); got != tt.to {
+			t.Errorf("parseDateRange(%q).to = %s, want %s", tt.value, got, tt.to)
+		}
+	}
+
+	if _, err := parseDateRange("soon", now); err == nil {
+		t.Errorf("parseDateRange(%q) error = nil, want error", "soon")
+	}
+}
+
+func TestFilter_Timestamps(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local)
+	tasks := []Task{
+		{TitleStr: "done today", CompletedAt: now},
+		{TitleStr: "done last month", CompletedAt: now.AddDate(0, -1, 0)},
+		{TitleStr: "open", CreatedAt: now},
+	}
+	targets := make([]string, len(tasks))
+	for i, tk := range tasks {
+		targets[i] = tk.FilterValue()
+	}
+
+	tests := []struct {
+		term string
+		want []int
+	}{
+		{term: "done:week", want: []int{0}},
+		{term: "done:60d", want: []int{0, 1}},
+		{term: "created:today", want: []int{2}},
+		{term: "done:60d month", want: []int{1}},
+	}
+
+	for _, tt := range tests {
+		ranks := filterAt(tt.term, targets, tasks, now)
+		if len(ranks) != len(tt.want) {
+			t.Errorf("filterAt(%q) returned %d ranks, want %d", tt.term, len(ranks), len(tt.want))
+			continue
+		}
+		for i, r := range ranks {
+			if r.Index != tt.want[i] {
+				t.Errorf("filterAt(%q)[%d].Index = %d, want %d", tt.term, i, r.Index, tt.want[i])
+			}
+		}
+	}
+
+	// Fuzzy matching never sees the dates.
+	if ranks := filterAt("2025", targets, tasks, now); len(ranks) != 0 {
+		t.Errorf("filterAt(%q) matched %d dates, want none", "2025", len(ranks))
+	}
+	if got := tasks[0].FilterValue(); got != "done today" {
+		t.Errorf("FilterValue() = %q, want the title alone", got)
+	}
+}
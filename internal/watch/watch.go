@@ -0,0 +1,219 @@
+// Package watch reports changes made to files, such as a tasks file
+// edited by a script, another terminaltask session, or a sync tool. It
+// relies on file system notifications (inotify and its equivalents)
+// and falls back to polling where those are unavailable.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// DefaultPollInterval is how often files are checked when file
+	// system notifications are unavailable.
+	DefaultPollInterval = time.Second
+
+	// settleDelay groups the burst of events a single save produces,
+	// such as writing a temporary file and renaming it, into one change.
+	settleDelay = 100 * time.Millisecond
+)
+
+// Watcher reports changes to a set of files on its Changes channel.
+type Watcher struct {
+	changes chan struct{}
+	done    chan struct{}
+	stop    sync.Once
+	wg      sync.WaitGroup
+
+	// notify is the file system watcher, or nil when polling.
+	notify *fsnotify.Watcher
+}
+
+// New starts watching the files at paths, which need not exist yet.
+// Files are watched through their directories, so replacing a file by
+// renaming another one over it counts as a change too.
+func New(paths ...string) *Watcher {
+	w := newWatcher()
+
+	names := make(map[string]bool, len(paths))
+	dirs := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		names[p] = true
+		dirs[filepath.Dir(p)] = true
+	}
+
+	notify, err := fsnotify.NewWatcher()
+	if err == nil {
+		for dir := range dirs {
+			if err = notify.Add(dir); err != nil {
+				_ = notify.Close()
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Warn("file notifications unavailable, polling for changes", "err", err)
+		w.startPolling(paths, DefaultPollInterval)
+		return w
+	}
+
+	w.notify = notify
+	w.wg.Add(1)
+	go w.listen(names)
+	return w
+}
+
+// newPolling returns a Watcher that checks the files at paths every
+// interval.
+func newPolling(interval time.Duration, paths ...string) *Watcher {
+	w := newWatcher()
+	w.startPolling(paths, interval)
+	return w
+}
+
+func newWatcher() *Watcher {
+	return &Watcher{
+		// A pending change is enough; later ones merge into it.
+		changes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// Changes returns the channel on which changes are reported. Changes
+// that happen before the previous one was received are merged into it.
+// The channel is closed by Close.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Polling reports whether the files are polled rather than watched
+// through file system notifications.
+func (w *Watcher) Polling() bool {
+	return w.notify == nil
+}
+
+// Close stops watching and closes the Changes channel.
+func (w *Watcher) Close() error {
+	var err error
+	w.stop.Do(func() {
+		close(w.done)
+		if w.notify != nil {
+			err = w.notify.Close()
+		}
+		w.wg.Wait()
+		close(w.changes)
+	})
+	return err
+}
+
+// changed reports a change unless one is already pending.
+func (w *Watcher) changed() {
+	select {
+	case w.changes <- struct{}{}:
+	default:
+	}
+}
+
+// listen turns file system events on the watched files into changes,
+// once they have settled.
+func (w *Watcher) listen(names map[string]bool) {
+	defer w.wg.Done()
+
+	var (
+		timer  *time.Timer
+		settle <-chan time.Time
+	)
+	for {
+		select {
+		case <-w.done:
+			return
+
+		case event, ok := <-w.notify.Events:
+			if !ok {
+				return
+			}
+			if !names[filepath.Clean(event.Name)] {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(settleDelay)
+			} else {
+				timer.Reset(settleDelay)
+			}
+			settle = timer.C
+
+		case err, ok := <-w.notify.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been dropped, so assume the worst.
+			log.Warn("watching files", "err", err)
+			w.changed()
+
+		case <-settle:
+			settle = nil
+			w.changed()
+		}
+	}
+}
+
+// stat returns the file info of path, or nil when it cannot be read.
+func stat(path string) os.FileInfo {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// unchanged reports whether two stats of a file show the same contents,
+// going by identity, modification time, and size.
+func unchanged(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// startPolling records the current state of the files at paths, then
+// checks them for changes every interval.
+func (w *Watcher) startPolling(paths []string, interval time.Duration) {
+	infos := make([]os.FileInfo, len(paths))
+	for i, p := range paths {
+		infos[i] = stat(p)
+	}
+	w.wg.Add(1)
+	go w.poll(paths, infos, interval)
+}
+
+// poll compares the files at paths against infos every interval.
+func (w *Watcher) poll(paths []string, infos []os.FileInfo, interval time.Duration) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			changed := false
+			for i, p := range paths {
+				if info := stat(p); !unchanged(info, infos[i]) {
+					infos[i] = info
+					changed = true
+				}
+			}
+			if changed {
+				w.changed()
+			}
+		}
+	}
+}
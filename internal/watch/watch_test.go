@@ -0,0 +1,107 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitTimeout bounds how long a test waits for a change to be reported.
+const waitTimeout = 5 * time.Second
+
+func expectChange(t *testing.T, w *Watcher) {
+	t.Helper()
+
+	select {
+	case _, ok := <-w.Changes():
+		if !ok {
+			t.Fatal("Changes() closed, want a change")
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("no change reported")
+	}
+}
+
+func expectNoChange(t *testing.T, w *Watcher, within time.Duration) {
+	t.Helper()
+
+	select {
+	case <-w.Changes():
+		t.Fatal("change reported, want none")
+	case <-time.After(within):
+	}
+}
+
+// replace writes data to path the way the stores do: through a
+// temporary file renamed over it.
+func replace(t *testing.T, path, data string) {
+	t.Helper()
+
+	tmp := path + ".1234.tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+}
+
+func TestWatcher_Notify(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+
+	w := New(path)
+	defer w.Close()
+	if w.Polling() {
+		t.Skip("file notifications unavailable")
+	}
+
+	// Other files in the directory are ignored.
+	if err := os.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	expectNoChange(t, w, 3*settleDelay)
+
+	// The file does not need to exist when watching starts.
+	replace(t, path, "[]")
+	expectChange(t, w)
+
+	replace(t, path, "[{}]")
+	expectChange(t, w)
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	w := newPolling(10*time.Millisecond, path)
+	defer w.Close()
+	if !w.Polling() {
+		t.Fatal("Polling() = false, want true")
+	}
+
+	replace(t, path, "[]")
+	expectChange(t, w)
+
+	expectNoChange(t, w, 50*time.Millisecond)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	expectChange(t, w)
+}
+
+func TestWatcher_CloseClosesChanges(t *testing.T) {
+	w := newPolling(time.Hour, filepath.Join(t.TempDir(), "tasks.json"))
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, ok := <-w.Changes(); ok {
+		t.Fatal("Changes() still open after Close")
+	}
+	// Closing twice is harmless.
+	if err := w.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+}